@@ -0,0 +1,292 @@
+package axis
+
+import (
+	"image/color"
+	"math"
+
+	"loov.dev/diagram"
+)
+
+// Position is the side of the plot area where the axis is drawn.
+type Position int
+
+const (
+	Bottom Position = iota
+	Left
+	Top
+	Right
+)
+
+// Vertical returns whether the axis runs vertically.
+func (pos Position) Vertical() bool { return pos == Left || pos == Right }
+
+// Axis draws ticks, labels and grid lines for a plot area.
+type Axis struct {
+	Position Position
+	Ticks    []Tick
+	// Map converts a tick value to a coordinate along the axis,
+	// X for horizontal and Y for vertical axes.
+	Map func(value float64) diagram.Length
+
+	// Title is drawn beyond the labels.
+	Title string
+
+	// Measurer is used to compute the space taken by labels.
+	Measurer diagram.Measurer
+
+	Theme struct {
+		TickLength      diagram.Length
+		MinorTickLength diagram.Length
+		LabelPadding    diagram.Length
+
+		Line      diagram.Style
+		Tick      diagram.Style
+		MinorTick diagram.Style
+		Label     diagram.Style
+		Title     diagram.Style
+		// Grid and MinorGrid lines are drawn across the plot area,
+		// they are omitted when the stroke is nil.
+		Grid      diagram.Style
+		MinorGrid diagram.Style
+	}
+}
+
+// New creates an axis with the default theme.
+func New(pos Position, ticks []Tick, fn func(float64) diagram.Length) *Axis {
+	axis := &Axis{}
+	axis.Position = pos
+	axis.Ticks = ticks
+	axis.Map = fn
+	axis.Measurer = diagram.DefaultMeasurer
+
+	axis.Theme.TickLength = 6
+	axis.Theme.MinorTickLength = 3
+	axis.Theme.LabelPadding = 4
+
+	axis.Theme.Line = diagram.Style{
+		Stroke: color.NRGBA{0x40, 0x40, 0x40, 0xFF},
+		Size:   1,
+	}
+	axis.Theme.Tick = axis.Theme.Line
+	axis.Theme.MinorTick = axis.Theme.Line
+	axis.Theme.Label = diagram.Style{
+		Fill: color.NRGBA{0x40, 0x40, 0x40, 0xFF},
+		Size: 12,
+	}
+	axis.Theme.Title = diagram.Style{
+		Fill: color.NRGBA{0x20, 0x20, 0x20, 0xFF},
+		Size: 14,
+	}
+	axis.Theme.Grid = diagram.Style{
+		Stroke: color.NRGBA{0xD0, 0xD0, 0xD0, 0xFF},
+		Size:   1,
+	}
+	axis.Theme.MinorGrid = diagram.Style{}
+
+	return axis
+}
+
+// edge returns the coordinate of the axis line and
+// the direction away from the plot area.
+func (axis *Axis) edge(area diagram.Rect) (at, dir diagram.Length) {
+	switch axis.Position {
+	case Left:
+		return area.Min.X, -1
+	case Right:
+		return area.Max.X, 1
+	case Top:
+		return area.Min.Y, -1
+	default:
+		return area.Max.Y, 1
+	}
+}
+
+// point returns a point at position along the axis and offset
+// across the axis.
+func (axis *Axis) point(along, across diagram.Length) diagram.Point {
+	if axis.Position.Vertical() {
+		return diagram.Point{X: across, Y: along}
+	}
+	return diagram.Point{X: along, Y: across}
+}
+
+// labelStyle returns the label style anchored towards the axis.
+func (axis *Axis) labelStyle() *diagram.Style {
+	style := axis.Theme.Label
+	switch axis.Position {
+	case Left:
+		style.Origin = diagram.Point{X: 1, Y: 0}
+	case Right:
+		style.Origin = diagram.Point{X: -1, Y: 0}
+	case Top:
+		style.Origin = diagram.Point{X: 0, Y: 1}
+	default:
+		style.Origin = diagram.Point{X: 0, Y: -1}
+	}
+	return &style
+}
+
+// labelExtent returns the space taken by labels across the axis.
+func (axis *Axis) labelExtent() diagram.Length {
+	style := axis.labelStyle()
+	extent := diagram.Length(0)
+	for _, tick := range axis.Ticks {
+		if tick.Label == "" {
+			continue
+		}
+		size := axis.Measurer.Measure(tick.Label, style).Size()
+		if axis.Position.Vertical() {
+			extent = max(extent, size.X)
+		} else {
+			extent = max(extent, size.Y)
+		}
+	}
+	return extent
+}
+
+// titleStyle returns the title style, vertical axes use rotated titles.
+func (axis *Axis) titleStyle() *diagram.Style {
+	style := axis.Theme.Title
+	switch axis.Position {
+	case Left:
+		style.Rotation = -math.Pi / 2
+		style.Origin = diagram.Point{X: 0, Y: 1}
+	case Right:
+		style.Rotation = math.Pi / 2
+		style.Origin = diagram.Point{X: 0, Y: 1}
+	case Top:
+		style.Origin = diagram.Point{X: 0, Y: 1}
+	default:
+		style.Origin = diagram.Point{X: 0, Y: -1}
+	}
+	return &style
+}
+
+// Extent returns the space taken by the axis outside of the plot area.
+func (axis *Axis) Extent() diagram.Length {
+	extent := axis.Theme.TickLength
+	if labels := axis.labelExtent(); labels > 0 {
+		extent += axis.Theme.LabelPadding + labels
+	}
+	if axis.Title != "" {
+		size := axis.Measurer.Measure(axis.Title, &axis.Theme.Title).Size()
+		extent += axis.Theme.LabelPadding + size.Y
+	}
+	return extent
+}
+
+// Bounds returns area extended by the space taken by the axis,
+// including labels overflowing the ends of the axis.
+func (axis *Axis) Bounds(area diagram.Rect) diagram.Rect {
+	at, dir := axis.edge(area)
+	outer := at + dir*axis.Extent()
+
+	bounds := area
+	include := func(p diagram.Point) {
+		bounds.Min = bounds.Min.Min(p)
+		bounds.Max = bounds.Max.Max(p)
+	}
+	include(axis.point(axis.along(area), outer))
+
+	style := axis.labelStyle()
+	offset := at + dir*(axis.Theme.TickLength+axis.Theme.LabelPadding)
+	for _, tick := range axis.Ticks {
+		if tick.Label == "" {
+			continue
+		}
+		r := axis.Measurer.Measure(tick.Label, style).Offset(axis.point(axis.Map(tick.Value), offset))
+		include(r.Min)
+		include(r.Max)
+	}
+	return bounds
+}
+
+// along returns the start of the axis line.
+func (axis *Axis) along(area diagram.Rect) diagram.Length {
+	if axis.Position.Vertical() {
+		return area.Min.Y
+	}
+	return area.Min.X
+}
+
+// Draw draws the axis on the edge of the plot area.
+func (axis *Axis) Draw(canvas diagram.Canvas, area diagram.Rect) {
+	at, dir := axis.edge(area)
+
+	// grid lines span the plot area
+	var gridFrom, gridTo diagram.Length
+	if axis.Position.Vertical() {
+		gridFrom, gridTo = area.Min.X, area.Max.X
+	} else {
+		gridFrom, gridTo = area.Min.Y, area.Max.Y
+	}
+
+	for _, tick := range axis.Ticks {
+		style := &axis.Theme.Grid
+		if tick.Minor {
+			style = &axis.Theme.MinorGrid
+		}
+		if style.Stroke == nil {
+			continue
+		}
+		v := axis.Map(tick.Value)
+		canvas.Poly([]diagram.Point{
+			axis.point(v, gridFrom),
+			axis.point(v, gridTo),
+		}, style)
+	}
+
+	if axis.Position.Vertical() {
+		canvas.Poly([]diagram.Point{
+			{X: at, Y: area.Min.Y},
+			{X: at, Y: area.Max.Y},
+		}, &axis.Theme.Line)
+	} else {
+		canvas.Poly([]diagram.Point{
+			{X: area.Min.X, Y: at},
+			{X: area.Max.X, Y: at},
+		}, &axis.Theme.Line)
+	}
+
+	labelStyle := axis.labelStyle()
+	labelAt := at + dir*(axis.Theme.TickLength+axis.Theme.LabelPadding)
+	for _, tick := range axis.Ticks {
+		v := axis.Map(tick.Value)
+
+		style, length := &axis.Theme.Tick, axis.Theme.TickLength
+		if tick.Minor {
+			style, length = &axis.Theme.MinorTick, axis.Theme.MinorTickLength
+		}
+		canvas.Poly([]diagram.Point{
+			axis.point(v, at),
+			axis.point(v, at+dir*length),
+		}, style)
+
+		if tick.Label != "" {
+			canvas.Text(tick.Label, axis.point(v, labelAt), labelStyle)
+		}
+	}
+
+	if axis.Title != "" {
+		var center diagram.Length
+		if axis.Position.Vertical() {
+			center = (area.Min.Y + area.Max.Y) / 2
+		} else {
+			center = (area.Min.X + area.Max.X) / 2
+		}
+
+		outer := axis.Theme.TickLength
+		if labels := axis.labelExtent(); labels > 0 {
+			outer += axis.Theme.LabelPadding + labels
+		}
+		outer += axis.Theme.LabelPadding
+		canvas.Text(axis.Title, axis.point(center, at+dir*outer), axis.titleStyle())
+	}
+}
+
+func max(a, b diagram.Length) diagram.Length {
+	if a > b {
+		return a
+	}
+	return b
+}
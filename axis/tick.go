@@ -0,0 +1,161 @@
+// Package axis implements tick generation and drawing of plot axes.
+package axis
+
+import (
+	"math"
+	"strconv"
+
+	"loov.dev/diagram"
+)
+
+// Tick is a single marked value on an axis.
+type Tick struct {
+	Value float64
+	Label string
+	// Minor ticks are drawn smaller and without a label.
+	Minor bool
+}
+
+// epsilon is used to avoid losing ticks at range ends due to rounding.
+const epsilon = 1e-9
+
+// Nice extends [min, max] to multiples of a nice step,
+// such that the range is split into approximately count intervals.
+func Nice(min, max float64, count int) (niceMin, niceMax, step float64) {
+	step = niceStep(min, max, count)
+	if step == 0 {
+		return min, max, 0
+	}
+	niceMin = math.Floor(min/step+epsilon) * step
+	niceMax = math.Ceil(max/step-epsilon) * step
+	return niceMin, niceMax, step
+}
+
+// niceStep returns a nice step for splitting [min, max] into count intervals.
+func niceStep(min, max float64, count int) float64 {
+	if count < 1 {
+		count = 1
+	}
+	span := max - min
+	if !(span > 0) || math.IsInf(span, 0) {
+		return 0
+	}
+	return diagram.NiceNumber(span/float64(count), true)
+}
+
+// minorStep returns a subdivision for a 1, 2 or 5 times power of ten step.
+func minorStep(step float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(step)))
+	switch math.Round(step / exp) {
+	case 2:
+		return step / 4
+	default:
+		return step / 5
+	}
+}
+
+// Linear returns ticks for values in [min, max],
+// with approximately count major ticks.
+func Linear(min, max float64, count int) []Tick {
+	step := niceStep(min, max, count)
+	if step == 0 {
+		return nil
+	}
+
+	decimals := int(-math.Floor(math.Log10(step) + epsilon))
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	return steps(min, max, step, minorStep(step), func(v float64) string {
+		return FormatFloat(v, decimals)
+	})
+}
+
+// steps returns ticks at multiples of minor in [min, max],
+// ticks at multiples of major are labeled.
+func steps(min, max, major, minor float64, label func(float64) string) []Tick {
+	div := int64(math.Round(major / minor))
+	if div < 1 {
+		div, minor = 1, major
+	}
+
+	var ticks []Tick
+	first := int64(math.Ceil(min/minor - epsilon))
+	last := int64(math.Floor(max/minor + epsilon))
+	for i := first; i <= last; i++ {
+		value := float64(i) * minor
+		if i%div != 0 {
+			ticks = append(ticks, Tick{Value: value, Minor: true})
+			continue
+		}
+		ticks = append(ticks, Tick{Value: value, Label: label(value)})
+	}
+	return ticks
+}
+
+// Log returns ticks for values in [min, max] on a logarithmic axis.
+//
+// Major ticks are placed at powers of base, minor ticks at
+// the integer multiples in between. When the range contains
+// less than two powers, the minor ticks are labeled as well.
+func Log(min, max, base float64) []Tick {
+	if !(min > 0) || !(max > min) || !(base > 1) {
+		return nil
+	}
+
+	lo := math.Floor(math.Log(min)/math.Log(base) + epsilon)
+	hi := math.Ceil(math.Log(max)/math.Log(base) - epsilon)
+	inside := func(v float64) bool {
+		return v >= min*(1-epsilon) && v <= max*(1+epsilon)
+	}
+
+	var ticks []Tick
+	majors := 0
+	for exp := lo; exp <= hi; exp++ {
+		power := math.Pow(base, exp)
+		if inside(power) {
+			ticks = append(ticks, Tick{Value: power, Label: formatLog(power)})
+			majors++
+		}
+		if hi-lo > 6 || base != math.Floor(base) {
+			continue
+		}
+		for m := 2.0; m < base; m++ {
+			if v := m * power; inside(v) {
+				ticks = append(ticks, Tick{Value: v, Minor: true})
+			}
+		}
+	}
+
+	if majors < 2 {
+		for i := range ticks {
+			if ticks[i].Minor {
+				ticks[i].Minor = false
+				ticks[i].Label = formatLog(ticks[i].Value)
+			}
+		}
+	}
+	return ticks
+}
+
+// formatLog formats values on a logarithmic axis, using
+// exponent notation for very large and small values.
+func formatLog(v float64) string {
+	if v >= 1e-4 && v < 1e7 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 3, 64)
+}
+
+// FormatFloat formats v with the specified number of decimals.
+func FormatFloat(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	// avoid printing negative zero
+	if v < 0 {
+		if rounded, err := strconv.ParseFloat(s, 64); err == nil && rounded == 0 {
+			return s[1:]
+		}
+	}
+	return s
+}
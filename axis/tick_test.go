@@ -0,0 +1,64 @@
+package axis_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"loov.dev/diagram/axis"
+)
+
+func labels(ticks []axis.Tick) string {
+	var s []string
+	for _, tick := range ticks {
+		if !tick.Minor {
+			s = append(s, tick.Label)
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+func TestTicks(t *testing.T) {
+	t0 := time.Date(2020, 3, 14, 15, 9, 26, 0, time.UTC)
+	tests := []struct {
+		name  string
+		ticks []axis.Tick
+		exp   string
+	}{
+		{"linear", axis.Linear(0, 1, 5), "0.0 0.2 0.4 0.6 0.8 1.0"},
+		{"linear-negative", axis.Linear(-12, 9, 4), "-10 -5 0 5"},
+		{"log", axis.Log(1, 1e4, 10), "1 10 100 1000 10000"},
+		{"log-narrow", axis.Log(2, 5, 10), "2 3 4 5"},
+		{"duration", axis.Duration(0, 200*time.Second, 4), "0 1m 2m 3m"},
+		{"duration-ms", axis.Duration(0, 5*time.Millisecond, 4), "0 2ms 4ms"},
+		{"time", axis.Time(t0, t0.Add(5*time.Hour), 5), "16:00 17:00 18:00 19:00 20:00"},
+		{"time-date", axis.Time(t0, t0.Add(20*time.Hour), 3), "18:00 Mar 15 06:00"},
+		{"time-month", axis.Time(t0, t0.AddDate(1, 0, 0), 4), "Apr 2020 Jul 2020 Oct 2020 Jan 2021"},
+	}
+
+	for _, test := range tests {
+		if got := labels(test.ticks); got != test.exp {
+			t.Errorf("%v: got %q expected %q", test.name, got, test.exp)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d   time.Duration
+		exp string
+	}{
+		{0, "0"},
+		{1500 * time.Millisecond, "1.5s"},
+		{90 * time.Second, "1m30s"},
+		{2 * time.Hour, "2h"},
+		{36 * time.Hour, "1d12h"},
+		{-time.Minute, "-1m"},
+		{250 * time.Microsecond, "250µs"},
+	}
+	for _, test := range tests {
+		if got := axis.FormatDuration(test.d); got != test.exp {
+			t.Errorf("%v: got %q expected %q", test.d, got, test.exp)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package axis
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"loov.dev/diagram"
+)
+
+const day = 24 * time.Hour
+
+// durationSteps are the preferred steps for duration and time axes.
+var durationSteps = []struct{ step, minor time.Duration }{
+	{time.Second, 200 * time.Millisecond},
+	{2 * time.Second, 500 * time.Millisecond},
+	{5 * time.Second, time.Second},
+	{10 * time.Second, 2 * time.Second},
+	{15 * time.Second, 5 * time.Second},
+	{30 * time.Second, 10 * time.Second},
+	{time.Minute, 10 * time.Second},
+	{2 * time.Minute, 30 * time.Second},
+	{5 * time.Minute, time.Minute},
+	{10 * time.Minute, 2 * time.Minute},
+	{15 * time.Minute, 5 * time.Minute},
+	{30 * time.Minute, 10 * time.Minute},
+	{time.Hour, 10 * time.Minute},
+	{2 * time.Hour, 30 * time.Minute},
+	{3 * time.Hour, time.Hour},
+	{6 * time.Hour, time.Hour},
+	{12 * time.Hour, 2 * time.Hour},
+	{day, 6 * time.Hour},
+}
+
+// durationStep returns a nice step for splitting span into count intervals.
+func durationStep(span time.Duration, count int) (step, minor time.Duration) {
+	if count < 1 {
+		count = 1
+	}
+	target := span / time.Duration(count)
+
+	switch {
+	case target < time.Second:
+		nice := diagram.NiceNumber(float64(target), false)
+		if nice < 1 {
+			return 1, 1
+		}
+		return time.Duration(nice), time.Duration(minorStep(nice))
+	case target > day:
+		days := diagram.NiceNumber(float64(target)/float64(day), false)
+		return time.Duration(days * float64(day)), time.Duration(minorStep(days) * float64(day))
+	}
+
+	best := durationSteps[0]
+	for _, candidate := range durationSteps[1:] {
+		if closer(candidate.step, best.step, target) {
+			best = candidate
+		}
+	}
+	return best.step, best.minor
+}
+
+// closer returns whether a is closer to target than b on a logarithmic scale.
+func closer(a, b, target time.Duration) bool {
+	return math.Abs(math.Log(float64(a)/float64(target))) < math.Abs(math.Log(float64(b)/float64(target)))
+}
+
+// Duration returns ticks for durations in [min, max],
+// with approximately count major ticks.
+//
+// Tick values are in nanoseconds, i.e. float64(time.Duration).
+func Duration(min, max time.Duration, count int) []Tick {
+	if max <= min {
+		return nil
+	}
+
+	step, minor := durationStep(max-min, count)
+	return steps(float64(min), float64(max), float64(step), float64(minor), func(v float64) string {
+		return FormatDuration(time.Duration(math.Round(v)))
+	})
+}
+
+// FormatDuration formats duration compactly, e.g. "1h30m", "2d" or "1.5s".
+//
+// Unlike time.Duration.String, zero units are omitted.
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+
+	var s strings.Builder
+	if d < 0 {
+		s.WriteByte('-')
+		d = -d
+	}
+	if d < time.Second {
+		s.WriteString(d.String())
+		return s.String()
+	}
+
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{day, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+	}
+	for _, u := range units {
+		if d >= u.unit {
+			s.WriteString(strconv.FormatInt(int64(d/u.unit), 10))
+			s.WriteString(u.name)
+			d %= u.unit
+		}
+	}
+
+	if d > 0 {
+		s.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+		s.WriteString("s")
+	}
+	return s.String()
+}
+
+// calendarStep is a step on a time axis, which respects calendar units.
+type calendarStep struct {
+	months   int
+	days     int
+	duration time.Duration
+}
+
+func (step calendarStep) add(t time.Time, n int) time.Time {
+	if step.months != 0 || step.days != 0 {
+		return t.AddDate(0, n*step.months, n*step.days)
+	}
+	return t.Add(time.Duration(n) * step.duration)
+}
+
+// approx returns the approximate length of the step.
+func (step calendarStep) approx() time.Duration {
+	return time.Duration(step.months)*30*day + time.Duration(step.days)*day + step.duration
+}
+
+// floor returns the last step boundary before t.
+func (step calendarStep) floor(t time.Time) time.Time {
+	year, month, date := t.Date()
+	switch {
+	case step.months > 0:
+		index := year*12 + int(month) - 1
+		index -= mod(index, step.months)
+		return time.Date(index/12, time.Month(index%12+1), 1, 0, 0, 0, 0, t.Location())
+	case step.days > 0:
+		midnight := time.Date(year, month, date, 0, 0, 0, 0, t.Location())
+		if step.days == 7 {
+			// weeks start on monday
+			return midnight.AddDate(0, 0, -mod(int(midnight.Weekday())-1, 7))
+		}
+		return midnight
+	default:
+		midnight := time.Date(year, month, date, 0, 0, 0, 0, t.Location())
+		n := t.Sub(midnight) / step.duration
+		return midnight.Add(n * step.duration)
+	}
+}
+
+func mod(a, b int) int {
+	r := a % b
+	if r < 0 {
+		r += b
+	}
+	return r
+}
+
+// timeSteps are the preferred calendar steps for time axes longer than a day.
+var timeSteps = []struct {
+	step, minor calendarStep
+	layout      string
+}{
+	{calendarStep{days: 1}, calendarStep{duration: 6 * time.Hour}, "Jan 2"},
+	{calendarStep{days: 2}, calendarStep{days: 1}, "Jan 2"},
+	{calendarStep{days: 7}, calendarStep{days: 1}, "Jan 2"},
+	{calendarStep{months: 1}, calendarStep{}, "Jan 2006"},
+	{calendarStep{months: 3}, calendarStep{months: 1}, "Jan 2006"},
+	{calendarStep{months: 6}, calendarStep{months: 1}, "Jan 2006"},
+}
+
+// timeStep returns a nice calendar step for splitting span into count intervals.
+func timeStep(span time.Duration, count int) (step, minor calendarStep, layout string) {
+	if count < 1 {
+		count = 1
+	}
+	target := span / time.Duration(count)
+
+	if target < day {
+		step, minor := durationStep(span, count)
+		layout := "15:04"
+		switch {
+		case step < time.Millisecond:
+			layout = "15:04:05.000000"
+		case step < time.Second:
+			layout = "15:04:05.000"
+		case step < time.Minute:
+			layout = "15:04:05"
+		}
+		return calendarStep{duration: step}, calendarStep{duration: minor}, layout
+	}
+
+	if target < 9*30*day {
+		best := timeSteps[0]
+		for _, candidate := range timeSteps[1:] {
+			if closer(candidate.step.approx(), best.step.approx(), target) {
+				best = candidate
+			}
+		}
+		return best.step, best.minor, best.layout
+	}
+
+	years := diagram.NiceNumber(float64(target)/float64(365*day), false)
+	if years < 1 {
+		years = 1
+	}
+	minorYears := minorStep(years)
+	if minorYears < 1 {
+		return calendarStep{months: int(years) * 12}, calendarStep{months: 3}, "2006"
+	}
+	return calendarStep{months: int(years) * 12}, calendarStep{months: int(minorYears) * 12}, "2006"
+}
+
+// Time returns ticks for times in [min, max],
+// with approximately count major ticks.
+//
+// Ticks are aligned to calendar units in the location of min.
+// Tick values are nanoseconds since Unix epoch, i.e. float64(t.UnixNano()).
+func Time(min, max time.Time, count int) []Tick {
+	if !max.After(min) {
+		return nil
+	}
+
+	step, minor, layout := timeStep(max.Sub(min), count)
+	if minor.approx() <= 0 {
+		minor = step
+	}
+
+	var ticks []Tick
+	start := step.floor(min)
+	for i := 0; ; i++ {
+		major := step.add(start, i)
+		if major.After(max) {
+			break
+		}
+		if !major.Before(min) {
+			label := major.Format(layout)
+			// mark the change of date on axes shorter than a day
+			if step.duration > 0 && major.Hour() == 0 && major.Minute() == 0 && major.Second() == 0 && major.Nanosecond() == 0 {
+				label = major.Format("Jan 2")
+			}
+			ticks = append(ticks, Tick{Value: float64(major.UnixNano()), Label: label})
+		}
+
+		next := step.add(start, i+1)
+		for k := 1; ; k++ {
+			t := minor.add(major, k)
+			if !t.Before(next) || t.After(max) {
+				break
+			}
+			if !t.Before(min) {
+				ticks = append(ticks, Tick{Value: float64(t.UnixNano()), Minor: true})
+			}
+		}
+	}
+	return ticks
+}
+
+// NiceTime extends [min, max] to the calendar boundaries
+// of the step used by Time for approximately count ticks.
+func NiceTime(min, max time.Time, count int) (niceMin, niceMax time.Time) {
+	if !max.After(min) {
+		return min, max
+	}
+
+	step, _, _ := timeStep(max.Sub(min), count)
+	niceMin = step.floor(min)
+	niceMax = step.floor(max)
+	if niceMax.Before(max) {
+		niceMax = step.add(niceMax, 1)
+	}
+	return niceMin, niceMax
+}
@@ -5,7 +5,9 @@ type Canvas interface {
 	Layer(index int) Canvas
 	Clip(r Rect) Canvas
 	Context(r Rect) Canvas
+	Transform(m Affine) Canvas
 	Text(text string, at Point, style *Style)
 	Poly(points []Point, style *Style)
 	Rect(r Rect, style *Style)
+	Path(path *Path, style *Style)
 }
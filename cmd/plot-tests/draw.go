@@ -2,9 +2,12 @@ package main
 
 import (
 	"image/color"
+	"math"
 	"time"
 
 	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
 )
 
 type Config struct {
@@ -23,6 +26,7 @@ type Plot struct {
 	y    float64
 	line int
 	span Span
+	x    *scale.Time
 
 	SVG   *diagram.SVG
 	Grid  diagram.Canvas
@@ -40,6 +44,8 @@ func RenderSVG(config Config, ts *TestSuite) []byte {
 		maxX: 0,
 		y:    20,
 		span: ts.Span,
+		x: scale.NewTime(ts.Start, ts.Finish,
+			50, 50+ts.Duration().Seconds()*config.PxPerSecond),
 
 		Grid:  canvas.Layer(0),
 		Spans: canvas.Layer(1),
@@ -52,7 +58,7 @@ func RenderSVG(config Config, ts *TestSuite) []byte {
 	}
 
 	plot.addGrid()
-	width, height := plot.tox(ts.Finish)+150, plot.y
+	width, height := math.Max(plot.tox(ts.Finish), plot.maxX)+10, plot.y
 	canvas.Style = ""
 	canvas.SetSize(width, height)
 
@@ -66,51 +72,40 @@ func RenderSVG(config Config, ts *TestSuite) []byte {
 	return canvas.Bytes()
 }
 
-func (p *Plot) tox(t time.Time) float64 {
-	return 50 + t.Sub(p.span.Start).Seconds()*p.PxPerSecond
+// addText draws text and keeps track of the rightmost text position.
+func (p *Plot) addText(text string, at diagram.Point, style *diagram.Style) {
+	p.Text.Text(text, at, style)
+
+	bounds := diagram.DefaultMeasurer.Measure(text, style).Offset(at)
+	p.maxX = math.Max(p.maxX, bounds.Max.X)
 }
 
+func (p *Plot) tox(t time.Time) float64 { return p.x.Map(t) }
+
 func (p *Plot) addGrid() {
 	duration := p.span.Duration()
+	width := p.x.To - p.x.From
 
-	minorTick := 10 * time.Second
-	k := 1
-	for tick := 0 * time.Second; tick < duration; tick += minorTick {
-		k++
-		if k%2 == 0 {
-			continue
-		}
+	ticks := axis.Duration(0, duration, int(width/100))
+	top := axis.New(axis.Top, ticks, func(v float64) diagram.Length {
+		return p.tox(p.span.Start.Add(time.Duration(v)))
+	})
+	top.Theme.Grid.Stroke = color.Gray{0x80}
+	top.Theme.Label.Fill = color.Gray{0x40}
 
-		t := p.span.Start.Add(tick)
+	// shade every other interval between ticks
+	for i := 0; i+1 < len(ticks); i += 2 {
 		p.Grid.Rect(diagram.R(
-			p.tox(t), 0,
-			p.tox(t.Add(minorTick)), p.y,
+			top.Map(ticks[i].Value), 0,
+			top.Map(ticks[i+1].Value), p.y,
 		), &diagram.Style{
 			Fill: color.Gray{0xF8},
 		})
 	}
 
-	majorTick := time.Minute
-	for tick := 0 * time.Second; tick < duration; tick += majorTick {
-		t := p.span.Start.Add(tick)
-
-		p.Grid.Poly([]diagram.Point{
-			{X: p.tox(t), Y: 0},
-			{X: p.tox(t), Y: p.y},
-		}, &diagram.Style{
-			Stroke: color.Gray{0x80},
-			Size:   1,
-		})
-
-		p.Text.Text(tick.String(), diagram.Point{
-			X: p.tox(t),
-			Y: 20,
-		}, &diagram.Style{
-			Stroke: color.Gray{0x40},
-			Size:   12,
-			Origin: diagram.Point{X: -1, Y: 0},
-		})
-	}
+	area := diagram.R(p.tox(p.span.Start), 20, p.tox(p.span.Finish), p.y)
+	top.Draw(p.Grid, area)
+	p.maxX = math.Max(p.maxX, top.Bounds(area).Max.X)
 }
 
 func (p *Plot) addPackage(t *Task) {
@@ -129,7 +124,7 @@ func (p *Plot) addPackage(t *Task) {
 		Hint: t.Name + " " + t.Duration().String(),
 	})
 
-	p.Text.Text(t.Name, diagram.Point{
+	p.addText(t.Name, diagram.Point{
 		X: r.Min.X + 5.0,
 		Y: (r.Min.Y + r.Max.Y) / 2,
 	}, &diagram.Style{
@@ -138,7 +133,7 @@ func (p *Plot) addPackage(t *Task) {
 		Origin: diagram.Point{X: -1, Y: 0},
 	})
 
-	p.Text.Text(t.Duration().Truncate(time.Second).String(), diagram.Point{
+	p.addText(t.Duration().Truncate(time.Second).String(), diagram.Point{
 		X: r.Min.X - 5.0,
 		Y: (r.Min.Y + r.Max.Y) / 2,
 	}, &diagram.Style{
@@ -186,7 +181,7 @@ func (p *Plot) addTest(level int, parent diagram.Point, t *Task) {
 		})
 	}
 
-	p.Text.Text(t.Name, diagram.Point{
+	p.addText(t.Name, diagram.Point{
 		X: r.Min.X + 2.0,
 		Y: r.Max.Y - 2.0,
 	}, &diagram.Style{
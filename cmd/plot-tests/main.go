@@ -37,7 +37,7 @@ func main() {
 		var err error
 		in, err = os.Open(fname)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to open %q: %w\n", fname, err)
+			fmt.Fprintf(os.Stderr, "failed to open %q: %v\n", fname, err)
 			os.Exit(1)
 		}
 	} else {
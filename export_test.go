@@ -0,0 +1,18 @@
+package diagram
+
+// Cubics exposes cubics for tests.
+func (path *Path) Cubics() *Path { return path.cubics() }
+
+// Flatten exposes flatten for tests.
+func (path *Path) Flatten(tolerance Length) [][]Point {
+	var lines [][]Point
+	for _, line := range path.flatten(tolerance) {
+		lines = append(lines, line.points)
+	}
+	return lines
+}
+
+// Place exposes place for tests.
+func (marker Marker) Place(tip Point, angle float64, width Length) (lines, fills [][]Point) {
+	return marker.place(tip, angle, width)
+}
@@ -0,0 +1,237 @@
+// Package flowchart implements flowcharts with automatic layered layout.
+//
+// Nodes are placed using the Sugiyama method: cycles are removed by
+// reversing edges, nodes are assigned to layers, the order within layers
+// is chosen to reduce edge crossings and finally coordinates are assigned.
+package flowchart
+
+import (
+	"image/color"
+	"math"
+
+	"loov.dev/diagram"
+)
+
+// Direction is the direction in which layers are stacked.
+type Direction int
+
+const (
+	TopDown Direction = iota
+	LeftRight
+)
+
+// Routing is the style of edges.
+type Routing int
+
+const (
+	// Orthogonal edges consist of horizontal and vertical segments.
+	Orthogonal Routing = iota
+	// Spline edges are smooth curves.
+	Spline
+)
+
+// Diagram is a flowchart of nodes connected with edges.
+type Diagram struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	Direction Direction
+	Routing   Routing
+
+	// Measurer is used to size nodes to fit their text.
+	Measurer diagram.Measurer
+
+	Theme struct {
+		NodeWidth    diagram.Length // minimum node width
+		NodeHeight   diagram.Length // minimum node height
+		NodePadding  diagram.Length // space between text and outline
+		NodeSpacing  diagram.Length // space between nodes in a layer
+		LayerSpacing diagram.Length // space between layers
+		Padding      diagram.Length // space around the diagram
+
+		Node    diagram.Style
+		Caption diagram.Style
+		Edge    diagram.Style
+		Label   diagram.Style
+		// LabelBackground is drawn behind edge labels.
+		LabelBackground diagram.Style
+	}
+}
+
+// New creates a flowchart with the default theme.
+func New() *Diagram {
+	dia := &Diagram{}
+	dia.Measurer = diagram.DefaultMeasurer
+
+	dia.Theme.NodeWidth = 80
+	dia.Theme.NodeHeight = 36
+	dia.Theme.NodePadding = 8
+	dia.Theme.NodeSpacing = 30
+	dia.Theme.LayerSpacing = 50
+	dia.Theme.Padding = 10
+
+	dia.Theme.Node = diagram.Style{
+		Stroke: color.NRGBA{0x30, 0x30, 0x30, 0xFF},
+		Fill:   color.NRGBA{0xEE, 0xF3, 0xFA, 0xFF},
+		Size:   1.5,
+	}
+	dia.Theme.Caption = diagram.Style{
+		Fill: color.NRGBA{0x10, 0x10, 0x10, 0xFF},
+		Size: 14,
+	}
+	dia.Theme.Edge = diagram.Style{
+		Stroke:    color.NRGBA{0x30, 0x30, 0x30, 0xFF},
+		Size:      1.3,
+		EndMarker: diagram.MarkerArrow,
+	}
+	dia.Theme.Label = diagram.Style{
+		Fill: color.NRGBA{0x30, 0x30, 0x30, 0xFF},
+		Size: 12,
+	}
+	dia.Theme.LabelBackground = diagram.Style{
+		Fill: color.White,
+	}
+
+	return dia
+}
+
+// Node is a box in the flowchart.
+type Node struct {
+	ID    string
+	Text  string
+	Shape Shape
+
+	Style   diagram.Style
+	Caption diagram.Style
+
+	// Bounds is the position of the node after layout.
+	Bounds diagram.Rect
+}
+
+// Edge connects two nodes.
+type Edge struct {
+	From, To *Node
+	Text     string
+
+	Line    diagram.Style
+	Caption diagram.Style
+}
+
+func (node *Node) Shaped(shape Shape) *Node         { node.Shape = shape; return node }
+func (node *Node) Labeled(text string) *Node        { node.Text = text; return node }
+func (node *Node) Styled(style diagram.Style) *Node { node.Style = style; return node }
+
+func (edge *Edge) Labeled(text string) *Edge       { edge.Text = text; return edge }
+func (edge *Edge) Lined(style diagram.Style) *Edge { edge.Line = style; return edge }
+
+// Node returns the node with the specified id,
+// creating a Process node when it doesn't exist.
+func (dia *Diagram) Node(id string) *Node {
+	for _, node := range dia.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+
+	node := &Node{ID: id, Text: id, Shape: Process}
+	dia.Nodes = append(dia.Nodes, node)
+	return node
+}
+
+// Connect adds an edge between nodes with the specified ids.
+func (dia *Diagram) Connect(from, to string) *Edge {
+	edge := &Edge{From: dia.Node(from), To: dia.Node(to)}
+	dia.Edges = append(dia.Edges, edge)
+	return edge
+}
+
+// point converts layout coordinates to diagram coordinates.
+func (dia *Diagram) point(across, along diagram.Length) diagram.Point {
+	if dia.Direction == LeftRight {
+		return diagram.Point{X: along, Y: across}
+	}
+	return diagram.Point{X: across, Y: along}
+}
+
+// forward returns the direction from a layer towards the next layer.
+func (dia *Diagram) forward() diagram.Point {
+	if dia.Direction == LeftRight {
+		return diagram.P(1, 0)
+	}
+	return diagram.P(0, 1)
+}
+
+// measure returns the size of node fitted to its text.
+func (dia *Diagram) measure(node *Node) diagram.Point {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+
+	text := measurer.Measure(node.Text, node.Caption.Or(dia.Theme.Caption)).Size()
+	padding := dia.Theme.NodePadding
+	size := node.Shape.fit(diagram.P(text.X+2*padding, text.Y+2*padding))
+	return diagram.Point{
+		X: math.Max(size.X, dia.Theme.NodeWidth),
+		Y: math.Max(size.Y, dia.Theme.NodeHeight),
+	}
+}
+
+// Size returns the size needed to draw the diagram.
+func (dia *Diagram) Size() (width, height float64) {
+	g := dia.layout()
+	size := g.size.Add(diagram.P(2*dia.Theme.Padding, 2*dia.Theme.Padding))
+	return size.X, size.Y
+}
+
+// Draw lays out and draws the diagram.
+func (dia *Diagram) Draw(canvas diagram.Canvas) {
+	g := dia.layout()
+
+	edges := canvas.Layer(0)
+	nodes := canvas.Layer(1)
+	texts := canvas.Layer(2)
+
+	for _, edge := range dia.Edges {
+		route := g.routes[edge]
+		if route == nil {
+			continue
+		}
+
+		style := *edge.Line.Or(dia.Theme.Edge)
+		if edge.Text != "" && style.Hint == "" {
+			style.Hint = edge.Text
+		}
+		edges.Path(route.path, &style)
+
+		if edge.Text != "" {
+			dia.drawLabel(texts, edge, route.label)
+		}
+	}
+
+	for _, node := range dia.Nodes {
+		style := *node.Style.Or(dia.Theme.Node)
+		node.Shape.draw(nodes, node.Bounds, &style)
+
+		caption := *node.Caption.Or(dia.Theme.Caption)
+		caption.Origin = diagram.P(0, 0)
+		texts.Text(node.Text, node.Bounds.UnitLocation(diagram.P(0, 0)), &caption)
+	}
+}
+
+// drawLabel draws the label of edge centered at p, with a background.
+func (dia *Diagram) drawLabel(canvas diagram.Canvas, edge *Edge, at diagram.Point) {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+
+	style := *edge.Caption.Or(dia.Theme.Label)
+	style.Origin = diagram.P(0, 0)
+
+	if !dia.Theme.LabelBackground.IsZero() {
+		r := measurer.Measure(edge.Text, &style).Offset(at)
+		canvas.Rect(r.Shrink(diagram.P(-2, -1)), &dia.Theme.LabelBackground)
+	}
+	canvas.Text(edge.Text, at, &style)
+}
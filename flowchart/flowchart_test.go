@@ -0,0 +1,131 @@
+package flowchart_test
+
+import (
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/flowchart"
+)
+
+func center(node *flowchart.Node) diagram.Point {
+	return node.Bounds.UnitLocation(diagram.P(0, 0))
+}
+
+func overlaps(a, b diagram.Rect) bool {
+	return a.Min.X < b.Max.X && b.Min.X < a.Max.X &&
+		a.Min.Y < b.Max.Y && b.Min.Y < a.Max.Y
+}
+
+func TestLayout(t *testing.T) {
+	dia := flowchart.New()
+	dia.Connect("start", "check")
+	dia.Connect("check", "yes").Labeled("yes")
+	dia.Connect("check", "no").Labeled("no")
+	dia.Connect("yes", "end")
+	dia.Connect("no", "check") // cycle
+	dia.Connect("start", "end")
+	dia.Connect("no", "no") // self loop
+	dia.Node("check").Shaped(flowchart.Decision)
+
+	svg := diagram.NewSVG(dia.Size())
+	dia.Draw(svg)
+
+	output := string(svg.Bytes())
+	if strings.Contains(output, "NaN") || strings.Contains(output, "Inf") {
+		t.Errorf("invalid output:\n%s", output)
+	}
+
+	for _, edge := range dia.Edges {
+		if edge.From == edge.To || edge.To.ID == "check" {
+			continue
+		}
+		if edge.From.Bounds.Max.Y >= edge.To.Bounds.Min.Y {
+			t.Errorf("%v -> %v: should point downwards: %v %v", edge.From.ID, edge.To.ID, edge.From.Bounds, edge.To.Bounds)
+		}
+	}
+
+	for i, a := range dia.Nodes {
+		for _, b := range dia.Nodes[i+1:] {
+			if overlaps(a.Bounds, b.Bounds) {
+				t.Errorf("%v and %v overlap: %v %v", a.ID, b.ID, a.Bounds, b.Bounds)
+			}
+		}
+	}
+}
+
+func TestCrossingMinimization(t *testing.T) {
+	dia := flowchart.New()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		dia.Node(id)
+	}
+	// depth first order places c before d, which crosses b -> c
+	dia.Connect("a", "c")
+	dia.Connect("a", "d")
+	dia.Connect("b", "c")
+	dia.Size()
+
+	a, b := center(dia.Node("a")), center(dia.Node("b"))
+	c, d := center(dia.Node("c")), center(dia.Node("d"))
+	if (a.X < b.X) != (d.X < c.X) {
+		t.Errorf("edges cross: a=%v b=%v c=%v d=%v", a, b, c, d)
+	}
+}
+
+func TestLeftRight(t *testing.T) {
+	dia := flowchart.New()
+	dia.Direction = flowchart.LeftRight
+	dia.Routing = flowchart.Spline
+	dia.Connect("a", "b")
+	dia.Connect("a", "c")
+	dia.Connect("b", "d")
+	dia.Connect("c", "d")
+	dia.Connect("a", "d")
+
+	width, height := dia.Size()
+	for _, node := range dia.Nodes {
+		if node.Bounds.Max.X > width || node.Bounds.Max.Y > height {
+			t.Errorf("%v outside of diagram: %v", node.ID, node.Bounds)
+		}
+	}
+	if a, d := dia.Node("a").Bounds, dia.Node("d").Bounds; a.Max.X >= d.Min.X {
+		t.Errorf("layers should go left to right: %v %v", a, d)
+	}
+}
+
+// paths returns the paths drawn into rec and its layers.
+func paths(rec *diagram.Recorder) []*diagram.Path {
+	var result []*diagram.Path
+	for _, op := range rec.Ops {
+		if op.Kind == diagram.OpPath {
+			result = append(result, op.Path)
+		}
+	}
+	for _, layer := range rec.Layers {
+		result = append(result, paths(layer)...)
+	}
+	return result
+}
+
+func TestRoutesInside(t *testing.T) {
+	for _, direction := range []flowchart.Direction{flowchart.TopDown, flowchart.LeftRight} {
+		dia := flowchart.New()
+		dia.Direction = direction
+		dia.Connect("a", "b")
+		dia.Connect("b", "b")
+
+		width, height := dia.Size()
+		rec := diagram.NewRecorder(width, height)
+		dia.Draw(rec)
+
+		for _, path := range paths(rec) {
+			for _, cmd := range path.Commands {
+				for _, p := range cmd.Points {
+					if p.X < 0 || p.Y < 0 || p.X > width || p.Y > height {
+						t.Errorf("%v: %v outside of %vx%v", direction, p, width, height)
+					}
+				}
+			}
+		}
+	}
+}
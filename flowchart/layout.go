@@ -0,0 +1,634 @@
+package flowchart
+
+import (
+	"math"
+	"sort"
+
+	"loov.dev/diagram"
+)
+
+// vertex is a node or a dummy node of a long edge in the layered graph.
+//
+// Coordinates are in layout space: across is the position
+// within a layer and along is the position of the layer.
+type vertex struct {
+	node *Node // nil for dummy vertices
+
+	layer int
+	order int
+
+	across diagram.Length
+	// size is the extent of the vertex, X across and Y along the layers.
+	size diagram.Point
+
+	prev, next []*vertex // neighbours in adjacent layers
+}
+
+// route is the drawing of an edge.
+type route struct {
+	path  *diagram.Path
+	label diagram.Point
+}
+
+// graph is the layered graph used for layout.
+type graph struct {
+	vertices map[*Node]*vertex
+	layers   [][]*vertex
+	// along is the center of each layer and depth its size.
+	along, depth []diagram.Length
+
+	// chains contain the vertices of each edge, from source to target
+	// in layer order, reversed is set for edges pointing backwards.
+	chains   map[*Edge][]*vertex
+	reversed map[*Edge]bool
+
+	routes map[*Edge]*route
+	size   diagram.Point
+}
+
+// layout runs all the layout steps and assigns Node.Bounds.
+func (dia *Diagram) layout() *graph {
+	g := &graph{
+		vertices: map[*Node]*vertex{},
+		chains:   map[*Edge][]*vertex{},
+		routes:   map[*Edge]*route{},
+	}
+	for _, node := range dia.Nodes {
+		size := dia.measure(node)
+		if dia.Direction == LeftRight {
+			size.X, size.Y = size.Y, size.X
+		}
+		g.vertices[node] = &vertex{node: node, size: size}
+	}
+
+	g.reversed = removeCycles(dia.Nodes, dia.Edges)
+	layers := assignLayers(dia.Nodes, dia.Edges, g.reversed)
+	g.addVertices(dia, layers)
+	g.orderLayers()
+	g.assignCoordinates(dia)
+
+	for _, edge := range dia.Edges {
+		r := g.route(dia, edge)
+		g.routes[edge] = r
+		if r != nil {
+			g.include(dia, r.path)
+		}
+	}
+	return g
+}
+
+// include grows the diagram size to contain path,
+// self loops are drawn outside of the node bounds.
+func (g *graph) include(dia *Diagram, path *diagram.Path) {
+	padding := diagram.P(dia.Theme.Padding, dia.Theme.Padding)
+	for _, cmd := range path.Commands {
+		for _, p := range cmd.Points {
+			g.size = g.size.Max(p.Sub(padding))
+		}
+	}
+}
+
+// direct returns the edge endpoints in layer order.
+func direct(edge *Edge, reversed map[*Edge]bool) (from, to *Node) {
+	if reversed[edge] {
+		return edge.To, edge.From
+	}
+	return edge.From, edge.To
+}
+
+// removeCycles finds edges, which need to be reversed to make the graph acyclic.
+//
+// It uses depth first search, in node order, and reverses edges that point
+// to a node on the current search path. Self loops are ignored.
+func removeCycles(nodes []*Node, edges []*Edge) map[*Edge]bool {
+	outgoing := map[*Node][]*Edge{}
+	for _, edge := range edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge)
+	}
+
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := map[*Node]int{}
+	reversed := map[*Edge]bool{}
+
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		state[node] = active
+		for _, edge := range outgoing[node] {
+			if edge.From == edge.To {
+				continue
+			}
+			switch state[edge.To] {
+			case active:
+				reversed[edge] = true
+			case unvisited:
+				visit(edge.To)
+			}
+		}
+		state[node] = done
+	}
+
+	// start from nodes without incoming edges to keep the natural direction
+	incoming := map[*Node]int{}
+	for _, edge := range edges {
+		if edge.From != edge.To {
+			incoming[edge.To]++
+		}
+	}
+	for _, node := range nodes {
+		if incoming[node] == 0 && state[node] == unvisited {
+			visit(node)
+		}
+	}
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+	return reversed
+}
+
+// assignLayers assigns each node to a layer using the longest path from
+// the sources, sources are then moved next to their first successor.
+func assignLayers(nodes []*Node, edges []*Edge, reversed map[*Edge]bool) map[*Node]int {
+	preds := map[*Node][]*Node{}
+	succs := map[*Node][]*Node{}
+	for _, edge := range edges {
+		if edge.From == edge.To {
+			continue
+		}
+		from, to := direct(edge, reversed)
+		preds[to] = append(preds[to], from)
+		succs[from] = append(succs[from], to)
+	}
+
+	// topological order with Kahn's algorithm, keeping node order for ties
+	indegree := map[*Node]int{}
+	for _, node := range nodes {
+		indegree[node] = len(preds[node])
+	}
+	var order, queue []*Node
+	for _, node := range nodes {
+		if indegree[node] == 0 {
+			queue = append(queue, node)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		order = append(order, node)
+		for _, succ := range succs[node] {
+			indegree[succ]--
+			if indegree[succ] == 0 {
+				queue = append(queue, succ)
+			}
+		}
+	}
+
+	layer := map[*Node]int{}
+	for _, node := range order {
+		for _, pred := range preds[node] {
+			if layer[pred]+1 > layer[node] {
+				layer[node] = layer[pred] + 1
+			}
+		}
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		node := order[i]
+		if len(preds[node]) > 0 || len(succs[node]) == 0 {
+			continue
+		}
+		closest := math.MaxInt32
+		for _, succ := range succs[node] {
+			if layer[succ] < closest {
+				closest = layer[succ]
+			}
+		}
+		layer[node] = closest - 1
+	}
+
+	return layer
+}
+
+// addVertices creates the layers and adds dummy vertices for
+// edges spanning multiple layers.
+func (g *graph) addVertices(dia *Diagram, layers map[*Node]int) {
+	add := func(v *vertex) {
+		for len(g.layers) <= v.layer {
+			g.layers = append(g.layers, nil)
+		}
+		v.order = len(g.layers[v.layer])
+		g.layers[v.layer] = append(g.layers[v.layer], v)
+	}
+
+	// initial order follows depth first search from the first layer
+	visited := map[*Node]bool{}
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		if visited[node] {
+			return
+		}
+		visited[node] = true
+		v := g.vertices[node]
+		v.layer = layers[node]
+		add(v)
+		for _, edge := range dia.Edges {
+			from, to := direct(edge, g.reversed)
+			if from == node && to != node {
+				visit(to)
+			}
+		}
+	}
+	for _, node := range dia.Nodes {
+		if layers[node] == 0 {
+			visit(node)
+		}
+	}
+	for _, node := range dia.Nodes {
+		visit(node)
+	}
+
+	for _, edge := range dia.Edges {
+		if edge.From == edge.To {
+			continue
+		}
+		from, to := direct(edge, g.reversed)
+		chain := []*vertex{g.vertices[from]}
+		for layer := layers[from] + 1; layer < layers[to]; layer++ {
+			dummy := &vertex{layer: layer}
+			add(dummy)
+			chain = append(chain, dummy)
+		}
+		chain = append(chain, g.vertices[to])
+
+		for i := 1; i < len(chain); i++ {
+			chain[i-1].next = append(chain[i-1].next, chain[i])
+			chain[i].prev = append(chain[i].prev, chain[i-1])
+		}
+		g.chains[edge] = chain
+	}
+}
+
+// orderLayers reduces edge crossings by sorting vertices in each layer
+// by the barycenter of their neighbours, sweeping down and up.
+func (g *graph) orderLayers() {
+	best := g.snapshot()
+	bestCrossings := g.crossings()
+
+	const iterations = 12
+	for iteration := 0; iteration < iterations && bestCrossings > 0; iteration++ {
+		if iteration%2 == 0 {
+			for layer := 1; layer < len(g.layers); layer++ {
+				sortByBarycenter(g.layers[layer], func(v *vertex) []*vertex { return v.prev })
+			}
+		} else {
+			for layer := len(g.layers) - 2; layer >= 0; layer-- {
+				sortByBarycenter(g.layers[layer], func(v *vertex) []*vertex { return v.next })
+			}
+		}
+
+		if crossings := g.crossings(); crossings < bestCrossings {
+			best, bestCrossings = g.snapshot(), crossings
+		}
+	}
+	g.restore(best)
+}
+
+// sortByBarycenter sorts vertices by the average order of their neighbours,
+// vertices without neighbours keep their position.
+func sortByBarycenter(layer []*vertex, neighbours func(v *vertex) []*vertex) {
+	barycenter := make(map[*vertex]float64, len(layer))
+	for _, v := range layer {
+		adjacent := neighbours(v)
+		if len(adjacent) == 0 {
+			barycenter[v] = float64(v.order)
+			continue
+		}
+		sum := 0.0
+		for _, n := range adjacent {
+			sum += float64(n.order)
+		}
+		barycenter[v] = sum / float64(len(adjacent))
+	}
+
+	sort.SliceStable(layer, func(i, k int) bool {
+		return barycenter[layer[i]] < barycenter[layer[k]]
+	})
+	for i, v := range layer {
+		v.order = i
+	}
+}
+
+// crossings counts the edge crossings between adjacent layers.
+func (g *graph) crossings() int {
+	total := 0
+	for _, layer := range g.layers {
+		type segment struct{ from, to int }
+		var segments []segment
+		for _, v := range layer {
+			for _, n := range v.next {
+				segments = append(segments, segment{v.order, n.order})
+			}
+		}
+		for i, a := range segments {
+			for _, b := range segments[i+1:] {
+				if (a.from-b.from)*(a.to-b.to) < 0 {
+					total++
+				}
+			}
+		}
+	}
+	return total
+}
+
+// snapshot returns a copy of the layer order.
+func (g *graph) snapshot() [][]*vertex {
+	layers := make([][]*vertex, len(g.layers))
+	for i, layer := range g.layers {
+		layers[i] = append([]*vertex(nil), layer...)
+	}
+	return layers
+}
+
+// restore sets the layer order from a snapshot.
+func (g *graph) restore(layers [][]*vertex) {
+	g.layers = layers
+	for _, layer := range layers {
+		for i, v := range layer {
+			v.order = i
+		}
+	}
+}
+
+// assignCoordinates positions layers and places vertices within
+// layers close to their neighbours.
+func (g *graph) assignCoordinates(dia *Diagram) {
+	spacing := dia.Theme.LayerSpacing
+
+	g.along = make([]diagram.Length, len(g.layers))
+	g.depth = make([]diagram.Length, len(g.layers))
+	offset := diagram.Length(0)
+	for i, layer := range g.layers {
+		for _, v := range layer {
+			g.depth[i] = math.Max(g.depth[i], v.size.Y)
+		}
+		if i > 0 {
+			offset += spacing
+		}
+		g.along[i] = offset + g.depth[i]/2
+		offset += g.depth[i]
+	}
+
+	// initial placement packs vertices
+	for _, layer := range g.layers {
+		x := diagram.Length(0)
+		for i, v := range layer {
+			if i > 0 {
+				x += g.separation(dia, layer[i-1], v)
+			}
+			v.across = x
+		}
+	}
+
+	const iterations = 8
+	for iteration := 0; iteration < iterations; iteration++ {
+		if iteration%2 == 0 {
+			for layer := 1; layer < len(g.layers); layer++ {
+				g.place(dia, g.layers[layer], func(v *vertex) []*vertex { return v.prev })
+			}
+		} else {
+			for layer := len(g.layers) - 2; layer >= 0; layer-- {
+				g.place(dia, g.layers[layer], func(v *vertex) []*vertex { return v.next })
+			}
+		}
+	}
+
+	// move the diagram to the origin
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, layer := range g.layers {
+		for _, v := range layer {
+			min = math.Min(min, v.across-v.size.X/2)
+			max = math.Max(max, v.across+v.size.X/2)
+		}
+	}
+	if math.IsInf(min, 0) {
+		min, max = 0, 0
+	}
+	for _, layer := range g.layers {
+		for _, v := range layer {
+			v.across -= min
+		}
+	}
+
+	g.size = dia.point(max-min, offset)
+
+	padding := dia.Theme.Padding
+	for node, v := range g.vertices {
+		center := dia.point(v.across, g.along[v.layer])
+		size := dia.point(v.size.X, v.size.Y)
+		node.Bounds = diagram.Rect{
+			Min: diagram.P(padding+center.X-size.X/2, padding+center.Y-size.Y/2),
+			Max: diagram.P(padding+center.X+size.X/2, padding+center.Y+size.Y/2),
+		}
+	}
+}
+
+// separation returns the minimum distance between centers of adjacent vertices.
+func (g *graph) separation(dia *Diagram, a, b *vertex) diagram.Length {
+	gap := dia.Theme.NodeSpacing
+	if a.node == nil || b.node == nil {
+		gap /= 2
+	}
+	return (a.size.X+b.size.X)/2 + gap
+}
+
+// place moves vertices in layer towards the average position
+// of their neighbours, while keeping order and separation.
+func (g *graph) place(dia *Diagram, layer []*vertex, neighbours func(v *vertex) []*vertex) {
+	n := len(layer)
+	if n == 0 {
+		return
+	}
+
+	desired := make([]diagram.Length, n)
+	for i, v := range layer {
+		adjacent := neighbours(v)
+		if len(adjacent) == 0 {
+			desired[i] = v.across
+			continue
+		}
+		sum := diagram.Length(0)
+		for _, a := range adjacent {
+			sum += a.across
+		}
+		desired[i] = sum / float64(len(adjacent))
+	}
+
+	// resolve overlaps from the left and from the right, then average
+	left := make([]diagram.Length, n)
+	for i := range layer {
+		left[i] = desired[i]
+		if i > 0 {
+			left[i] = math.Max(left[i], left[i-1]+g.separation(dia, layer[i-1], layer[i]))
+		}
+	}
+	right := make([]diagram.Length, n)
+	for i := n - 1; i >= 0; i-- {
+		right[i] = desired[i]
+		if i < n-1 {
+			right[i] = math.Min(right[i], right[i+1]-g.separation(dia, layer[i], layer[i+1]))
+		}
+	}
+	for i, v := range layer {
+		v.across = (left[i] + right[i]) / 2
+	}
+}
+
+// route creates the path of edge.
+func (g *graph) route(dia *Diagram, edge *Edge) *route {
+	padding := diagram.P(dia.Theme.Padding, dia.Theme.Padding)
+	forward := dia.forward()
+
+	if edge.From == edge.To {
+		return g.selfLoop(dia, edge.From)
+	}
+
+	chain := g.chains[edge]
+	if len(chain) < 2 {
+		return nil
+	}
+
+	// points in layout space between each pair of layers
+	type point struct{ across, along diagram.Length }
+	first, last := chain[0], chain[len(chain)-1]
+	start := first.node.Shape.port(first.node.Bounds, forward)
+	end := last.node.Shape.port(last.node.Bounds, forward.Neg())
+
+	toLayout := func(p diagram.Point) point {
+		p = p.Sub(padding)
+		if dia.Direction == LeftRight {
+			return point{across: p.Y, along: p.X}
+		}
+		return point{across: p.X, along: p.Y}
+	}
+	toCanvas := func(p point) diagram.Point {
+		return dia.point(p.across, p.along).Add(padding)
+	}
+
+	points := []point{toLayout(start)}
+	for _, v := range chain[1 : len(chain)-1] {
+		points = append(points, point{v.across, g.along[v.layer]})
+	}
+	points = append(points, toLayout(end))
+
+	var result []diagram.Point
+	path := diagram.NewPath()
+	switch dia.Routing {
+	case Spline:
+		result = append(result, toCanvas(points[0]))
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			d := (b.along - a.along) / 2
+			result = append(result,
+				toCanvas(point{a.across, a.along + d}),
+				toCanvas(point{b.across, b.along - d}),
+				toCanvas(b),
+			)
+		}
+	default:
+		result = append(result, toCanvas(points[0]))
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			// bend in the middle of the gap between layers
+			layer := chain[i-1].layer
+			mid := g.along[layer] + g.depth[layer]/2 + dia.Theme.LayerSpacing/2
+			if a.across != b.across {
+				result = append(result,
+					toCanvas(point{a.across, mid}),
+					toCanvas(point{b.across, mid}),
+				)
+			}
+			result = append(result, toCanvas(b))
+		}
+	}
+
+	if g.reversed[edge] {
+		for i, k := 0, len(result)-1; i < k; i, k = i+1, k-1 {
+			result[i], result[k] = result[k], result[i]
+		}
+	}
+
+	path.MoveTo(result[0])
+	if dia.Routing == Spline {
+		for i := 1; i+2 < len(result); i += 3 {
+			path.CubicTo(result[i], result[i+1], result[i+2])
+		}
+	} else {
+		for _, p := range result[1:] {
+			path.LineTo(p)
+		}
+	}
+
+	return &route{path: path, label: midpoint(result)}
+}
+
+// selfLoop routes an edge from node to itself around the far side.
+func (g *graph) selfLoop(dia *Diagram, node *Node) *route {
+	r := node.Bounds
+	gap := dia.Theme.NodeSpacing / 2
+	var points []diagram.Point
+	if dia.Direction == LeftRight {
+		start := node.Shape.port(r, diagram.P(0, 1))
+		end := node.Shape.port(r, diagram.P(1, 0))
+		points = []diagram.Point{
+			start,
+			{X: start.X, Y: r.Max.Y + gap},
+			{X: r.Max.X + gap, Y: r.Max.Y + gap},
+			{X: r.Max.X + gap, Y: end.Y},
+			end,
+		}
+	} else {
+		start := node.Shape.port(r, diagram.P(1, 0))
+		end := node.Shape.port(r, diagram.P(0, 1))
+		points = []diagram.Point{
+			start,
+			{X: r.Max.X + gap, Y: start.Y},
+			{X: r.Max.X + gap, Y: r.Max.Y + gap},
+			{X: end.X, Y: r.Max.Y + gap},
+			end,
+		}
+	}
+	return &route{
+		path:  diagram.NewPath().Polyline(points...),
+		label: points[2],
+	}
+}
+
+// midpoint returns the point halfway along the polyline.
+func midpoint(points []diagram.Point) diagram.Point {
+	total := diagram.Length(0)
+	for i := 1; i < len(points); i++ {
+		total += distance(points[i-1], points[i])
+	}
+
+	remaining := total / 2
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		length := distance(a, b)
+		if length >= remaining && length > 0 {
+			t := remaining / length
+			return diagram.P(a.X+(b.X-a.X)*t, a.Y+(b.Y-a.Y)*t)
+		}
+		remaining -= length
+	}
+	return points[len(points)-1]
+}
+
+// distance returns the distance between a and b.
+func distance(a, b diagram.Point) diagram.Length {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
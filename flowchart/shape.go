@@ -0,0 +1,80 @@
+package flowchart
+
+import (
+	"math"
+
+	"loov.dev/diagram"
+)
+
+// Shape is the outline of a node.
+type Shape string
+
+const (
+	// Process is a rectangle, used for steps.
+	Process Shape = "process"
+	// Decision is a diamond, used for branching.
+	Decision Shape = "decision"
+	// Terminator is a stadium, used for start and end.
+	Terminator Shape = "terminator"
+	// Data is a parallelogram, used for input and output.
+	Data Shape = "data"
+)
+
+// fit returns the size of the shape that contains text of the specified size.
+func (shape Shape) fit(text diagram.Point) diagram.Point {
+	switch shape {
+	case Decision:
+		// the text rectangle is inscribed in the diamond
+		return diagram.Point{X: text.X * 2, Y: text.Y * 2}
+	case Terminator:
+		return diagram.Point{X: text.X + text.Y, Y: text.Y}
+	case Data:
+		return diagram.Point{X: text.X + text.Y, Y: text.Y}
+	default:
+		return text
+	}
+}
+
+// skew returns the horizontal offset of the slanted sides of Data.
+func skew(r diagram.Rect) diagram.Length {
+	return r.Size().Y / 2
+}
+
+// draw draws the shape filling r.
+func (shape Shape) draw(canvas diagram.Canvas, r diagram.Rect, style *diagram.Style) {
+	switch shape {
+	case Decision:
+		c := r.UnitLocation(diagram.P(0, 0))
+		canvas.Poly(diagram.Ps(
+			c.X, r.Min.Y,
+			r.Max.X, c.Y,
+			c.X, r.Max.Y,
+			r.Min.X, c.Y,
+			c.X, r.Min.Y,
+		), style)
+	case Terminator:
+		canvas.Path(diagram.RoundedRect(r, r.Size().Y/2), style)
+	case Data:
+		s := skew(r)
+		canvas.Poly(diagram.Ps(
+			r.Min.X+s, r.Min.Y,
+			r.Max.X, r.Min.Y,
+			r.Max.X-s, r.Max.Y,
+			r.Min.X, r.Max.Y,
+			r.Min.X+s, r.Min.Y,
+		), style)
+	default:
+		canvas.Rect(r, style)
+	}
+}
+
+// port returns the point on the outline of the shape, filling r,
+// in the direction dir, which is one of the unit axis vectors.
+func (shape Shape) port(r diagram.Rect, dir diagram.Point) diagram.Point {
+	p := r.UnitLocation(dir)
+	if shape == Data && dir.X != 0 {
+		// the middle of the slanted side
+		p.X -= math.Copysign(skew(r)/2, dir.X)
+	}
+	return p
+}
@@ -0,0 +1,117 @@
+package diagram
+
+import "strings"
+
+// fontMetrics describes a font in units of 1/1000 em.
+type fontMetrics struct {
+	// name of the corresponding standard PDF font
+	name string
+
+	ascent    float64
+	descent   float64
+	capHeight float64
+
+	// widths of printable ASCII characters starting from ' '
+	widths [95]float64
+	// missing is the width of characters outside of widths
+	missing float64
+}
+
+// width returns the advance of text in units of 1/1000 em.
+func (metrics *fontMetrics) width(text string) float64 {
+	total := 0.0
+	for _, r := range text {
+		total += metrics.runeWidth(r)
+	}
+	return total
+}
+
+var helveticaMetrics = fontMetrics{
+	name:      "Helvetica",
+	ascent:    718,
+	descent:   -207,
+	capHeight: 718,
+	widths: [95]float64{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // ' ' - '/'
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // '0' - '9'
+		278, 278, 584, 584, 584, 556, 1015, // ':' - '@'
+		667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // 'A' - 'M'
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // 'N' - 'Z'
+		278, 278, 278, 469, 556, 333, // '[' - '`'
+		556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // 'a' - 'm'
+		556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // 'n' - 'z'
+		334, 260, 334, 584, // '{' - '~'
+	},
+	missing: 556,
+}
+
+var timesMetrics = fontMetrics{
+	name:      "Times-Roman",
+	ascent:    683,
+	descent:   -217,
+	capHeight: 662,
+	widths: [95]float64{
+		250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278, // ' ' - '/'
+		500, 500, 500, 500, 500, 500, 500, 500, 500, 500, // '0' - '9'
+		278, 278, 564, 564, 564, 444, 921, // ':' - '@'
+		722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, // 'A' - 'M'
+		722, 722, 556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, // 'N' - 'Z'
+		333, 278, 333, 469, 500, 333, // '[' - '`'
+		444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, // 'a' - 'm'
+		500, 500, 500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, // 'n' - 'z'
+		480, 200, 480, 541, // '{' - '~'
+	},
+	missing: 500,
+}
+
+var courierMetrics = fontMetrics{
+	name:      "Courier",
+	ascent:    629,
+	descent:   -157,
+	capHeight: 562,
+	widths: [95]float64{
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600, 600,
+		600, 600, 600, 600,
+	},
+	missing: 600,
+}
+
+// lookupFont finds metrics for a CSS-like font family name,
+// unknown fonts use Helvetica metrics.
+func lookupFont(family string) *fontMetrics {
+	family = strings.ToLower(family)
+	contains := func(names ...string) bool {
+		for _, name := range names {
+			if strings.Contains(family, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case contains("courier", "mono"):
+		return &courierMetrics
+	case contains("sans", "helvetica", "arial", "verdana", "system-ui"):
+		return &helveticaMetrics
+	case contains("times", "serif", "georgia"):
+		return &timesMetrics
+	default:
+		return &helveticaMetrics
+	}
+}
+
+// runeWidth returns the advance of r in units of 1/1000 em.
+func (metrics *fontMetrics) runeWidth(r rune) float64 {
+	if r >= ' ' && int(r-' ') < len(metrics.widths) {
+		return metrics.widths[r-' ']
+	}
+	return metrics.missing
+}
@@ -0,0 +1,12 @@
+package gantt
+
+import "time"
+
+// Span exposes span for tests.
+func (dia *Diagram) Span() (start, end time.Time) {
+	start, end, _ = dia.span()
+	return start, end
+}
+
+// Scheduled exposes the scheduled start for tests.
+func (task *Task) Scheduled() time.Time { return task.start }
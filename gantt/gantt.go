@@ -0,0 +1,531 @@
+// Package gantt implements gantt charts of tasks over time.
+package gantt
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// Diagram is a gantt chart, tasks are grouped into sections
+// and drawn as bars along a time axis.
+type Diagram struct {
+	Sections []*Section
+
+	// Start and End limit the time axis, zero values fit the tasks.
+	Start, End time.Time
+	// Today draws a marker at the specified time, zero omits the marker.
+	Today time.Time
+
+	// Measurer is used to size the label column.
+	Measurer diagram.Measurer
+
+	Theme struct {
+		TimeWidth  diagram.Length // width of the time axis
+		RowHeight  diagram.Length
+		BarHeight  diagram.Length
+		LabelWidth diagram.Length // minimum width of the label column
+		Padding    diagram.Length
+
+		Section     diagram.Style
+		SectionFill diagram.Style // background of every other section
+		Label       diagram.Style
+		Bar         diagram.Style
+		Progress    diagram.Style
+		Milestone   diagram.Style
+		Dependency  diagram.Style
+		Today       diagram.Style
+	}
+}
+
+// New creates a gantt chart with the default theme.
+func New() *Diagram {
+	dia := &Diagram{}
+	dia.Measurer = diagram.DefaultMeasurer
+
+	dia.Theme.TimeWidth = 600
+	dia.Theme.RowHeight = 24
+	dia.Theme.BarHeight = 16
+	dia.Theme.LabelWidth = 100
+	dia.Theme.Padding = 8
+
+	dia.Theme.Section = diagram.Style{
+		Fill: color.NRGBA{0x20, 0x20, 0x20, 0xFF},
+		Size: 14,
+	}
+	dia.Theme.SectionFill = diagram.Style{
+		Fill: color.NRGBA{0xF4, 0xF4, 0xF4, 0xFF},
+	}
+	dia.Theme.Label = diagram.Style{
+		Fill: color.NRGBA{0x30, 0x30, 0x30, 0xFF},
+		Size: 12,
+	}
+	dia.Theme.Bar = diagram.Style{
+		Fill:   color.NRGBA{0xAE, 0xC7, 0xE8, 0xFF},
+		Stroke: color.NRGBA{0x1F, 0x77, 0xB4, 0xFF},
+		Size:   1,
+	}
+	dia.Theme.Progress = diagram.Style{
+		Fill: color.NRGBA{0x1F, 0x77, 0xB4, 0xFF},
+	}
+	dia.Theme.Milestone = diagram.Style{
+		Fill:   color.NRGBA{0xFF, 0x7F, 0x0E, 0xFF},
+		Stroke: color.NRGBA{0xA0, 0x4A, 0x00, 0xFF},
+		Size:   1,
+	}
+	dia.Theme.Dependency = diagram.Style{
+		Stroke:    color.NRGBA{0x50, 0x50, 0x50, 0xFF},
+		Size:      1,
+		EndMarker: diagram.MarkerArrow,
+	}
+	dia.Theme.Today = diagram.Style{
+		Stroke: color.NRGBA{0xD6, 0x27, 0x28, 0xFF},
+		Size:   1.5,
+		Dash:   []diagram.Length{4, 2},
+		Hint:   "today",
+	}
+
+	return dia
+}
+
+// Section is a named group of tasks.
+type Section struct {
+	Name  string
+	Tasks []*Task
+
+	Caption diagram.Style
+}
+
+// Task is a bar from Start for Duration, milestones are drawn
+// as a diamond at Start.
+type Task struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	// Progress is the completed fraction of the task, in range [0, 1].
+	Progress float64
+	// Milestone marks a single point in time.
+	Milestone bool
+	// Dependencies must finish before the task starts.
+	Dependencies []*Task
+
+	Caption diagram.Style
+	Bar     diagram.Style
+
+	// start is Start or the scheduled start when Start is zero.
+	start time.Time
+	y     diagram.Length
+}
+
+// NewTask creates a task, zero start schedules the task after
+// its dependencies or the previous task.
+func NewTask(name string, start time.Time, duration time.Duration) *Task {
+	return &Task{
+		Name:     name,
+		Start:    start,
+		Duration: duration,
+	}
+}
+
+// NewMilestone creates a milestone at the specified time.
+func NewMilestone(name string, at time.Time) *Task {
+	return &Task{
+		Name:      name,
+		Start:     at,
+		Milestone: true,
+	}
+}
+
+func (task *Task) End() time.Time { return task.Start.Add(task.Duration) }
+
+// end returns the scheduled end of task.
+func (task *Task) end() time.Time { return task.start.Add(task.Duration) }
+
+func (task *Task) After(tasks ...*Task) *Task {
+	task.Dependencies = append(task.Dependencies, tasks...)
+	return task
+}
+func (task *Task) Progressed(fraction float64) *Task { task.Progress = fraction; return task }
+func (task *Task) Styled(style diagram.Style) *Task  { task.Bar = style; return task }
+
+// Section returns the section with the specified name,
+// creating it when it doesn't exist.
+func (dia *Diagram) Section(name string) *Section {
+	for _, section := range dia.Sections {
+		if strings.EqualFold(section.Name, name) {
+			return section
+		}
+	}
+
+	section := &Section{Name: name}
+	dia.Sections = append(dia.Sections, section)
+	return section
+}
+
+// Add adds tasks to the last section.
+func (dia *Diagram) Add(tasks ...*Task) {
+	if len(dia.Sections) == 0 {
+		dia.Section("")
+	}
+	dia.Sections[len(dia.Sections)-1].Add(tasks...)
+}
+
+// Add adds tasks to the section.
+func (section *Section) Add(tasks ...*Task) {
+	section.Tasks = append(section.Tasks, tasks...)
+}
+
+// tasks returns all tasks in drawing order.
+func (dia *Diagram) tasks() []*Task {
+	var tasks []*Task
+	for _, section := range dia.Sections {
+		tasks = append(tasks, section.Tasks...)
+	}
+	return tasks
+}
+
+// schedule assigns start times to tasks without one,
+// without modifying Task.Start.
+//
+// A task starts after its last placed dependency, tasks without
+// placed dependencies start after the closest placed task listed before.
+func (dia *Diagram) schedule() {
+	tasks := dia.tasks()
+	index := map[*Task]int{}
+	for i := len(tasks) - 1; i >= 0; i-- {
+		index[tasks[i]] = i
+	}
+
+	scheduled := map[*Task]bool{}
+	visiting := map[*Task]bool{}
+
+	var visit func(task *Task)
+	visit = func(task *Task) {
+		if scheduled[task] || visiting[task] {
+			return
+		}
+		task.start = task.Start
+		if !task.start.IsZero() {
+			scheduled[task] = true
+			return
+		}
+		// mark while visiting dependencies to break cycles
+		visiting[task] = true
+		defer delete(visiting, task)
+
+		var start time.Time
+		for _, dep := range task.Dependencies {
+			visit(dep)
+			// dependencies in a cycle may still be unplaced
+			if !scheduled[dep] {
+				continue
+			}
+			if dep.end().After(start) {
+				start = dep.end()
+			}
+		}
+
+		if start.IsZero() {
+			if i, ok := index[task]; ok {
+				for i--; i >= 0; i-- {
+					visit(tasks[i])
+					if scheduled[tasks[i]] {
+						start = tasks[i].end()
+						break
+					}
+				}
+			}
+		}
+
+		task.start = start
+		if !start.IsZero() {
+			scheduled[task] = true
+		}
+	}
+
+	for _, task := range tasks {
+		visit(task)
+	}
+}
+
+// span returns the time range of the axis.
+func (dia *Diagram) span() (start, end time.Time, auto bool) {
+	start, end = dia.Start, dia.End
+	if !start.IsZero() && !end.IsZero() {
+		return start, end, false
+	}
+
+	var first, last time.Time
+	for _, task := range dia.tasks() {
+		if task.start.IsZero() {
+			continue
+		}
+		if first.IsZero() || task.start.Before(first) {
+			first = task.start
+		}
+		if last.IsZero() || task.end().After(last) {
+			last = task.end()
+		}
+	}
+	if !dia.Today.IsZero() {
+		if first.IsZero() || dia.Today.Before(first) {
+			first = dia.Today
+		}
+		if last.IsZero() || dia.Today.After(last) {
+			last = dia.Today
+		}
+	}
+	if first.IsZero() {
+		first = time.Now()
+	}
+	if !last.After(first) {
+		last = first.Add(24 * time.Hour)
+	}
+
+	if start.IsZero() {
+		start = first
+	}
+	if end.IsZero() {
+		end = last
+	}
+	return start, end, true
+}
+
+// layout contains the computed positions of the chart.
+type layout struct {
+	labelWidth diagram.Length
+	area       diagram.Rect
+	times      *scale.Time
+	axis       *axis.Axis
+	size       diagram.Point
+}
+
+func (dia *Diagram) layout() *layout {
+	dia.schedule()
+
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+
+	lay := &layout{}
+	padding := dia.Theme.Padding
+
+	lay.labelWidth = dia.Theme.LabelWidth
+	for _, section := range dia.Sections {
+		size := measurer.Measure(section.Name, section.Caption.Or(dia.Theme.Section)).Size()
+		lay.labelWidth = math.Max(lay.labelWidth, size.X+2*padding)
+		for _, task := range section.Tasks {
+			size := measurer.Measure(task.Name, task.Caption.Or(dia.Theme.Label)).Size()
+			lay.labelWidth = math.Max(lay.labelWidth, size.X+3*padding)
+		}
+	}
+
+	start, end, auto := dia.span()
+	count := int(dia.Theme.TimeWidth / 100)
+	if count < 2 {
+		count = 2
+	}
+	lay.times = scale.NewTime(start, end, lay.labelWidth, lay.labelWidth+dia.Theme.TimeWidth)
+	if auto {
+		lay.times.Nice(count)
+	}
+	lay.axis = scale.NewAxis(axis.Top, lay.times, count)
+	lay.axis.Measurer = measurer
+
+	top := padding + lay.axis.Extent()
+	y := top
+	for _, section := range dia.Sections {
+		if section.Name != "" {
+			y += dia.Theme.RowHeight
+		}
+		for _, task := range section.Tasks {
+			task.y = y + dia.Theme.RowHeight/2
+			y += dia.Theme.RowHeight
+		}
+	}
+
+	lay.area = diagram.R(lay.labelWidth, top, lay.labelWidth+dia.Theme.TimeWidth, y)
+	bounds := lay.axis.Bounds(lay.area)
+	lay.size = diagram.P(math.Max(lay.area.Max.X, bounds.Max.X)+padding, y+padding)
+	return lay
+}
+
+// Size returns the size needed to draw the diagram.
+func (dia *Diagram) Size() (width, height float64) {
+	lay := dia.layout()
+	return lay.size.X, lay.size.Y
+}
+
+// Draw draws the diagram.
+func (dia *Diagram) Draw(canvas diagram.Canvas) {
+	lay := dia.layout()
+
+	guide := canvas.Layer(-1)
+	bars := canvas.Layer(0)
+	texts := canvas.Layer(1)
+
+	padding := dia.Theme.Padding
+	rowHeight := dia.Theme.RowHeight
+
+	// sections
+	y := lay.area.Min.Y
+	for i, section := range dia.Sections {
+		top := y
+		if section.Name != "" {
+			style := *section.Caption.Or(dia.Theme.Section)
+			style.Origin = diagram.P(-1, 0)
+			texts.Text(section.Name, diagram.P(padding, y+rowHeight/2), &style)
+			y += rowHeight
+		}
+		y += rowHeight * float64(len(section.Tasks))
+
+		if i%2 == 1 && !dia.Theme.SectionFill.IsZero() {
+			guide.Rect(diagram.R(0, top, lay.size.X, y), &dia.Theme.SectionFill)
+		}
+	}
+	lay.axis.Draw(guide, lay.area)
+
+	// tasks
+	for _, section := range dia.Sections {
+		indent := padding
+		if section.Name != "" {
+			indent = 2 * padding
+		}
+		for _, task := range section.Tasks {
+			style := *task.Caption.Or(dia.Theme.Label)
+			style.Origin = diagram.P(-1, 0)
+			texts.Text(task.Name, diagram.P(indent, task.y), &style)
+
+			if task.start.IsZero() {
+				continue
+			}
+			if task.Milestone {
+				dia.drawMilestone(bars, lay, task)
+			} else {
+				dia.drawTask(bars, lay, task)
+			}
+		}
+	}
+
+	// dependencies
+	for _, task := range dia.tasks() {
+		for _, dep := range task.Dependencies {
+			if task.start.IsZero() || dep.start.IsZero() {
+				continue
+			}
+			dia.drawDependency(texts, lay, dep, task)
+		}
+	}
+
+	if !dia.Today.IsZero() {
+		x := lay.times.Map(dia.Today)
+		texts.Poly(diagram.Ps(x, lay.area.Min.Y, x, lay.area.Max.Y), &dia.Theme.Today)
+	}
+}
+
+// hint returns the tooltip of a task.
+func hint(task *Task) string {
+	if task.Milestone {
+		return task.Name + "\n" + formatTime(task.start)
+	}
+	text := task.Name + "\n" + formatTime(task.start) + " - " + formatTime(task.end()) +
+		" (" + axis.FormatDuration(task.Duration) + ")"
+	if task.Progress > 0 {
+		text += "\n" + strconv.Itoa(int(math.Round(task.Progress*100))) + "% done"
+	}
+	return text
+}
+
+// formatTime formats t as a date, including time of day when not midnight.
+func formatTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func (dia *Diagram) drawTask(canvas diagram.Canvas, lay *layout, task *Task) {
+	half := dia.Theme.BarHeight / 2
+	r := diagram.R(lay.times.Map(task.start), task.y-half, lay.times.Map(task.end()), task.y+half)
+
+	style := *task.Bar.Or(dia.Theme.Bar)
+	style.Hint = hint(task)
+	canvas.Path(diagram.RoundedRect(r, 3), &style)
+
+	if progress := math.Max(0, math.Min(task.Progress, 1)); progress > 0 {
+		done := r
+		done.Max.X = r.Min.X + (r.Max.X-r.Min.X)*progress
+		style := dia.Theme.Progress
+		style.Hint = hint(task)
+		canvas.Path(diagram.RoundedRect(done, 3), &style)
+	}
+}
+
+func (dia *Diagram) drawMilestone(canvas diagram.Canvas, lay *layout, task *Task) {
+	x, y, r := lay.times.Map(task.start), task.y, dia.Theme.BarHeight/2
+	style := *task.Bar.Or(dia.Theme.Milestone)
+	style.Hint = hint(task)
+	canvas.Poly(diagram.Ps(x, y-r, x+r, y, x, y+r, x-r, y, x, y-r), &style)
+}
+
+// endpoint returns where dependency arrows attach to task,
+// at the end when finish is set and otherwise at the start.
+func (dia *Diagram) endpoint(lay *layout, task *Task, finish bool) diagram.Point {
+	if task.Milestone {
+		x, r := lay.times.Map(task.start), dia.Theme.BarHeight/2
+		if finish {
+			return diagram.P(x+r, task.y)
+		}
+		return diagram.P(x-r, task.y)
+	}
+	if finish {
+		return diagram.P(lay.times.Map(task.end()), task.y)
+	}
+	return diagram.P(lay.times.Map(task.start), task.y)
+}
+
+// drawDependency draws an orthogonal arrow from the end of dep
+// to the start of task.
+func (dia *Diagram) drawDependency(canvas diagram.Canvas, lay *layout, dep, task *Task) {
+	from := dia.endpoint(lay, dep, true)
+	to := dia.endpoint(lay, task, false)
+	gap := dia.Theme.Padding
+
+	var points []diagram.Point
+	if to.X-gap >= from.X+gap || from.Y == to.Y {
+		points = []diagram.Point{
+			from,
+			{X: from.X + gap, Y: from.Y},
+			{X: from.X + gap, Y: to.Y},
+			to,
+		}
+		if from.Y == to.Y {
+			points = []diagram.Point{from, to}
+		}
+	} else {
+		// route around the bars between the rows
+		between := to.Y - dia.Theme.RowHeight/2
+		if to.Y < from.Y {
+			between = to.Y + dia.Theme.RowHeight/2
+		}
+		points = []diagram.Point{
+			from,
+			{X: from.X + gap, Y: from.Y},
+			{X: from.X + gap, Y: between},
+			{X: to.X - gap, Y: between},
+			{X: to.X - gap, Y: to.Y},
+			to,
+		}
+	}
+
+	style := dia.Theme.Dependency
+	style.Hint = dep.Name + " -> " + task.Name
+	canvas.Poly(points, &style)
+}
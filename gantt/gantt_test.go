@@ -0,0 +1,104 @@
+package gantt_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/gantt"
+)
+
+// end returns the scheduled end of task.
+func end(task *gantt.Task) time.Time { return task.Scheduled().Add(task.Duration) }
+
+func TestSchedule(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	dia := gantt.New()
+	a := gantt.NewTask("a", start, 2*day)
+	b := gantt.NewTask("b", time.Time{}, day)
+	c := gantt.NewTask("c", start, 5*day)
+	d := gantt.NewMilestone("d", time.Time{}).After(b, c)
+	dia.Section("first").Add(a, b)
+	dia.Section("second").Add(c, d)
+
+	// cycles must not hang
+	e := gantt.NewTask("e", time.Time{}, day)
+	f := gantt.NewTask("f", time.Time{}, day).After(e)
+	e.After(f)
+	dia.Add(e, f)
+
+	svg := diagram.NewSVG(dia.Size())
+	dia.Draw(svg)
+
+	if !b.Scheduled().Equal(end(a)) {
+		t.Errorf("b should start after previous task: got %v", b.Scheduled())
+	}
+	if !d.Scheduled().Equal(end(c)) {
+		t.Errorf("d should start after the last dependency: got %v", d.Scheduled())
+	}
+	// f is placed after the closest placed task and e after f
+	if !f.Scheduled().Equal(end(d)) {
+		t.Errorf("f should start after d: got %v", f.Scheduled())
+	}
+	if !e.Scheduled().Equal(end(f)) {
+		t.Errorf("e should start after f: got %v", e.Scheduled())
+	}
+	if first, last := dia.Span(); !first.Equal(start) || !last.Equal(end(e)) {
+		t.Errorf("expected span %v - %v, got %v - %v", start, end(e), first, last)
+	}
+
+	output := string(svg.Bytes())
+	if strings.Contains(output, "NaN") || strings.Contains(output, "Inf") {
+		t.Errorf("invalid output:\n%s", output)
+	}
+}
+
+func TestScheduleLaterDependency(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	dia := gantt.New()
+	a := gantt.NewTask("a", start, 2*day)
+	b := gantt.NewTask("b", time.Time{}, day)
+	c := gantt.NewTask("c", time.Time{}, 3*day)
+	dia.Add(a, b.After(c), c)
+	dia.Size()
+
+	if !c.Scheduled().Equal(end(a)) {
+		t.Errorf("c should start after a: got %v", c.Scheduled())
+	}
+	if !b.Scheduled().Equal(end(c)) {
+		t.Errorf("b should start after c: got %v", b.Scheduled())
+	}
+	if first, last := dia.Span(); !first.Equal(start) || !last.Equal(end(b)) {
+		t.Errorf("expected span %v - %v, got %v - %v", start, end(b), first, last)
+	}
+}
+
+func TestScheduleKeepsTasks(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	dia := gantt.New()
+	a := gantt.NewTask("a", start, 2*day)
+	b := gantt.NewTask("b", time.Time{}, day)
+	dia.Add(a, b)
+	dia.Draw(diagram.NewSVG(dia.Size()))
+
+	if !b.Start.IsZero() {
+		t.Errorf("drawing should not modify the start of b, got %v", b.Start)
+	}
+	if !b.Scheduled().Equal(end(a)) {
+		t.Errorf("b should start after a: got %v", b.Scheduled())
+	}
+
+	// changes are picked up by the next draw
+	a.Duration = 5 * day
+	dia.Draw(diagram.NewSVG(dia.Size()))
+	if !b.Scheduled().Equal(start.Add(5 * day)) {
+		t.Errorf("b should follow the new end of a: got %v", b.Scheduled())
+	}
+}
@@ -76,6 +76,79 @@ func (r Rect) Row(i, count int) Rect {
 	return R(r.Min.X, y0, r.Max.X, y1)
 }
 
+// Shapes
+
+func Circle(center Point, radius Length) *Path {
+	return Ellipse(center, Point{radius, radius})
+}
+
+func Ellipse(center Point, radius Point) *Path {
+	left := Point{center.X - radius.X, center.Y}
+	right := Point{center.X + radius.X, center.Y}
+	return NewPath().
+		MoveTo(right).
+		ArcTo(radius, 0, false, true, left).
+		ArcTo(radius, 0, false, true, right).
+		Close()
+}
+
+func RoundedRect(r Rect, radius Length) *Path {
+	size := r.Size()
+	radius = math.Max(0, math.Min(radius, math.Min(size.X, size.Y)*0.5))
+	if radius == 0 {
+		return NewPath().Polyline(r.Points()[:4]...).Close()
+	}
+
+	corner := Point{radius, radius}
+	return NewPath().
+		MoveTo(Point{r.Min.X + radius, r.Min.Y}).
+		LineTo(Point{r.Max.X - radius, r.Min.Y}).
+		ArcTo(corner, 0, false, true, Point{r.Max.X, r.Min.Y + radius}).
+		LineTo(Point{r.Max.X, r.Max.Y - radius}).
+		ArcTo(corner, 0, false, true, Point{r.Max.X - radius, r.Max.Y}).
+		LineTo(Point{r.Min.X + radius, r.Max.Y}).
+		ArcTo(corner, 0, false, true, Point{r.Min.X, r.Max.Y - radius}).
+		LineTo(Point{r.Min.X, r.Min.Y + radius}).
+		ArcTo(corner, 0, false, true, Point{r.Min.X + radius, r.Min.Y}).
+		Close()
+}
+
+// Polar returns the point at radius from center in the direction of angle,
+// angles are in radians measured clockwise from the positive X axis.
+func Polar(center Point, radius Length, angle float64) Point {
+	return Point{
+		X: center.X + radius*math.Cos(angle),
+		Y: center.Y + radius*math.Sin(angle),
+	}
+}
+
+// Sector returns a ring segment between inner and outer radius from angle
+// start to end, inner radius 0 results in a pie slice.
+// Angles are in radians measured clockwise from the positive X axis.
+func Sector(center Point, inner, outer Length, start, end float64) *Path {
+	path := NewPath().MoveTo(Polar(center, outer, start))
+	arcAround(path, center, outer, start, end)
+	if inner > 0 {
+		path.LineTo(Polar(center, inner, end))
+		arcAround(path, center, inner, end, start)
+	} else {
+		path.LineTo(center)
+	}
+	return path.Close()
+}
+
+// arcAround adds a circular arc around center from angle start to end,
+// the arc is split such that full circles are drawn correctly.
+func arcAround(path *Path, center Point, radius Length, start, end float64) {
+	const maxSweep = math.Pi / 2
+	n := int(math.Ceil(math.Abs(end-start) / maxSweep))
+	radii := Point{radius, radius}
+	for i := 1; i <= n; i++ {
+		angle := start + (end-start)*float64(i)/float64(n)
+		path.ArcTo(radii, 0, false, end > start, Polar(center, radius, angle))
+	}
+}
+
 // Convenience functions
 
 func Ps(cs ...Length) []Point {
@@ -115,3 +188,129 @@ func Points(x, y []float64) []Point {
 
 	return points
 }
+
+// Affine is a 2D affine transformation, it maps points as:
+//
+//	x' = A*x + C*y + E
+//	y' = B*x + D*y + F
+//
+// The fields are in the same order as SVG and PDF matrices.
+// Canvases treat the zero value as Identity.
+type Affine struct{ A, B, C, D, E, F float64 }
+
+func Identity() Affine { return Affine{A: 1, D: 1} }
+
+func Translate(by Point) Affine   { return Affine{A: 1, D: 1, E: by.X, F: by.Y} }
+func Scale(sx, sy float64) Affine { return Affine{A: sx, D: sy} }
+
+// Rotate rotates by angle in radians.
+func Rotate(angle float64) Affine {
+	sn, cs := math.Sincos(angle)
+	return Affine{A: cs, B: sn, C: -sn, D: cs}
+}
+
+// Skew skews along x and y axes by angles in radians.
+func Skew(ax, ay float64) Affine {
+	return Affine{A: 1, B: math.Tan(ay), C: math.Tan(ax), D: 1}
+}
+
+// RotateAround rotates by angle around the center.
+func RotateAround(center Point, angle float64) Affine {
+	return Translate(center.Neg()).Then(Rotate(angle)).Then(Translate(center))
+}
+
+// Then returns a transform that first applies m and then next.
+func (m Affine) Then(next Affine) Affine {
+	return Affine{
+		A: next.A*m.A + next.C*m.B,
+		B: next.B*m.A + next.D*m.B,
+		C: next.A*m.C + next.C*m.D,
+		D: next.B*m.C + next.D*m.D,
+		E: next.A*m.E + next.C*m.F + next.E,
+		F: next.B*m.E + next.D*m.F + next.F,
+	}
+}
+
+func (m Affine) IsIdentity() bool { return m == Identity() }
+
+// IsZero checks whether m is the zero value.
+func (m Affine) IsZero() bool { return m == Affine{} }
+
+// orIdentity returns Identity for the zero value and m otherwise.
+func (m Affine) orIdentity() Affine {
+	if m.IsZero() {
+		return Identity()
+	}
+	return m
+}
+
+// IsTranslation checks whether m only translates.
+func (m Affine) IsTranslation() bool { return m.A == 1 && m.B == 0 && m.C == 0 && m.D == 1 }
+
+func (m Affine) Determinant() float64 { return m.A*m.D - m.B*m.C }
+
+// Invert returns the inverse transform, ok is false when m is not invertible.
+func (m Affine) Invert() (inv Affine, ok bool) {
+	det := m.Determinant()
+	if det == 0 {
+		return Affine{}, false
+	}
+	return Affine{
+		A: m.D / det,
+		B: -m.B / det,
+		C: -m.C / det,
+		D: m.A / det,
+		E: (m.C*m.F - m.D*m.E) / det,
+		F: (m.B*m.E - m.A*m.F) / det,
+	}, true
+}
+
+func (m Affine) Apply(p Point) Point {
+	return Point{
+		X: m.A*p.X + m.C*p.Y + m.E,
+		Y: m.B*p.X + m.D*p.Y + m.F,
+	}
+}
+
+// ApplyVector transforms p ignoring the translation.
+func (m Affine) ApplyVector(p Point) Point {
+	return Point{
+		X: m.A*p.X + m.C*p.Y,
+		Y: m.B*p.X + m.D*p.Y,
+	}
+}
+
+// ApplyRect returns the bounding box of the transformed rectangle.
+func (m Affine) ApplyRect(r Rect) Rect {
+	corners := r.Points()[:4]
+	result := Rect{m.Apply(corners[0]), m.Apply(corners[0])}
+	for _, p := range corners[1:] {
+		p = m.Apply(p)
+		result.Min = result.Min.Min(p)
+		result.Max = result.Max.Max(p)
+	}
+	return result
+}
+
+func (m Affine) ApplyPoints(points []Point) []Point {
+	result := make([]Point, len(points))
+	for i, p := range points {
+		result[i] = m.Apply(p)
+	}
+	return result
+}
+
+// ApplyPath returns the transformed path.
+//
+// Arcs are converted to curves, unless m only translates.
+func (m Affine) ApplyPath(path *Path) *Path {
+	if !m.IsTranslation() {
+		path = path.cubics()
+	}
+	result := &Path{Commands: make([]PathCommand, len(path.Commands))}
+	for i, cmd := range path.Commands {
+		cmd.Points = m.ApplyPoints(cmd.Points)
+		result.Commands[i] = cmd
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package diagram_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+func checkAffine(t *testing.T, got, expected diagram.Affine) {
+	t.Helper()
+	a := []float64{got.A, got.B, got.C, got.D, got.E, got.F}
+	b := []float64{expected.A, expected.B, expected.C, expected.D, expected.E, expected.F}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			t.Errorf("expected %+v, got %+v", expected, got)
+			return
+		}
+	}
+}
+
+func TestAffineThen(t *testing.T) {
+	p := diagram.P(3, 4)
+
+	m := diagram.Translate(diagram.P(10, 0)).Then(diagram.Scale(2, 3))
+	if got := m.Apply(p); got != diagram.P(26, 12) {
+		t.Errorf("translate then scale: expected 26,12, got %v", got)
+	}
+	m = diagram.Scale(2, 3).Then(diagram.Translate(diagram.P(10, 0)))
+	if got := m.Apply(p); got != diagram.P(16, 12) {
+		t.Errorf("scale then translate: expected 16,12, got %v", got)
+	}
+
+	rotated := diagram.RotateAround(diagram.P(10, 10), math.Pi/2).Apply(diagram.P(20, 10))
+	if distance(rotated, diagram.P(10, 20)) > 1e-9 {
+		t.Errorf("expected rotation to 10,20, got %v", rotated)
+	}
+
+	checkAffine(t, diagram.Identity().Then(m), m)
+	checkAffine(t, m.Then(diagram.Identity()), m)
+}
+
+func TestAffineInvert(t *testing.T) {
+	for _, m := range []diagram.Affine{
+		diagram.Identity(),
+		diagram.Translate(diagram.P(-5, 7)),
+		diagram.Scale(2, 0.5),
+		diagram.Rotate(0.7),
+		diagram.Skew(0.3, -0.2),
+		diagram.RotateAround(diagram.P(3, 4), 1).Then(diagram.Scale(3, 2)).Then(diagram.Translate(diagram.P(1, 1))),
+	} {
+		inv, ok := m.Invert()
+		if !ok {
+			t.Errorf("%+v should be invertible", m)
+			continue
+		}
+		checkAffine(t, m.Then(inv), diagram.Identity())
+		checkAffine(t, inv.Then(m), diagram.Identity())
+
+		p := diagram.P(12, -7)
+		if got := inv.Apply(m.Apply(p)); distance(got, p) > 1e-9 {
+			t.Errorf("round trip of %v gave %v", p, got)
+		}
+	}
+
+	if _, ok := diagram.Scale(0, 1).Invert(); ok {
+		t.Errorf("singular transform should not be invertible")
+	}
+}
+
+func TestAffineApplyRect(t *testing.T) {
+	r := diagram.R(0, 0, 10, 20)
+	checkRect(t, diagram.Translate(diagram.P(5, 5)).ApplyRect(r), diagram.R(5, 5, 15, 25), 1e-9)
+	checkRect(t, diagram.Scale(-1, 1).ApplyRect(r), diagram.R(-10, 0, 0, 20), 1e-9)
+	checkRect(t, diagram.Rotate(math.Pi/2).ApplyRect(r), diagram.R(-20, 0, 0, 10), 1e-9)
+
+	s := math.Sqrt(0.5)
+	checkRect(t, diagram.Rotate(math.Pi/4).ApplyRect(diagram.R(-1, -1, 1, 1)), diagram.R(-2*s, -2*s, 2*s, 2*s), 1e-9)
+
+	// bounds of a transformed canvas map back to the parent bounds
+	m := diagram.RotateAround(diagram.P(50, 25), 0.3)
+	rotated := diagram.NewRecorder(100, 50).Transform(m)
+	inv, _ := m.Invert()
+	checkRect(t, rotated.Bounds(), inv.ApplyRect(diagram.R(0, 0, 100, 50)), 1e-9)
+}
+
+func TestAffineZero(t *testing.T) {
+	if !(diagram.Affine{}).IsZero() || diagram.Identity().IsZero() {
+		t.Errorf("invalid IsZero")
+	}
+
+	svg := &diagram.SVG{}
+	svg.SetSize(10, 10)
+	svg.Rect(diagram.R(0, 0, 5, 5), &diagram.Style{Fill: red})
+	if out := string(svg.Bytes()); strings.Contains(out, "matrix(") {
+		t.Errorf("zero value SVG should not have a transform:\n%s", out)
+	}
+
+	// zero transform draws the same as identity
+	for _, newCanvas := range []func() diagram.Canvas{
+		func() diagram.Canvas { return diagram.NewSVG(20, 20) },
+		func() diagram.Canvas { return diagram.NewPDF(20, 20) },
+		func() diagram.Canvas { return diagram.NewImage(20, 20) },
+	} {
+		zero, identity := newCanvas(), newCanvas()
+		zero.Transform(diagram.Affine{}).Rect(diagram.R(5, 5, 15, 15), &diagram.Style{Fill: red})
+		identity.Transform(diagram.Identity()).Rect(diagram.R(5, 5, 15, 15), &diagram.Style{Fill: red})
+
+		encode := func(c diagram.Canvas) []byte { return c.(interface{ Bytes() []byte }).Bytes() }
+		if !bytes.Equal(encode(zero), encode(identity)) {
+			t.Errorf("%T: zero transform differs from identity", zero)
+		}
+	}
+
+	if bounds := diagram.NewRecorder(20, 20).Transform(diagram.Affine{}).Bounds(); bounds != diagram.R(0, 0, 20, 20) {
+		t.Errorf("zero transform should keep bounds, got %v", bounds)
+	}
+}
@@ -0,0 +1,367 @@
+package diagram
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+)
+
+// Image is a Canvas that rasterizes into an *image.RGBA.
+type Image struct {
+	Background color.Color
+	imageContext
+}
+
+type imageContext struct {
+	index int
+	clip  bool
+	// transform relative to parent
+	transform Affine
+	// bounds in local coordinates
+	bounds   Rect
+	elements []imageElement
+	layers   []*imageContext
+}
+
+type imageElement struct {
+	// style
+	style Style
+	// line
+	points []Point
+	// path
+	path *Path
+	// text
+	text   string
+	origin Point
+	// context
+	context *imageContext
+}
+
+func NewImage(width, height Length) *Image {
+	img := &Image{}
+	img.Background = color.White
+	img.transform = Identity()
+	img.bounds.Max.X = width
+	img.bounds.Max.Y = height
+	return img
+}
+
+func (img *Image) SetSize(width, height Length) {
+	img.bounds.Max.X = width
+	img.bounds.Max.Y = height
+}
+
+// Bytes returns the image encoded as PNG.
+func (img *Image) Bytes() []byte {
+	var buffer bytes.Buffer
+	img.EncodePNG(&buffer)
+	return buffer.Bytes()
+}
+
+// EncodePNG rasterizes the image and writes it as PNG.
+func (img *Image) EncodePNG(dst io.Writer) error {
+	return png.Encode(dst, img.RGBA())
+}
+
+func (img *imageContext) Bounds() Rect { return img.bounds }
+func (img *imageContext) Size() Point  { return img.bounds.Size() }
+
+func (img *imageContext) context(transform Affine, bounds Rect, clip bool) Canvas {
+	element := imageElement{}
+	element.context = &imageContext{}
+	element.context.clip = clip
+	element.context.transform = transform
+	element.context.bounds = bounds
+	img.elements = append(img.elements, element)
+	return element.context
+}
+
+func (img *imageContext) Context(r Rect) Canvas {
+	return img.context(Translate(r.Min), r.Zero(), false)
+}
+
+func (img *imageContext) Clip(r Rect) Canvas {
+	return img.context(Translate(r.Min), r.Zero(), true)
+}
+
+func (img *imageContext) Transform(m Affine) Canvas {
+	m = m.orIdentity()
+	inv, _ := m.Invert()
+	return img.context(m, inv.ApplyRect(img.bounds), false)
+}
+
+func (img *imageContext) Layer(index int) Canvas {
+	if index == 0 {
+		return img
+	}
+
+	i := sort.Search(len(img.layers), func(i int) bool {
+		return img.layers[i].index > index
+	})
+	if i > 0 && img.layers[i-1].index == index {
+		return img.layers[i-1]
+	}
+
+	layer := &imageContext{}
+	layer.index = index
+	layer.transform = Identity()
+	layer.bounds = img.bounds
+
+	img.layers = append(img.layers, layer)
+	copy(img.layers[i+1:], img.layers[i:])
+	img.layers[i] = layer
+	return layer
+}
+
+func (img *imageContext) Text(text string, at Point, style *Style) {
+	style.mustExist()
+	img.elements = append(img.elements, imageElement{
+		text:   text,
+		origin: at,
+		style:  *style,
+	})
+}
+
+func (img *imageContext) Poly(points []Point, style *Style) {
+	style.mustExist()
+	img.elements = append(img.elements, imageElement{
+		points: points,
+		style:  *style,
+	})
+}
+
+func (img *imageContext) Rect(r Rect, style *Style) {
+	img.Poly(r.Points(), style)
+}
+
+func (img *imageContext) Path(path *Path, style *Style) {
+	style.mustExist()
+	if path == nil {
+		return
+	}
+	img.elements = append(img.elements, imageElement{
+		path:  path.clone(),
+		style: *style,
+	})
+}
+
+// RGBA rasterizes the image.
+func (img *Image) RGBA() *image.RGBA {
+	size := img.bounds.Size()
+	width, height := int(math.Ceil(size.X)), int(math.Ceil(size.Y))
+
+	r := &imageRenderer{}
+	r.dst = image.NewRGBA(image.Rect(0, 0, width, height))
+	r.z = newRasterizer(width, height)
+
+	if img.Background != nil {
+		r.z.polygon(R(0, 0, size.X, size.Y).Points())
+		r.z.draw(r.dst, img.Background, nil)
+	}
+
+	// match the half-pixel offset of the SVG output
+	r.drawLayer(&img.imageContext, Translate(Point{0.5, 0.5}), nil)
+
+	return r.dst
+}
+
+type imageRenderer struct {
+	dst *image.RGBA
+	z   *rasterizer
+}
+
+func (r *imageRenderer) drawLayer(img *imageContext, m Affine, clip *image.Alpha) {
+	m = img.transform.orIdentity().Then(m)
+	if img.clip {
+		r.z.transform = m
+		r.z.polygon(img.bounds.Points())
+		clip = r.z.mask(clip)
+		if clip.Rect.Empty() {
+			return
+		}
+	}
+
+	after := len(img.layers)
+	for i, layer := range img.layers {
+		if layer.index >= 0 {
+			after = i
+			break
+		}
+		r.drawLayer(layer, m, clip)
+	}
+
+	for i := range img.elements {
+		r.drawElement(&img.elements[i], m, clip)
+	}
+
+	for _, layer := range img.layers[after:] {
+		r.drawLayer(layer, m, clip)
+	}
+}
+
+func (r *imageRenderer) drawElement(el *imageElement, m Affine, clip *image.Alpha) {
+	// geometry is computed in local coordinates and
+	// transformed when adding to the rasterizer
+	r.z.transform = m
+
+	if len(el.points) > 0 {
+		points := el.points
+		r.fillPolygons([][]Point{points}, el.style.Fill, clip)
+		r.strokePolylines([][]Point{points}, []bool{points[0] == points[len(points)-1]}, &el.style, clip)
+		r.drawMarkers([][]Point{points}, &el.style, clip)
+	}
+	if !el.path.Empty() {
+		tolerance := flattenTolerance / math.Sqrt(math.Abs(m.Determinant()))
+		lines := el.path.flatten(tolerance)
+		polygons := make([][]Point, 0, len(lines))
+		closed := make([]bool, 0, len(lines))
+		for _, line := range lines {
+			polygons = append(polygons, line.points)
+			closed = append(closed, line.closed)
+		}
+		r.fillPolygons(polygons, el.style.Fill, clip)
+		r.strokePolylines(polygons, closed, &el.style, clip)
+		r.drawMarkers(polygons, &el.style, clip)
+	}
+	if el.text != "" {
+		r.drawText(el.text, el.origin, &el.style, clip)
+	}
+	if el.context != nil {
+		r.drawLayer(el.context, m, clip)
+	}
+}
+
+func (r *imageRenderer) fillPolygons(polygons [][]Point, fill color.Color, clip *image.Alpha) {
+	if fill == nil {
+		return
+	}
+	for _, poly := range polygons {
+		r.z.polygon(poly)
+	}
+	r.z.draw(r.dst, fill, clip)
+}
+
+func (r *imageRenderer) strokePolylines(polylines [][]Point, closed []bool, style *Style, clip *image.Alpha) {
+	if style.Stroke == nil {
+		return
+	}
+
+	width := style.Size
+	if width == 0 {
+		width = 1
+	}
+
+	for i, points := range polylines {
+		if len(points) < 2 {
+			continue
+		}
+
+		if len(style.Dash) == 0 {
+			for _, poly := range strokePolyline(points, width, closed[i]) {
+				r.z.polygon(poly)
+			}
+			continue
+		}
+
+		offset := Length(0)
+		if len(style.DashOffset) > 0 {
+			offset = style.DashOffset[0]
+		}
+		for _, line := range dashPolyline(points, style.Dash, offset) {
+			for _, poly := range strokePolyline(line, width, false) {
+				r.z.polygon(poly)
+			}
+		}
+	}
+	r.z.draw(r.dst, style.Stroke, clip)
+}
+
+func (r *imageRenderer) drawMarkers(lines [][]Point, style *Style, clip *image.Alpha) {
+	if style.Stroke == nil || (style.StartMarker == MarkerNone && style.EndMarker == MarkerNone) {
+		return
+	}
+	start, startAngle, end, endAngle, ok := lineEnds(lines)
+	if !ok {
+		return
+	}
+
+	width := style.Size
+	if width == 0 {
+		width = 1
+	}
+
+	draw := func(marker Marker, tip Point, angle float64) {
+		lines, fills := marker.place(tip, angle, width)
+		for _, line := range lines {
+			for _, poly := range strokePolyline(line, width, false) {
+				r.z.polygon(poly)
+			}
+		}
+		for _, fill := range fills {
+			r.z.polygon(fill)
+		}
+		r.z.draw(r.dst, style.Stroke, clip)
+	}
+
+	draw(style.StartMarker, start, startAngle)
+	draw(style.EndMarker, end, endAngle)
+}
+
+func (r *imageRenderer) drawText(text string, at Point, style *Style, clip *image.Alpha) {
+	fill := style.Fill
+	if fill == nil {
+		fill = style.Stroke
+	}
+	if fill == nil {
+		fill = color.Black
+	}
+
+	// glyphs are 5x7 units, stretched horizontally to match font metrics
+	layout := layoutText(text, style)
+	unit := layout.metrics.capHeight * layout.size / 1000 / 7
+
+	for _, line := range layout.lines {
+		m := Translate(line.offset).Then(Rotate(style.Rotation)).Then(Translate(at))
+		r.drawLine(line.text, &layout, unit, m)
+	}
+	r.z.draw(r.dst, fill, clip)
+}
+
+// drawLine adds glyphs of a single line of text to the rasterizer.
+func (r *imageRenderer) drawLine(text string, layout *textLayout, unit Length, m Affine) {
+	transform := m.Apply
+
+	x := 0.0
+	for _, c := range text {
+		advance := layout.metrics.runeWidth(c) * layout.size / 1000
+		columnWidth := advance / 6
+
+		glyph := glyph5x7(c)
+		for column, bits := range glyph {
+			x0 := x + float64(column)*columnWidth
+			x1 := x0 + columnWidth
+			for row := 0; row < 7; row++ {
+				if bits&(1<<uint(row)) == 0 {
+					continue
+				}
+				start := row
+				for row+1 < 7 && bits&(1<<uint(row+1)) != 0 {
+					row++
+				}
+				y0 := float64(start-7) * unit
+				y1 := float64(row+1-7) * unit
+				r.z.polygon([]Point{
+					transform(Point{x0, y0}),
+					transform(Point{x1, y0}),
+					transform(Point{x1, y1}),
+					transform(Point{x0, y1}),
+				})
+			}
+		}
+		x += advance
+	}
+}
@@ -0,0 +1,153 @@
+package diagram_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+var (
+	red  = color.RGBA{R: 0xFF, A: 0xFF}
+	blue = color.RGBA{B: 0xFF, A: 0xFF}
+)
+
+// decodePNG rasterizes img and decodes the resulting PNG.
+func decodePNG(t *testing.T, img *diagram.Image) image.Image {
+	t.Helper()
+	m, err := png.Decode(bytes.NewReader(img.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// rgba returns 8-bit color components at x, y.
+func rgba(m image.Image, x, y int) [4]uint8 {
+	r, g, b, a := m.At(x, y).RGBA()
+	return [4]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
+
+func checkPixel(t *testing.T, m image.Image, x, y int, expected color.Color) {
+	t.Helper()
+	r, g, b, a := expected.RGBA()
+	want := [4]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+	if got := rgba(m, x, y); got != want {
+		t.Errorf("pixel %d,%d: expected %v, got %v", x, y, want, got)
+	}
+}
+
+func TestImageFill(t *testing.T) {
+	img := diagram.NewImage(20, 20)
+	// the half-pixel offset makes the edges cover half of a pixel
+	img.Rect(diagram.R(4, 4, 12, 12), &diagram.Style{Fill: red})
+	m := decodePNG(t, img)
+
+	if size := m.Bounds().Size(); size != image.Pt(20, 20) {
+		t.Fatalf("expected 20x20, got %v", size)
+	}
+	checkPixel(t, m, 0, 0, color.White)
+	checkPixel(t, m, 8, 8, red)
+	checkPixel(t, m, 16, 8, color.White)
+
+	// anti-aliased edges blend with the background
+	for _, p := range []image.Point{{4, 8}, {12, 8}, {8, 4}, {8, 12}} {
+		c := rgba(m, p.X, p.Y)
+		if c[0] != 0xFF || c[1] < 0x40 || c[1] > 0xC0 {
+			t.Errorf("edge pixel %v: expected partial coverage, got %v", p, c)
+		}
+	}
+}
+
+func TestImageStroke(t *testing.T) {
+	img := diagram.NewImage(20, 20)
+	img.Poly(diagram.Ps(2, 9.5, 18, 9.5), &diagram.Style{Stroke: color.Black, Size: 2})
+	m := decodePNG(t, img)
+
+	checkPixel(t, m, 10, 9, color.Black)
+	checkPixel(t, m, 10, 10, color.Black)
+	checkPixel(t, m, 10, 5, color.White)
+	checkPixel(t, m, 10, 14, color.White)
+}
+
+func TestImageClip(t *testing.T) {
+	img := diagram.NewImage(20, 20)
+	img.Clip(diagram.R(5, 5, 10, 10)).Rect(diagram.R(-10, -10, 30, 30), &diagram.Style{Fill: red})
+	m := decodePNG(t, img)
+
+	checkPixel(t, m, 7, 7, red)
+	for _, p := range []image.Point{{2, 2}, {12, 7}, {7, 12}, {18, 18}} {
+		checkPixel(t, m, p.X, p.Y, color.White)
+	}
+}
+
+func TestImageLayers(t *testing.T) {
+	img := diagram.NewImage(20, 20)
+	img.Layer(1).Rect(diagram.R(0, 0, 10, 20), &diagram.Style{Fill: blue})
+	img.Rect(diagram.R(0, 0, 20, 20), &diagram.Style{Fill: red})
+	img.Layer(-1).Rect(diagram.R(0, 0, 20, 20), &diagram.Style{Fill: color.Black})
+	m := decodePNG(t, img)
+
+	checkPixel(t, m, 5, 10, blue)
+	checkPixel(t, m, 15, 10, red)
+
+	if img.Layer(1) != img.Layer(1) {
+		t.Errorf("layer should be reused")
+	}
+}
+
+func TestImageTransform(t *testing.T) {
+	img := diagram.NewImage(20, 20)
+	rotated := img.Transform(diagram.RotateAround(diagram.P(10, 10), 3.14159265/2))
+	rotated.Rect(diagram.R(0, 8, 20, 12), &diagram.Style{Fill: red})
+	m := decodePNG(t, img)
+
+	// horizontal bar becomes vertical
+	checkPixel(t, m, 10, 2, red)
+	checkPixel(t, m, 10, 17, red)
+	checkPixel(t, m, 2, 10, color.White)
+}
+
+func TestImageText(t *testing.T) {
+	dark := func(m image.Image, r image.Rectangle) int {
+		n := 0
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if c := rgba(m, x, y); c[0] < 0x80 {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	img := diagram.NewImage(60, 30)
+	style := &diagram.Style{Fill: color.Black, Size: 16, Origin: diagram.P(-1, 0)}
+	img.Text("HI", diagram.P(5, 15), style)
+	m := decodePNG(t, img)
+
+	text := diagram.DefaultMeasurer.Measure("HI", style).Offset(diagram.P(5, 15))
+	inside := image.Rect(int(text.Min.X), int(text.Min.Y), int(text.Max.X)+2, int(text.Max.Y)+2)
+	if n := dark(m, inside); n < 20 {
+		t.Errorf("expected glyph pixels inside %v, got %d", inside, n)
+	}
+	if n := dark(m, m.Bounds()) - dark(m, inside); n != 0 {
+		t.Errorf("expected no glyph pixels outside %v, got %d", inside, n)
+	}
+
+	empty := diagram.NewImage(60, 30)
+	empty.Text(" ", diagram.P(5, 15), style)
+	if n := dark(decodePNG(t, empty), m.Bounds()); n != 0 {
+		t.Errorf("space should not draw, got %d pixels", n)
+	}
+
+	clipped := diagram.NewImage(60, 30)
+	clipped.Clip(diagram.R(0, 0, 60, 10)).Text("HI", diagram.P(5, 15), style)
+	cm := decodePNG(t, clipped)
+	if n := dark(cm, image.Rect(0, 11, 60, 30)); n != 0 {
+		t.Errorf("expected clipped text, got %d pixels below clip", n)
+	}
+}
@@ -0,0 +1,102 @@
+package diagram
+
+import "math"
+
+// Marker is a shape drawn at the start or end of a line.
+//
+// Markers are scaled relative to the stroke width and
+// use the stroke color.
+type Marker string
+
+const (
+	MarkerNone     Marker = ""
+	MarkerArrow    Marker = "arrow"
+	MarkerTriangle Marker = "triangle"
+	MarkerCross    Marker = "cross"
+	MarkerCircle   Marker = "circle"
+	MarkerDiamond  Marker = "diamond"
+)
+
+// geometry returns marker shape pointing towards +X with the tip at origin,
+// using stroke width as the unit. Cross and circle are centered at origin.
+//
+// lines should be stroked with the stroke width and fills filled.
+// The line itself is not shortened, so near the tip of a triangle
+// or a diamond the line may be wider than the marker.
+func (marker Marker) geometry() (lines, fills [][]Point) {
+	switch marker {
+	case MarkerArrow:
+		sn, cs := math.Sincos(math.Pi / 8)
+		return [][]Point{{{-4 * cs, -4 * sn}, {0, 0}, {-4 * cs, 4 * sn}}}, nil
+	case MarkerTriangle:
+		return nil, [][]Point{{{0, 0}, {-6, -2.4}, {-6, 2.4}}}
+	case MarkerCross:
+		return [][]Point{
+			{{-2.8, -2.8}, {2.8, 2.8}},
+			{{-2.8, 2.8}, {2.8, -2.8}},
+		}, nil
+	case MarkerCircle:
+		return nil, [][]Point{circlePolygon(Point{}, 2)}
+	case MarkerDiamond:
+		return nil, [][]Point{{{0, 0}, {-3.5, -2}, {-7, 0}, {-3.5, 2}}}
+	}
+	return nil, nil
+}
+
+// place returns marker geometry placed at tip, pointing in direction angle.
+func (marker Marker) place(tip Point, angle float64, width Length) (lines, fills [][]Point) {
+	lines, fills = marker.geometry()
+
+	sn, cs := math.Sincos(angle)
+	transform := func(polys [][]Point) [][]Point {
+		result := make([][]Point, len(polys))
+		for i, poly := range polys {
+			result[i] = make([]Point, len(poly))
+			for k, p := range poly {
+				p = p.Scale(width)
+				result[i][k] = Point{
+					X: tip.X + p.X*cs - p.Y*sn,
+					Y: tip.Y + p.X*sn + p.Y*cs,
+				}
+			}
+		}
+		return result
+	}
+
+	return transform(lines), transform(fills)
+}
+
+// lineEnds returns the end points of polylines and the outward directions.
+func lineEnds(lines [][]Point) (start Point, startAngle float64, end Point, endAngle float64, ok bool) {
+	if len(lines) == 0 {
+		return
+	}
+
+	first := lines[0]
+	last := lines[len(lines)-1]
+	if len(first) < 2 || len(last) < 2 {
+		return
+	}
+
+	start = first[0]
+	next := start
+	for _, p := range first[1:] {
+		if p != start {
+			next = p
+			break
+		}
+	}
+
+	end = last[len(last)-1]
+	prev := end
+	for i := len(last) - 2; i >= 0; i-- {
+		if last[i] != end {
+			prev = last[i]
+			break
+		}
+	}
+
+	startAngle = math.Atan2(start.Y-next.Y, start.X-next.X)
+	endAngle = math.Atan2(end.Y-prev.Y, end.X-prev.X)
+	return start, startAngle, end, endAngle, true
+}
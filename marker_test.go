@@ -0,0 +1,80 @@
+package diagram_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+func TestMarkerTip(t *testing.T) {
+	const eps = 1e-9
+	for _, marker := range []diagram.Marker{diagram.MarkerArrow, diagram.MarkerTriangle, diagram.MarkerDiamond} {
+		lines, fills := marker.Place(diagram.P(0, 0), 0, 1)
+		tip := false
+		for _, poly := range append(lines, fills...) {
+			for _, p := range poly {
+				if p.X > eps {
+					t.Errorf("%v: %v is beyond the tip", marker, p)
+				}
+				if math.Abs(p.X) < eps && math.Abs(p.Y) < eps {
+					tip = true
+				}
+			}
+		}
+		if !tip {
+			t.Errorf("%v: expected a vertex at the tip", marker)
+		}
+	}
+
+	for _, marker := range []diagram.Marker{diagram.MarkerCross, diagram.MarkerCircle} {
+		lines, fills := marker.Place(diagram.P(0, 0), 0, 1)
+		var sum diagram.Point
+		n := 0
+		for _, poly := range append(lines, fills...) {
+			for _, p := range poly {
+				sum = sum.Add(p)
+				n++
+			}
+		}
+		if center := sum.Scale(1 / float64(n)); math.Abs(center.X) > 0.01 || math.Abs(center.Y) > 0.01 {
+			t.Errorf("%v: expected to be centered, got %v", marker, center)
+		}
+	}
+
+	if lines, fills := diagram.MarkerNone.Place(diagram.P(0, 0), 0, 1); len(lines) > 0 || len(fills) > 0 {
+		t.Errorf("none should not have geometry")
+	}
+}
+
+func TestMarkerPlace(t *testing.T) {
+	// pointing down with stroke width 2
+	_, fills := diagram.MarkerTriangle.Place(diagram.P(10, 20), math.Pi/2, 2)
+	triangle := fills[0]
+	if distance(triangle[0], diagram.P(10, 20)) > 1e-9 {
+		t.Errorf("tip should be at 10,20, got %v", triangle[0])
+	}
+	for _, p := range triangle[1:] {
+		if math.Abs(p.Y-8) > 1e-9 || math.Abs(math.Abs(p.X-10)-4.8) > 1e-9 {
+			t.Errorf("invalid base %v", p)
+		}
+	}
+}
+
+func TestMarkerRaster(t *testing.T) {
+	img := diagram.NewImage(40, 20)
+	img.Poly(diagram.Ps(4, 9.5, 30, 9.5), &diagram.Style{
+		Stroke:    color.Black,
+		Size:      2,
+		EndMarker: diagram.MarkerTriangle,
+	})
+	m := decodePNG(t, img)
+
+	// marker is drawn up to the line end and not beyond
+	checkPixel(t, m, 20, 7, color.Black)
+	checkPixel(t, m, 20, 12, color.Black)
+	for x := 31; x < 40; x++ {
+		checkPixel(t, m, x, 10, color.White)
+	}
+}
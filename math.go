@@ -2,7 +2,9 @@ package diagram
 
 import "math"
 
-func niceNumber(span float64, round bool) float64 {
+// NiceNumber returns a number in the form of 1, 2 or 5 times a power of ten
+// close to span. When round is false the result is at least span.
+func NiceNumber(span float64, round bool) float64 {
 	exp := math.Floor(math.Log10(span))
 	frac := span / math.Pow(10, exp)
 	var nice float64
@@ -0,0 +1,143 @@
+package diagram
+
+import "strings"
+
+// Measurer measures the space text occupies.
+type Measurer interface {
+	// Measure returns the bounds of text relative to the point
+	// where it is drawn with Canvas.Text.
+	Measure(text string, style *Style) Rect
+}
+
+// DefaultMeasurer measures text using built-in metrics for
+// Helvetica/Arial, Times and Courier. Other fonts are measured
+// with Helvetica metrics.
+var DefaultMeasurer Measurer = fontMeasurer{}
+
+type fontMeasurer struct{}
+
+func (fontMeasurer) Measure(text string, style *Style) Rect {
+	if style == nil {
+		style = &Style{}
+	}
+	layout := layoutText(text, style)
+	return Rotate(style.Rotation).ApplyRect(layout.bounds())
+}
+
+// defaultFontSize is the font size used when Style.Size is not specified.
+const defaultFontSize = 16
+
+// defaultLineHeight is the line height used when Style.LineHeight is not specified.
+const defaultLineHeight = 1.2
+
+// TextLines splits text into lines at newlines and wraps
+// them to Style.Wrap width using the built-in font metrics.
+func TextLines(text string, style *Style) []string {
+	if style == nil {
+		style = &Style{}
+	}
+	metrics := lookupFont(style.Font)
+	size := style.Size
+	if size == 0 {
+		size = defaultFontSize
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		paragraph = strings.TrimSuffix(paragraph, "\r")
+		if style.Wrap <= 0 {
+			lines = append(lines, paragraph)
+			continue
+		}
+
+		maxWidth := style.Wrap * 1000 / size
+		spaceWidth := metrics.runeWidth(' ')
+
+		line, lineWidth := "", 0.0
+		for _, word := range strings.Fields(paragraph) {
+			wordWidth := metrics.width(word)
+			if line != "" && lineWidth+spaceWidth+wordWidth > maxWidth {
+				lines = append(lines, line)
+				line, lineWidth = "", 0
+			}
+			if line != "" {
+				line += " "
+				lineWidth += spaceWidth
+			}
+			line += word
+			lineWidth += wordWidth
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// textLayout describes the placement of text lines.
+type textLayout struct {
+	metrics    *fontMetrics
+	size       Length
+	lineHeight Length
+	lines      []textLine
+}
+
+// textLine describes the placement of a single line of text.
+type textLine struct {
+	text  string
+	width Length
+	// offset is the start of the baseline relative to the anchor,
+	// before applying rotation.
+	offset Point
+}
+
+func layoutText(text string, style *Style) textLayout {
+	layout := textLayout{}
+	layout.metrics = lookupFont(style.Font)
+	layout.size = style.Size
+	if layout.size == 0 {
+		layout.size = defaultFontSize
+	}
+	layout.lineHeight = style.LineHeight
+	if layout.lineHeight == 0 {
+		layout.lineHeight = defaultLineHeight
+	}
+	layout.lineHeight *= layout.size
+
+	lines := TextLines(text, style)
+	capHeight := layout.metrics.capHeight * layout.size / 1000
+
+	// the first line is aligned to the top, the last to the bottom
+	// and the center of the block to the middle
+	y := capHeight*(1-style.Origin.Y)*0.5 - float64(len(lines)-1)*layout.lineHeight*(1+style.Origin.Y)*0.5
+
+	for _, line := range lines {
+		width := layout.metrics.width(line) * layout.size / 1000
+		layout.lines = append(layout.lines, textLine{
+			text:  line,
+			width: width,
+			offset: Point{
+				X: -width * (style.Origin.X + 1) * 0.5,
+				Y: y,
+			},
+		})
+		y += layout.lineHeight
+	}
+	return layout
+}
+
+// bounds returns the text box relative to the anchor, before applying rotation.
+func (layout *textLayout) bounds() Rect {
+	var bounds Rect
+	for i, line := range layout.lines {
+		r := R(
+			line.offset.X, line.offset.Y-layout.metrics.ascent*layout.size/1000,
+			line.offset.X+line.width, line.offset.Y-layout.metrics.descent*layout.size/1000,
+		)
+		if i == 0 {
+			bounds = r
+		} else {
+			bounds.Min = bounds.Min.Min(r.Min)
+			bounds.Max = bounds.Max.Max(r.Max)
+		}
+	}
+	return bounds
+}
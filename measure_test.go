@@ -0,0 +1,71 @@
+package diagram_test
+
+import (
+	"reflect"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+func TestTextLines(t *testing.T) {
+	tests := []struct {
+		text  string
+		wrap  diagram.Length
+		lines []string
+	}{
+		{"hello", 0, []string{"hello"}},
+		{"hello\nworld", 0, []string{"hello", "world"}},
+		{"hello world", 1000, []string{"hello world"}},
+		{"hello world", 50, []string{"hello", "world"}},
+		{"a verylongword b", 30, []string{"a", "verylongword", "b"}},
+		{"first line\n\nthird", 1000, []string{"first line", "", "third"}},
+	}
+
+	for _, test := range tests {
+		lines := diagram.TextLines(test.text, &diagram.Style{Wrap: test.wrap})
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("%q wrap %v: got %q expected %q", test.text, test.wrap, lines, test.lines)
+		}
+	}
+}
+
+func TestMeasureLines(t *testing.T) {
+	style := &diagram.Style{Origin: diagram.P(0, 0)}
+	single := diagram.DefaultMeasurer.Measure("hello", style)
+	double := diagram.DefaultMeasurer.Measure("hello\nhello", style)
+
+	if double.Size().Y <= single.Size().Y {
+		t.Errorf("expected multiple lines to be taller: %v %v", single, double)
+	}
+	if center(single) != center(double) {
+		t.Errorf("expected lines to be centered: %v %v", single, double)
+	}
+}
+
+func center(r diagram.Rect) diagram.Length { return (r.Min.Y + r.Max.Y) / 2 }
+
+func TestMeasureFonts(t *testing.T) {
+	width := func(font string) diagram.Length {
+		return diagram.DefaultMeasurer.Measure("iiiiWWWW", &diagram.Style{Font: font, Size: 10}).Size().X
+	}
+
+	helvetica, courier, times := width("Helvetica"), width("Courier New"), width("Times")
+	if helvetica == courier || helvetica == times || courier == times {
+		t.Fatalf("expected different metrics: %v %v %v", helvetica, courier, times)
+	}
+
+	for font, expected := range map[string]diagram.Length{
+		"":                helvetica,
+		"Arial":           helvetica,
+		"sans-serif":      helvetica,
+		"Unknown Family":  helvetica,
+		"monospace":       courier,
+		"Georgia":         times,
+		"serif":           times,
+		"Times New Roman": times,
+	} {
+		if got := width(font); got != expected {
+			t.Errorf("%q: expected width %v, got %v", font, expected, got)
+		}
+	}
+}
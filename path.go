@@ -0,0 +1,306 @@
+package diagram
+
+import "math"
+
+// Path describes a shape made of lines, curves and arcs.
+type Path struct {
+	Commands []PathCommand
+}
+
+// PathVerb is the type of a path command, they match SVG path commands.
+type PathVerb string
+
+const (
+	PathMoveTo  PathVerb = "M"
+	PathLineTo  PathVerb = "L"
+	PathQuadTo  PathVerb = "Q"
+	PathCubicTo PathVerb = "C"
+	PathArcTo   PathVerb = "A"
+	PathClose   PathVerb = "Z"
+)
+
+// PathCommand is a single segment of a Path.
+type PathCommand struct {
+	Verb PathVerb
+	// Points contains control points followed by the end point.
+	Points []Point `json:",omitempty"`
+	// Arc contains the parameters for PathArcTo.
+	Arc *Arc `json:",omitempty"`
+}
+
+// Arc describes an elliptical arc, same as the SVG arc command.
+type Arc struct {
+	Radius   Point
+	Rotation float64 // in radians
+	Large    bool
+	Sweep    bool
+}
+
+func NewPath() *Path { return &Path{} }
+
+func (path *Path) add(verb PathVerb, points ...Point) *Path {
+	path.Commands = append(path.Commands, PathCommand{Verb: verb, Points: points})
+	return path
+}
+
+func (path *Path) MoveTo(p Point) *Path          { return path.add(PathMoveTo, p) }
+func (path *Path) LineTo(p Point) *Path          { return path.add(PathLineTo, p) }
+func (path *Path) QuadTo(c, p Point) *Path       { return path.add(PathQuadTo, c, p) }
+func (path *Path) CubicTo(c1, c2, p Point) *Path { return path.add(PathCubicTo, c1, c2, p) }
+func (path *Path) Close() *Path                  { return path.add(PathClose) }
+
+// ArcTo adds an elliptical arc to p, the parameters match the SVG arc command.
+func (path *Path) ArcTo(radius Point, rotation float64, large, sweep bool, p Point) *Path {
+	path.Commands = append(path.Commands, PathCommand{
+		Verb:   PathArcTo,
+		Points: []Point{p},
+		Arc: &Arc{
+			Radius:   radius,
+			Rotation: rotation,
+			Large:    large,
+			Sweep:    sweep,
+		},
+	})
+	return path
+}
+
+func (path *Path) Empty() bool { return path == nil || len(path.Commands) == 0 }
+
+// clone returns a deep copy of path, so that canvases are not
+// affected by modifications after drawing.
+func (path *Path) clone() *Path {
+	if path == nil {
+		return nil
+	}
+	result := &Path{Commands: make([]PathCommand, len(path.Commands))}
+	for i, cmd := range path.Commands {
+		if cmd.Points != nil {
+			cmd.Points = append([]Point{}, cmd.Points...)
+		}
+		if cmd.Arc != nil {
+			arc := *cmd.Arc
+			cmd.Arc = &arc
+		}
+		result.Commands[i] = cmd
+	}
+	return result
+}
+
+// Polyline adds lines through points, starting a new subpath.
+func (path *Path) Polyline(points ...Point) *Path {
+	for i, p := range points {
+		if i == 0 {
+			path.MoveTo(p)
+		} else {
+			path.LineTo(p)
+		}
+	}
+	return path
+}
+
+// Bounds returns the bounding box of the path.
+func (path *Path) Bounds() Rect {
+	if path == nil {
+		return Rect{}
+	}
+	first := true
+	var bounds Rect
+	for _, line := range path.flatten(flattenTolerance) {
+		for _, p := range line.points {
+			if first {
+				bounds = Rect{p, p}
+				first = false
+			}
+			bounds.Min = bounds.Min.Min(p)
+			bounds.Max = bounds.Max.Max(p)
+		}
+	}
+	return bounds
+}
+
+// cubics returns an equivalent path that only uses
+// PathMoveTo, PathLineTo, PathCubicTo and PathClose.
+func (path *Path) cubics() *Path {
+	result := &Path{}
+
+	var start, current Point
+	for _, cmd := range path.Commands {
+		switch cmd.Verb {
+		case PathMoveTo:
+			start, current = cmd.Points[0], cmd.Points[0]
+			result.MoveTo(current)
+		case PathLineTo:
+			current = cmd.Points[0]
+			result.LineTo(current)
+		case PathQuadTo:
+			c, p := cmd.Points[0], cmd.Points[1]
+			result.CubicTo(
+				current.Add(c.Sub(current).Scale(2.0/3.0)),
+				p.Add(c.Sub(p).Scale(2.0/3.0)),
+				p)
+			current = p
+		case PathCubicTo:
+			current = cmd.Points[2]
+			result.CubicTo(cmd.Points[0], cmd.Points[1], cmd.Points[2])
+		case PathArcTo:
+			arcToCubics(result, current, *cmd.Arc, cmd.Points[0])
+			current = cmd.Points[0]
+		case PathClose:
+			current = start
+			result.Close()
+		}
+	}
+
+	return result
+}
+
+// pathLine is a flattened subpath.
+type pathLine struct {
+	points []Point
+	closed bool
+}
+
+// flatten converts path into polylines with the specified tolerance.
+func (path *Path) flatten(tolerance Length) []pathLine {
+	var lines []pathLine
+	var line pathLine
+
+	flush := func() {
+		if len(line.points) > 1 {
+			lines = append(lines, line)
+		}
+		line = pathLine{}
+	}
+
+	current := Point{}
+	for _, cmd := range path.cubics().Commands {
+		switch cmd.Verb {
+		case PathMoveTo:
+			flush()
+			current = cmd.Points[0]
+			line.points = append(line.points, current)
+		case PathLineTo:
+			if len(line.points) == 0 {
+				line.points = append(line.points, current)
+			}
+			current = cmd.Points[0]
+			line.points = append(line.points, current)
+		case PathCubicTo:
+			if len(line.points) == 0 {
+				line.points = append(line.points, current)
+			}
+			line.points = flattenCubic(line.points, current, cmd.Points[0], cmd.Points[1], cmd.Points[2], tolerance)
+			current = cmd.Points[2]
+		case PathClose:
+			if len(line.points) > 0 {
+				start := line.points[0]
+				if line.points[len(line.points)-1] != start {
+					line.points = append(line.points, start)
+				}
+				line.closed = true
+				current = start
+			}
+			flush()
+			line.points = append(line.points, current)
+		}
+	}
+	flush()
+
+	return lines
+}
+
+// flattenCubic appends line segments approximating the cubic curve to points.
+func flattenCubic(points []Point, p0, p1, p2, p3 Point, tolerance Length) []Point {
+	dd0 := p0.Sub(p1.Scale(2)).Add(p2)
+	dd1 := p1.Sub(p2.Scale(2)).Add(p3)
+	dd := math.Max(math.Hypot(dd0.X, dd0.Y), math.Hypot(dd1.X, dd1.Y))
+
+	n := int(math.Ceil(math.Sqrt(0.75 * dd / tolerance)))
+	if n < 1 {
+		n = 1
+	}
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+		points = append(points, Point{
+			X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+			Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+		})
+	}
+	return points
+}
+
+// arcToCubics appends cubic curves approximating the arc from p0 to p1.
+//
+// The conversion follows the SVG implementation notes
+// for the endpoint to center parameterization.
+func arcToCubics(path *Path, p0 Point, arc Arc, p1 Point) {
+	rx, ry := math.Abs(arc.Radius.X), math.Abs(arc.Radius.Y)
+	if p0 == p1 {
+		return
+	}
+	if rx == 0 || ry == 0 {
+		path.LineTo(p1)
+		return
+	}
+
+	sinphi, cosphi := math.Sincos(arc.Rotation)
+
+	dx, dy := (p0.X-p1.X)/2, (p0.Y-p1.Y)/2
+	x1 := cosphi*dx + sinphi*dy
+	y1 := -sinphi*dx + cosphi*dy
+
+	// scale up radii when they are too small
+	lambda := (x1*x1)/(rx*rx) + (y1*y1)/(ry*ry)
+	if lambda > 1 {
+		s := math.Sqrt(lambda)
+		rx, ry = rx*s, ry*s
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if arc.Large == arc.Sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+
+	cx := cosphi*cx1 - sinphi*cy1 + (p0.X+p1.X)/2
+	cy := sinphi*cx1 + cosphi*cy1 + (p0.Y+p1.Y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !arc.Sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if arc.Sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	segments := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(segments)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+
+	point := func(t float64) (p, d Point) {
+		sn, cs := math.Sincos(t)
+		ex, ey := rx*cs, ry*sn
+		dx, dy := -rx*sn, ry*cs
+		return Point{cx + cosphi*ex - sinphi*ey, cy + sinphi*ex + cosphi*ey},
+			Point{cosphi*dx - sinphi*dy, sinphi*dx + cosphi*dy}
+	}
+
+	for i := 0; i < segments; i++ {
+		t0 := theta + float64(i)*step
+		t1 := t0 + step
+		a, da := point(t0)
+		b, db := point(t1)
+		if i == segments-1 {
+			b = p1
+		}
+		path.CubicTo(a.Add(da.Scale(k)), b.Sub(db.Scale(k)), b)
+	}
+}
@@ -0,0 +1,195 @@
+package diagram_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+func distance(a, b diagram.Point) float64 { return math.Hypot(a.X-b.X, a.Y-b.Y) }
+
+func cubicAt(p0, p1, p2, p3 diagram.Point, t float64) diagram.Point {
+	mt := 1 - t
+	a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+	return diagram.Point{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+func checkRect(t *testing.T, got, expected diagram.Rect, tolerance float64) {
+	t.Helper()
+	if distance(got.Min, expected.Min) > tolerance || distance(got.Max, expected.Max) > tolerance {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestArcCubics(t *testing.T) {
+	center, radius := diagram.P(50, 30), 20.0
+	cubics := diagram.Circle(center, radius).Cubics()
+
+	var current diagram.Point
+	curves := 0
+	for _, cmd := range cubics.Commands {
+		switch cmd.Verb {
+		case diagram.PathMoveTo:
+			current = cmd.Points[0]
+		case diagram.PathCubicTo:
+			curves++
+			for i := 0; i <= 10; i++ {
+				p := cubicAt(current, cmd.Points[0], cmd.Points[1], cmd.Points[2], float64(i)/10)
+				if d := distance(p, center); math.Abs(d-radius) > radius*1e-3 {
+					t.Errorf("point %v is %v from center, expected %v", p, d, radius)
+				}
+			}
+			current = cmd.Points[2]
+		case diagram.PathClose:
+		default:
+			t.Errorf("unexpected %v", cmd.Verb)
+		}
+	}
+	if curves != 4 {
+		t.Errorf("expected 4 quarter curves, got %d", curves)
+	}
+}
+
+func TestArcRadiusScaling(t *testing.T) {
+	// radius too small to reach the end point results in a half circle
+	path := diagram.NewPath().MoveTo(diagram.P(0, 0)).ArcTo(diagram.P(1, 1), 0, false, true, diagram.P(10, 0))
+	checkRect(t, path.Bounds(), diagram.R(0, -5, 10, 0), 0.01)
+}
+
+func TestFlattenTolerance(t *testing.T) {
+	center, radius := diagram.P(0, 0), 100.0
+	for _, tolerance := range []float64{1, 0.1, 0.01} {
+		lines := diagram.Circle(center, radius).Flatten(tolerance)
+		if len(lines) != 1 {
+			t.Fatalf("expected a single line, got %d", len(lines))
+		}
+		points := lines[0]
+		if points[0] != points[len(points)-1] {
+			t.Errorf("closed path should end at the start")
+		}
+		for i := 1; i < len(points); i++ {
+			mid := points[i-1].Add(points[i]).Scale(0.5)
+			// cubic approximation error is well below the tolerances
+			if d := radius - distance(mid, center); d > tolerance+radius*3e-4 {
+				t.Errorf("tolerance %v: segment %v-%v deviates by %v", tolerance, points[i-1], points[i], d)
+			}
+		}
+		if len(points) > int(2*math.Pi*radius/math.Sqrt(tolerance)) {
+			t.Errorf("tolerance %v: too many points %d", tolerance, len(points))
+		}
+	}
+
+	if lines := diagram.NewPath().Polyline(diagram.Ps(0, 0, 10, 0, 10, 10)...).Flatten(1); len(lines) != 1 || len(lines[0]) != 3 {
+		t.Errorf("polyline should not be subdivided: %v", lines)
+	}
+}
+
+func TestPathBounds(t *testing.T) {
+	checkRect(t, diagram.Circle(diagram.P(10, 20), 5).Bounds(), diagram.R(5, 15, 15, 25), 0.01)
+	checkRect(t, diagram.Ellipse(diagram.P(10, 20), diagram.P(8, 4)).Bounds(), diagram.R(2, 16, 18, 24), 0.01)
+
+	// ellipse with radii 20 and 10 rotated by 45 degrees
+	rotation := math.Pi / 4
+	major := diagram.P(20*math.Cos(rotation), 20*math.Sin(rotation))
+	rotated := diagram.NewPath().
+		MoveTo(major).
+		ArcTo(diagram.P(20, 10), rotation, false, true, major.Neg()).
+		ArcTo(diagram.P(20, 10), rotation, false, true, major).
+		Close()
+	half := math.Sqrt((20*20 + 10*10) / 2.0)
+	checkRect(t, rotated.Bounds(), diagram.R(-half, -half, half, half), 0.05)
+
+	rect := diagram.R(10, 10, 40, 20)
+	for _, radius := range []diagram.Length{0, 3, 100} {
+		checkRect(t, diagram.RoundedRect(rect, radius).Bounds(), rect, 0.01)
+	}
+	if n := len(diagram.RoundedRect(rect, 0).Commands); n != 5 {
+		t.Errorf("expected a plain rectangle, got %d commands", n)
+	}
+
+	// radius is limited by half of the shorter side
+	clamped := diagram.RoundedRect(rect, 100)
+	if p := clamped.Commands[0].Points[0]; p != diagram.P(15, 10) {
+		t.Errorf("expected clamped radius, starting at %v", p)
+	}
+}
+
+func TestPathCopied(t *testing.T) {
+	for _, canvas := range []struct {
+		name   string
+		draw   func(*diagram.Path) diagram.Canvas
+		encode func(diagram.Canvas) []byte
+	}{
+		{"svg", func(p *diagram.Path) diagram.Canvas {
+			c := diagram.NewSVG(50, 50)
+			c.Path(p, &diagram.Style{Fill: red})
+			return c
+		}, func(c diagram.Canvas) []byte { return c.(*diagram.SVG).Bytes() }},
+		{"pdf", func(p *diagram.Path) diagram.Canvas {
+			c := diagram.NewPDF(50, 50)
+			c.Path(p, &diagram.Style{Fill: red})
+			return c
+		}, func(c diagram.Canvas) []byte { return c.(*diagram.PDF).Bytes() }},
+		{"image", func(p *diagram.Path) diagram.Canvas {
+			c := diagram.NewImage(50, 50)
+			c.Path(p, &diagram.Style{Fill: red})
+			return c
+		}, func(c diagram.Canvas) []byte { return c.(*diagram.Image).Bytes() }},
+	} {
+		path := diagram.Circle(diagram.P(25, 25), 10)
+		expected := canvas.encode(canvas.draw(path))
+
+		mutated := canvas.draw(path)
+		path.Commands[0].Points[0] = diagram.P(0, 0)
+		path.Commands[1].Arc.Radius = diagram.P(1, 1)
+		path.LineTo(diagram.P(50, 50))
+
+		if !bytes.Equal(canvas.encode(mutated), expected) {
+			t.Errorf("%v: modifying the path after drawing changed the output", canvas.name)
+		}
+	}
+
+	rec := diagram.NewRecorder(50, 50)
+	path := diagram.Circle(diagram.P(25, 25), 10)
+	rec.Path(path, &diagram.Style{Fill: red})
+	path.Commands[0].Points[0] = diagram.P(0, 0)
+	if p := rec.Ops[0].Path.Commands[0].Points[0]; p != diagram.P(35, 25) {
+		t.Errorf("recorder: modifying the path after drawing changed the op, got %v", p)
+	}
+}
+
+func TestNilPath(t *testing.T) {
+	var path *diagram.Path
+	if !path.Empty() || path.Bounds() != (diagram.Rect{}) {
+		t.Errorf("nil path should be empty")
+	}
+
+	svg := diagram.NewSVG(50, 50)
+	svg.Path(path, &diagram.Style{Fill: red})
+	if !bytes.Equal(svg.Bytes(), diagram.NewSVG(50, 50).Bytes()) {
+		t.Errorf("svg: nil path should not draw")
+	}
+
+	pdf := diagram.NewPDF(50, 50)
+	pdf.Path(path, &diagram.Style{Fill: red})
+	if !bytes.Equal(pdf.Bytes(), diagram.NewPDF(50, 50).Bytes()) {
+		t.Errorf("pdf: nil path should not draw")
+	}
+
+	img := diagram.NewImage(50, 50)
+	img.Path(path, &diagram.Style{Fill: red})
+	if !bytes.Equal(img.Bytes(), diagram.NewImage(50, 50).Bytes()) {
+		t.Errorf("image: nil path should not draw")
+	}
+
+	rec := diagram.NewRecorder(50, 50)
+	rec.Path(path, &diagram.Style{Fill: red})
+	if len(rec.Ops) != 0 {
+		t.Errorf("recorder: nil path should not be recorded, got %v", rec.Ops)
+	}
+}
@@ -0,0 +1,572 @@
+package diagram
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// PDF is a Canvas that writes a single page PDF 1.4 document.
+//
+// Text is rendered using the standard 14 fonts, Style.Font is
+// mapped to Helvetica, Times or Courier.
+type PDF struct {
+	pdfContext
+}
+
+type pdfContext struct {
+	index int
+	clip  bool
+	// transform relative to parent
+	transform Affine
+	// bounds in local coordinates
+	bounds   Rect
+	elements []pdfElement
+	layers   []*pdfContext
+}
+
+type pdfElement struct {
+	// style
+	style Style
+	// line
+	points []Point
+	// path
+	path *Path
+	// text
+	text   string
+	origin Point
+	// context
+	context *pdfContext
+}
+
+func NewPDF(width, height Length) *PDF {
+	pdf := &PDF{}
+	pdf.transform = Identity()
+	pdf.bounds.Max.X = width
+	pdf.bounds.Max.Y = height
+	return pdf
+}
+
+func (pdf *PDF) SetSize(width, height Length) {
+	pdf.bounds.Max.X = width
+	pdf.bounds.Max.Y = height
+}
+
+func (pdf *PDF) Bytes() []byte {
+	var buffer bytes.Buffer
+	pdf.WriteTo(&buffer)
+	return buffer.Bytes()
+}
+
+func (pdf *pdfContext) Bounds() Rect { return pdf.bounds }
+func (pdf *pdfContext) Size() Point  { return pdf.bounds.Size() }
+
+func (pdf *pdfContext) context(transform Affine, bounds Rect, clip bool) Canvas {
+	element := pdfElement{}
+	element.context = &pdfContext{}
+	element.context.clip = clip
+	element.context.transform = transform
+	element.context.bounds = bounds
+	pdf.elements = append(pdf.elements, element)
+	return element.context
+}
+
+func (pdf *pdfContext) Context(r Rect) Canvas { return pdf.context(Translate(r.Min), r.Zero(), false) }
+func (pdf *pdfContext) Clip(r Rect) Canvas    { return pdf.context(Translate(r.Min), r.Zero(), true) }
+
+func (pdf *pdfContext) Transform(m Affine) Canvas {
+	m = m.orIdentity()
+	inv, _ := m.Invert()
+	return pdf.context(m, inv.ApplyRect(pdf.bounds), false)
+}
+
+func (pdf *pdfContext) Layer(index int) Canvas {
+	if index == 0 {
+		return pdf
+	}
+
+	i := sort.Search(len(pdf.layers), func(i int) bool {
+		return pdf.layers[i].index > index
+	})
+	if i > 0 && pdf.layers[i-1].index == index {
+		return pdf.layers[i-1]
+	}
+
+	layer := &pdfContext{}
+	layer.index = index
+	layer.transform = Identity()
+	layer.bounds = pdf.bounds
+
+	pdf.layers = append(pdf.layers, layer)
+	copy(pdf.layers[i+1:], pdf.layers[i:])
+	pdf.layers[i] = layer
+	return layer
+}
+
+func (pdf *pdfContext) Text(text string, at Point, style *Style) {
+	style.mustExist()
+	pdf.elements = append(pdf.elements, pdfElement{
+		text:   text,
+		origin: at,
+		style:  *style,
+	})
+}
+
+func (pdf *pdfContext) Poly(points []Point, style *Style) {
+	style.mustExist()
+	pdf.elements = append(pdf.elements, pdfElement{
+		points: points,
+		style:  *style,
+	})
+}
+
+func (pdf *pdfContext) Rect(r Rect, style *Style) {
+	pdf.Poly(r.Points(), style)
+}
+
+func (pdf *pdfContext) Path(path *Path, style *Style) {
+	style.mustExist()
+	if path == nil {
+		return
+	}
+	pdf.elements = append(pdf.elements, pdfElement{
+		path:  path.clone(),
+		style: *style,
+	})
+}
+
+func (pdf *PDF) WriteTo(dst io.Writer) (n int64, err error) {
+	content := &pdfContent{
+		fonts:  map[string]string{},
+		alphas: map[string]string{},
+	}
+
+	size := pdf.bounds.Size()
+	// flip the coordinate system to have y pointing down
+	content.Printf("1 0 0 -1 0 %v cm\n", pdfNumber(size.Y))
+	content.writeLayer(&pdf.pdfContext)
+
+	var stream bytes.Buffer
+	compress := zlib.NewWriter(&stream)
+	compress.Write(content.Bytes())
+	compress.Close()
+
+	w := &pdfWriter{}
+	w.Printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	w.Object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	w.Object(2, "<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+
+	resources := &bytes.Buffer{}
+	resources.WriteString("<< /ProcSet [/PDF /Text]")
+	id := 5
+	var fonts, alphas []string
+	if len(content.fonts) > 0 {
+		resources.WriteString(" /Font <<")
+		fonts = sortedKeys(content.fonts)
+		for _, name := range fonts {
+			fmt.Fprintf(resources, " /%v %v 0 R", content.fonts[name], id)
+			id++
+		}
+		resources.WriteString(" >>")
+	}
+	if len(content.alphas) > 0 {
+		resources.WriteString(" /ExtGState <<")
+		alphas = sortedKeys(content.alphas)
+		for _, alpha := range alphas {
+			fmt.Fprintf(resources, " /%v %v 0 R", content.alphas[alpha], id)
+			id++
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	w.Object(3, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %v %v] /Resources %v /Contents 4 0 R >>",
+		pdfNumber(size.X), pdfNumber(size.Y), resources.String()))
+	w.Stream(4, "/Filter /FlateDecode", stream.Bytes())
+
+	id = 5
+	for _, name := range fonts {
+		w.Object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%v /Encoding /WinAnsiEncoding >>", name))
+		id++
+	}
+	for _, alpha := range alphas {
+		w.Object(id, fmt.Sprintf("<< /Type /ExtGState %v >>", alpha))
+		id++
+	}
+
+	w.Trailer(id)
+
+	written, err := dst.Write(w.Bytes())
+	return int64(written), err
+}
+
+type pdfContent struct {
+	bytes.Buffer
+	// fonts maps base font name to resource name
+	fonts map[string]string
+	// alphas maps graphics state parameters to resource name
+	alphas map[string]string
+}
+
+func (w *pdfContent) Printf(format string, args ...interface{}) { fmt.Fprintf(w, format, args...) }
+
+func (w *pdfContent) writeLayer(pdf *pdfContext) {
+	w.Printf("q\n")
+	defer w.Printf("Q\n")
+
+	if m := pdf.transform.orIdentity(); !m.IsIdentity() {
+		w.Printf("%v %v %v %v %v %v cm\n",
+			pdfNumber(m.A), pdfNumber(m.B), pdfNumber(m.C),
+			pdfNumber(m.D), pdfNumber(m.E), pdfNumber(m.F))
+	}
+	if pdf.clip {
+		size := pdf.bounds.Size()
+		w.Printf("%v %v %v %v re W n\n",
+			pdfNumber(pdf.bounds.Min.X), pdfNumber(pdf.bounds.Min.Y),
+			pdfNumber(size.X), pdfNumber(size.Y))
+	}
+
+	after := len(pdf.layers)
+	for i, layer := range pdf.layers {
+		if layer.index >= 0 {
+			after = i
+			break
+		}
+		w.writeLayer(layer)
+	}
+
+	for i := range pdf.elements {
+		w.writeElement(&pdf.elements[i])
+	}
+
+	for _, layer := range pdf.layers[after:] {
+		w.writeLayer(layer)
+	}
+}
+
+func (w *pdfContent) writeElement(el *pdfElement) {
+	if len(el.points) > 0 {
+		path := NewPath().Polyline(el.points...)
+		if len(el.points) > 2 && el.points[0] == el.points[len(el.points)-1] {
+			path.Close()
+		}
+		w.writePath(path, &el.style)
+	}
+	if !el.path.Empty() {
+		w.writePath(el.path, &el.style)
+	}
+	if el.text != "" {
+		w.writeText(el.text, el.origin, &el.style)
+	}
+	if el.context != nil {
+		w.writeLayer(el.context)
+	}
+}
+
+func (w *pdfContent) writePath(path *Path, style *Style) {
+	if style.Stroke == nil && style.Fill == nil {
+		return
+	}
+
+	w.Printf("q\n")
+	defer w.Printf("Q\n")
+
+	w.writeColors(style.Stroke, style.Fill)
+	if style.Stroke != nil {
+		width := style.Size
+		if width == 0 {
+			width = 1
+		}
+		w.Printf("%v w\n", pdfNumber(width))
+		if len(style.Dash) > 0 {
+			w.Printf("[")
+			for i, v := range style.Dash {
+				if i > 0 {
+					w.Printf(" ")
+				}
+				w.Printf("%v", pdfNumber(v))
+			}
+			offset := Length(0)
+			if len(style.DashOffset) > 0 {
+				offset = style.DashOffset[0]
+			}
+			w.Printf("] %v d\n", pdfNumber(offset))
+		}
+	}
+
+	for _, cmd := range path.cubics().Commands {
+		for _, p := range cmd.Points {
+			w.Printf("%v %v ", pdfNumber(p.X), pdfNumber(p.Y))
+		}
+		switch cmd.Verb {
+		case PathMoveTo:
+			w.Printf("m\n")
+		case PathLineTo:
+			w.Printf("l\n")
+		case PathCubicTo:
+			w.Printf("c\n")
+		case PathClose:
+			w.Printf("h\n")
+		}
+	}
+
+	switch {
+	case style.Stroke != nil && style.Fill != nil:
+		w.Printf("B\n")
+	case style.Stroke != nil:
+		w.Printf("S\n")
+	default:
+		w.Printf("f\n")
+	}
+
+	if style.Stroke != nil && (style.StartMarker != MarkerNone || style.EndMarker != MarkerNone) {
+		w.writeMarkers(path, style)
+	}
+}
+
+func (w *pdfContent) writeMarkers(path *Path, style *Style) {
+	var lines [][]Point
+	for _, line := range path.flatten(flattenTolerance) {
+		lines = append(lines, line.points)
+	}
+	start, startAngle, end, endAngle, ok := lineEnds(lines)
+	if !ok {
+		return
+	}
+
+	width := style.Size
+	if width == 0 {
+		width = 1
+	}
+
+	write := func(marker Marker, tip Point, angle float64) {
+		lines, fills := marker.place(tip, angle, width)
+		for _, line := range lines {
+			w.writePolyline(line, false)
+			w.Printf("S\n")
+		}
+		if len(fills) > 0 {
+			// use stroke color for filling, including the alpha
+			// which replaces the alpha of the path fill
+			r, g, b, a := pdfColor(style.Stroke)
+			w.Printf("%v %v %v rg\n", pdfNumber(r), pdfNumber(g), pdfNumber(b))
+			translucent := a < 1
+			if style.Fill != nil {
+				_, _, _, fillAlpha := pdfColor(style.Fill)
+				translucent = translucent || fillAlpha < 1
+			}
+			if translucent {
+				w.writeState(fmt.Sprintf("/ca %v", pdfNumber(a)))
+			}
+			for _, fill := range fills {
+				w.writePolyline(fill, true)
+			}
+			w.Printf("f\n")
+		}
+	}
+
+	w.Printf("[] 0 d\n")
+	write(style.StartMarker, start, startAngle)
+	write(style.EndMarker, end, endAngle)
+}
+
+func (w *pdfContent) writePolyline(points []Point, closed bool) {
+	for i, p := range points {
+		if i == 0 {
+			w.Printf("%v %v m\n", pdfNumber(p.X), pdfNumber(p.Y))
+		} else {
+			w.Printf("%v %v l\n", pdfNumber(p.X), pdfNumber(p.Y))
+		}
+	}
+	if closed {
+		w.Printf("h\n")
+	}
+}
+
+func (w *pdfContent) writeText(text string, at Point, style *Style) {
+	fill := style.Fill
+	if fill == nil {
+		fill = style.Stroke
+	}
+	if fill == nil {
+		fill = color.Black
+	}
+
+	name := pdfFont(style.Font)
+	resource, ok := w.fonts[name]
+	if !ok {
+		resource = fmt.Sprintf("F%v", len(w.fonts)+1)
+		w.fonts[name] = resource
+	}
+
+	layout := layoutText(text, style)
+	sn, cs := math.Sincos(style.Rotation)
+
+	w.Printf("q\n")
+	defer w.Printf("Q\n")
+
+	w.writeColors(nil, fill)
+	for _, line := range layout.lines {
+		x := at.X + line.offset.X*cs - line.offset.Y*sn
+		y := at.Y + line.offset.X*sn + line.offset.Y*cs
+
+		w.Printf("BT /%v %v Tf %v %v %v %v %v %v Tm (",
+			resource, pdfNumber(layout.size),
+			pdfNumber(cs), pdfNumber(sn), pdfNumber(sn), pdfNumber(-cs),
+			pdfNumber(x), pdfNumber(y))
+		w.Write(pdfString(line.text))
+		w.Printf(") Tj ET\n")
+	}
+}
+
+func (w *pdfContent) writeColors(stroke, fill color.Color) {
+	var state string
+	if stroke != nil {
+		r, g, b, a := pdfColor(stroke)
+		w.Printf("%v %v %v RG\n", pdfNumber(r), pdfNumber(g), pdfNumber(b))
+		if a < 1 {
+			state += fmt.Sprintf(" /CA %v", pdfNumber(a))
+		}
+	}
+	if fill != nil {
+		r, g, b, a := pdfColor(fill)
+		w.Printf("%v %v %v rg\n", pdfNumber(r), pdfNumber(g), pdfNumber(b))
+		if a < 1 {
+			state += fmt.Sprintf(" /ca %v", pdfNumber(a))
+		}
+	}
+
+	if state == "" {
+		return
+	}
+	w.writeState(strings.TrimSpace(state))
+}
+
+// writeState sets the graphics state parameters.
+func (w *pdfContent) writeState(state string) {
+	resource, ok := w.alphas[state]
+	if !ok {
+		resource = fmt.Sprintf("GS%v", len(w.alphas)+1)
+		w.alphas[state] = resource
+	}
+	w.Printf("/%v gs\n", resource)
+}
+
+// pdfFont returns the standard font name for a font family.
+func pdfFont(family string) string {
+	metrics := lookupFont(family)
+
+	lower := strings.ToLower(family)
+	bold := strings.Contains(lower, "bold")
+	italic := strings.Contains(lower, "italic") || strings.Contains(lower, "oblique")
+
+	base := strings.TrimSuffix(metrics.name, "-Roman")
+	slant := "Oblique"
+	if metrics == &timesMetrics {
+		slant = "Italic"
+	}
+
+	switch {
+	case bold && italic:
+		return base + "-Bold" + slant
+	case bold:
+		return base + "-Bold"
+	case italic:
+		return base + "-" + slant
+	default:
+		return metrics.name
+	}
+}
+
+// pdfColor converts color to non-premultiplied components in 0..1.
+func pdfColor(c color.Color) (r, g, b, a float64) {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return float64(nrgba.R) / 0xFF, float64(nrgba.G) / 0xFF, float64(nrgba.B) / 0xFF, float64(nrgba.A) / 0xFF
+}
+
+func pdfNumber(v float64) string {
+	s := fmt.Sprintf("%.3f", v)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// pdfString escapes text as a WinAnsi encoded literal string.
+func pdfString(text string) []byte {
+	var data []byte
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			data = append(data, '\\', byte(r))
+		case r == '\n':
+			data = append(data, '\\', 'n')
+		case r == '\r':
+			data = append(data, '\\', 'r')
+		case r == '\t':
+			data = append(data, '\\', 't')
+		case r < 0x20 || r > 0xFF || (r >= 0x7F && r < 0xA0):
+			data = append(data, '?')
+		default:
+			data = append(data, byte(r))
+		}
+	}
+	return data
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, k int) bool {
+		return m[keys[i]] < m[keys[k]]
+	})
+	return keys
+}
+
+// pdfWriter writes objects and keeps track of their offsets.
+type pdfWriter struct {
+	bytes.Buffer
+	offsets []int
+}
+
+func (w *pdfWriter) Printf(format string, args ...interface{}) { fmt.Fprintf(w, format, args...) }
+
+func (w *pdfWriter) begin(id int) {
+	for len(w.offsets) <= id {
+		w.offsets = append(w.offsets, 0)
+	}
+	w.offsets[id] = w.Len()
+	w.Printf("%v 0 obj\n", id)
+}
+
+func (w *pdfWriter) Object(id int, value string) {
+	w.begin(id)
+	w.Printf("%v\nendobj\n", value)
+}
+
+func (w *pdfWriter) Stream(id int, dict string, data []byte) {
+	w.begin(id)
+	w.Printf("<< /Length %v %v >>\nstream\n", len(data), dict)
+	w.Write(data)
+	w.Printf("\nendstream\nendobj\n")
+}
+
+func (w *pdfWriter) Trailer(count int) {
+	xref := w.Len()
+	w.Printf("xref\n0 %v\n", count)
+	w.Printf("0000000000 65535 f \n")
+	for id := 1; id < count; id++ {
+		w.Printf("%010d 00000 n \n", w.offsets[id])
+	}
+	w.Printf("trailer\n<< /Size %v /Root 1 0 R >>\nstartxref\n%v\n%%%%EOF\n", count, xref)
+}
@@ -0,0 +1,181 @@
+package diagram_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+// pdfObjects checks the header and cross-reference table of data
+// and returns the objects by id.
+func pdfObjects(t *testing.T, data []byte) map[int][]byte {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("invalid header %q", data[:10])
+	}
+	if !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("missing %%%%EOF")
+	}
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if match == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point to xref", xref)
+	}
+
+	var first, count int
+	table := data[xref+len("xref\n"):]
+	if _, err := fmt.Sscanf(string(table), "%d %d\n", &first, &count); err != nil || first != 0 {
+		t.Fatalf("invalid xref header: %v", err)
+	}
+	table = table[bytes.IndexByte(table, '\n')+1:]
+
+	objects := map[int][]byte{}
+	for id := 0; id < count; id++ {
+		entry := table[id*20 : id*20+20]
+		if id == 0 {
+			if string(entry) != "0000000000 65535 f \n" {
+				t.Fatalf("invalid free entry %q", entry)
+			}
+			continue
+		}
+		offset, err := strconv.Atoi(string(entry[:10]))
+		if err != nil {
+			t.Fatalf("invalid xref entry %q", entry)
+		}
+		header := fmt.Sprintf("%d 0 obj\n", id)
+		if !bytes.HasPrefix(data[offset:], []byte(header)) {
+			t.Fatalf("xref offset %d of object %d points to %q", offset, id, data[offset:offset+10])
+		}
+		body := data[offset+len(header):]
+		objects[id] = body[:bytes.Index(body, []byte("endobj\n"))]
+	}
+	if !bytes.Contains(data, []byte(fmt.Sprintf("/Size %d /Root 1 0 R", count))) {
+		t.Errorf("invalid trailer")
+	}
+	return objects
+}
+
+// pdfStream decodes a flate compressed stream object.
+func pdfStream(t *testing.T, object []byte) []byte {
+	t.Helper()
+	match := regexp.MustCompile(`^<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindSubmatch(object)
+	if match == nil {
+		t.Fatalf("invalid stream %q", object)
+	}
+	length, _ := strconv.Atoi(string(match[1]))
+	data := object[len(match[0]):]
+	if !bytes.HasPrefix(data[length:], []byte("\nendstream\n")) {
+		t.Fatalf("stream length %d does not match", length)
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(data[:length]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestPDF(t *testing.T) {
+	pdf := diagram.NewPDF(100, 50)
+	pdf.Rect(diagram.R(10, 10, 20, 20), &diagram.Style{Fill: color.NRGBA{R: 0xFF, A: 0x33}})
+	pdf.Poly(diagram.Ps(0, 0, 100, 50), &diagram.Style{Stroke: color.Black, Size: 1})
+	pdf.Text("hello", diagram.P(50, 25), &diagram.Style{Fill: color.Black, Size: 12})
+	pdf.Text("bold", diagram.P(50, 25), &diagram.Style{Fill: color.Black, Size: 12, Font: "Times Bold"})
+
+	objects := pdfObjects(t, pdf.Bytes())
+	if len(objects) != 7 {
+		t.Fatalf("expected 7 objects, got %d", len(objects))
+	}
+	if !bytes.Contains(objects[3], []byte("/MediaBox [0 0 100 50]")) {
+		t.Errorf("invalid page %q", objects[3])
+	}
+
+	content := string(pdfStream(t, objects[4]))
+	for _, expect := range []string{
+		"1 0 0 -1 0 50 cm\n",
+		"1 0 0 rg\n/GS1 gs\n",
+		"(hello) Tj",
+		"(bold) Tj",
+	} {
+		if !strings.Contains(content, expect) {
+			t.Errorf("content does not contain %q:\n%s", expect, content)
+		}
+	}
+
+	// resources refer to the font and graphics state objects
+	resources := regexp.MustCompile(`/(F\d+|GS\d+) (\d+) 0 R`).FindAllSubmatch(objects[3], -1)
+	found := map[string]string{}
+	for _, resource := range resources {
+		id, _ := strconv.Atoi(string(resource[2]))
+		found[string(resource[1])] = string(objects[id])
+	}
+	fonts := 0
+	for name, object := range found {
+		switch name[0] {
+		case 'F':
+			fonts++
+			if !regexp.MustCompile(`/Type /Font /Subtype /Type1 /BaseFont /(Helvetica|Times-Bold) `).MatchString(object) {
+				t.Errorf("invalid font %v: %q", name, object)
+			}
+		case 'G':
+			if object != "<< /Type /ExtGState /ca 0.2 >>\n" {
+				t.Errorf("invalid graphics state %v: %q", name, object)
+			}
+		}
+	}
+	if fonts != 2 || found["GS1"] == "" {
+		t.Errorf("invalid resources %q", objects[3])
+	}
+}
+
+func TestPDFUnknownFont(t *testing.T) {
+	pdf := diagram.NewPDF(100, 50)
+	pdf.Text("hello", diagram.P(50, 25), &diagram.Style{Fill: color.Black, Size: 12, Font: "Unknown Family"})
+
+	objects := pdfObjects(t, pdf.Bytes())
+	if font := string(objects[5]); !strings.Contains(font, "/BaseFont /Helvetica ") {
+		t.Errorf("unknown family should use Helvetica, got %q", font)
+	}
+}
+
+func TestPDFMarkerAlpha(t *testing.T) {
+	pdf := diagram.NewPDF(100, 50)
+	pdf.Poly(diagram.Ps(10, 25, 90, 25), &diagram.Style{
+		Stroke:    color.NRGBA{R: 0xFF, A: 0x33},
+		Size:      2,
+		EndMarker: diagram.MarkerTriangle,
+	})
+
+	objects := pdfObjects(t, pdf.Bytes())
+	content := string(pdfStream(t, objects[4]))
+
+	// the triangle is filled with the stroke color and alpha
+	match := regexp.MustCompile(`1 0 0 rg\n/(GS\d+) gs\n`).FindStringSubmatch(content)
+	if match == nil {
+		t.Fatalf("marker fill should set the alpha:\n%s", content)
+	}
+	resource := regexp.MustCompile(`/` + match[1] + ` (\d+) 0 R`).FindStringSubmatch(string(objects[3]))
+	if resource == nil {
+		t.Fatalf("missing resource %v in %q", match[1], objects[3])
+	}
+	id, _ := strconv.Atoi(resource[1])
+	if state := string(objects[id]); state != "<< /Type /ExtGState /ca 0.2 >>\n" {
+		t.Errorf("invalid marker graphics state %q", state)
+	}
+}
@@ -0,0 +1,252 @@
+package plot
+
+import (
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// BarSeries is a named list of values, one for each category.
+type BarSeries struct {
+	Name   string
+	Values []float64
+	// Style overrides the bar style, the default uses palette colors.
+	Style diagram.Style
+}
+
+// BarChart draws values of categories as bars.
+//
+// Multiple series are drawn side by side, or on top of each other
+// when Stacked is set. Negative values extend bars below zero.
+type BarChart struct {
+	Title      string
+	Categories []string
+	Series     []*BarSeries
+
+	// Stacked draws series on top of each other.
+	Stacked bool
+	// Horizontal draws bars from left to right.
+	Horizontal bool
+	// ValueLabels draws the value of each bar.
+	ValueLabels bool
+	// Format formats value labels, defaults to FormatValue.
+	Format func(float64) string
+
+	// Category configures the category axis, only Title is used.
+	Category Axis
+	// Value configures the value axis, logarithmic scale is not supported.
+	Value Axis
+
+	Theme Theme
+	// BandPadding is the space between categories as a fraction of step.
+	BandPadding float64
+	// BarPadding is the space between grouped bars as a fraction of bar width.
+	BarPadding float64
+}
+
+// NewBarChart creates a bar chart with the default theme.
+func NewBarChart(categories ...string) *BarChart {
+	chart := &BarChart{}
+	chart.Categories = categories
+	chart.Theme = DefaultTheme()
+	chart.BandPadding = 0.2
+	chart.BarPadding = 0.1
+	return chart
+}
+
+// Add adds a new series to the chart.
+func (chart *BarChart) Add(name string, values []float64) *BarSeries {
+	series := &BarSeries{Name: name, Values: values}
+	chart.Series = append(chart.Series, series)
+	return series
+}
+
+// style returns the bar style of the i-th series.
+func (chart *BarChart) style(i int) *diagram.Style {
+	return chart.Series[i].Style.Or(diagram.Style{
+		Fill: chart.Theme.Color(i),
+	})
+}
+
+// value returns the value of series at category index,
+// missing and invalid values are treated as zero.
+func (series *BarSeries) value(category int) float64 {
+	if category >= len(series.Values) {
+		return 0
+	}
+	v := series.Values[category]
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// bar is a single bar from value start to end.
+type bar struct {
+	series, category int
+	start, end       float64
+}
+
+// bars computes the value range of all bars.
+func (chart *BarChart) bars() []bar {
+	var bars []bar
+	for category := range chart.Categories {
+		positive, negative := 0.0, 0.0
+		for i, series := range chart.Series {
+			v := series.value(category)
+			b := bar{series: i, category: category, start: 0, end: v}
+			if chart.Stacked {
+				if v >= 0 {
+					b.start, b.end = positive, positive+v
+					positive += v
+				} else {
+					b.start, b.end = negative, negative+v
+					negative += v
+				}
+			}
+			bars = append(bars, b)
+		}
+	}
+	return bars
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *BarChart) Draw(canvas diagram.Canvas) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	var entries []legendEntry
+	for i, series := range chart.Series {
+		if series.Name != "" {
+			entries = append(entries, legendEntry{name: series.Name, style: *chart.style(i), box: true})
+		}
+	}
+	bounds = chart.Theme.legend(canvas, bounds, entries)
+
+	bars := chart.bars()
+	values := newExtent()
+	values.add(0, false)
+	for _, b := range bars {
+		values.add(b.end, false)
+	}
+
+	valueAxis := chart.Value
+	valueAxis.Log = false
+
+	var categories *scale.Band
+	var value scale.Scale
+	area, axes := layoutAxes(bounds, func(area diagram.Rect) []*axis.Axis {
+		var categoryAxis, valuePosition axis.Position
+		var count int
+		if chart.Horizontal {
+			count = chart.Theme.tickCount(area.Size().X)
+			categories = scale.NewBand(chart.Categories, area.Min.Y, area.Max.Y)
+			value = valueAxis.scale(values.min, values.max, area.Min.X, area.Max.X, count)
+			categoryAxis, valuePosition = axis.Left, axis.Bottom
+		} else {
+			count = chart.Theme.tickCount(area.Size().Y)
+			categories = scale.NewBand(chart.Categories, area.Min.X, area.Max.X)
+			value = valueAxis.scale(values.min, values.max, area.Max.Y, area.Min.Y, count)
+			categoryAxis, valuePosition = axis.Bottom, axis.Left
+		}
+		categories.PaddingInner = chart.BandPadding
+		categories.PaddingOuter = chart.BandPadding / 2
+
+		category := chart.Category.axis(categoryAxis, categories, 0)
+		category.Theme.Grid = diagram.Style{}
+		category.Theme.TickLength = 0
+		return []*axis.Axis{category, valueAxis.axis(valuePosition, value, count)}
+	})
+	for _, a := range axes {
+		a.Draw(canvas, area)
+	}
+
+	// rect returns the rectangle for a bar along the category axis
+	// from low to high and along the value axis from start to end.
+	rect := func(low, high diagram.Length, start, end float64) diagram.Rect {
+		p0, p1 := value.Position(start), value.Position(end)
+		if chart.Horizontal {
+			return diagram.R(math.Min(p0, p1), low, math.Max(p0, p1), high)
+		}
+		return diagram.R(low, math.Min(p0, p1), high, math.Max(p0, p1))
+	}
+
+	plot := clip(canvas, area)
+	labels := canvas.Layer(1)
+	format := chart.Format
+	if format == nil {
+		format = FormatValue
+	}
+
+	for _, b := range bars {
+		low := categories.MapIndex(b.category)
+		width := categories.Bandwidth()
+		if !chart.Stacked && len(chart.Series) > 1 {
+			group := scale.NewBand(make([]string, len(chart.Series)), low, low+width)
+			group.PaddingInner = chart.BarPadding
+			low, width = group.MapIndex(b.series), group.Bandwidth()
+		}
+
+		series := chart.Series[b.series]
+		style := *chart.style(b.series)
+		if style.Hint == "" {
+			style.Hint = chart.Categories[b.category]
+			if series.Name != "" {
+				style.Hint += " " + series.Name
+			}
+			style.Hint += ": " + format(series.value(b.category))
+		}
+
+		r := rect(low, low+width, b.start, b.end)
+		plot.Rect(r, &style)
+
+		if chart.ValueLabels {
+			chart.drawLabel(labels, r, b, format(series.value(b.category)))
+		}
+	}
+
+	// zero line separates positive and negative values
+	if values.min < 0 {
+		zero := value.Position(0)
+		line := diagram.Ps(area.Min.X, zero, area.Max.X, zero)
+		if chart.Horizontal {
+			line = diagram.Ps(zero, area.Min.Y, zero, area.Max.Y)
+		}
+		plot.Poly(line, &axes[1].Theme.Line)
+	}
+}
+
+// drawLabel draws value label for bar rectangle r.
+func (chart *BarChart) drawLabel(canvas diagram.Canvas, r diagram.Rect, b bar, label string) {
+	style := chart.Theme.Label
+	center := r.UnitLocation(diagram.Point{})
+	negative := b.end < b.start
+
+	if chart.Stacked {
+		// stacked labels are placed inside the segment
+		style.Origin = diagram.Point{}
+		size := diagram.DefaultMeasurer.Measure(label, &style).Size()
+		if size.X > r.Size().X || size.Y > r.Size().Y {
+			return
+		}
+		canvas.Text(label, center, &style)
+		return
+	}
+
+	const gap = 3
+	switch {
+	case chart.Horizontal && !negative:
+		style.Origin = diagram.Point{X: -1, Y: 0}
+		canvas.Text(label, diagram.P(r.Max.X+gap, center.Y), &style)
+	case chart.Horizontal && negative:
+		style.Origin = diagram.Point{X: 1, Y: 0}
+		canvas.Text(label, diagram.P(r.Min.X-gap, center.Y), &style)
+	case !negative:
+		style.Origin = diagram.Point{X: 0, Y: 1}
+		canvas.Text(label, diagram.P(center.X, r.Min.Y-gap), &style)
+	default:
+		style.Origin = diagram.Point{X: 0, Y: -1}
+		canvas.Text(label, diagram.P(center.X, r.Max.Y+gap), &style)
+	}
+}
@@ -0,0 +1,323 @@
+package plot
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// Summary describes the distribution of a sample.
+type Summary struct {
+	Count int
+	Mean  float64
+
+	// Q1, Median and Q3 are the quartiles.
+	Q1, Median, Q3 float64
+	// Low and High are the smallest and largest values
+	// within 1.5 IQR of the quartiles.
+	Low, High float64
+	// Outliers are the values outside of [Low, High].
+	Outliers []float64
+}
+
+// Summarize computes the summary statistics of values,
+// NaN and infinite values are ignored.
+func Summarize(values []float64) Summary {
+	sorted := finite(values)
+	summary := Summary{Count: len(sorted)}
+	if len(sorted) == 0 {
+		nan := math.NaN()
+		summary.Mean = nan
+		summary.Q1, summary.Median, summary.Q3 = nan, nan, nan
+		summary.Low, summary.High = nan, nan
+		return summary
+	}
+
+	for _, v := range sorted {
+		summary.Mean += v
+	}
+	summary.Mean /= float64(len(sorted))
+
+	summary.Q1 = Quantile(sorted, 0.25)
+	summary.Median = Quantile(sorted, 0.5)
+	summary.Q3 = Quantile(sorted, 0.75)
+
+	iqr := summary.Q3 - summary.Q1
+	lowFence, highFence := summary.Q1-1.5*iqr, summary.Q3+1.5*iqr
+	summary.Low, summary.High = summary.Q1, summary.Q3
+	for _, v := range sorted {
+		if v < lowFence || v > highFence {
+			summary.Outliers = append(summary.Outliers, v)
+			continue
+		}
+		summary.Low = math.Min(summary.Low, v)
+		summary.High = math.Max(summary.High, v)
+	}
+	return summary
+}
+
+// String formats the summary for tooltips.
+func (summary Summary) String() string {
+	return fmt.Sprintf("n=%d mean=%s\nlow=%s q1=%s median=%s q3=%s high=%s\noutliers=%d",
+		summary.Count, FormatValue(summary.Mean),
+		FormatValue(summary.Low), FormatValue(summary.Q1), FormatValue(summary.Median),
+		FormatValue(summary.Q3), FormatValue(summary.High),
+		len(summary.Outliers))
+}
+
+// Sample is a named set of values.
+type Sample struct {
+	Name   string
+	Values []float64
+	// Style overrides the style, the default uses palette colors.
+	Style diagram.Style
+}
+
+// distribution contains the common parts of box and violin plots.
+type distribution struct {
+	Title   string
+	Samples []*Sample
+
+	// Horizontal draws the value axis from left to right.
+	Horizontal bool
+
+	// Category configures the category axis, only Title is used.
+	Category Axis
+	// Value configures the value axis, non-positive values
+	// are ignored on logarithmic axes.
+	Value Axis
+
+	Theme Theme
+	// Width is the width of a shape as a fraction of the category band.
+	Width float64
+}
+
+// Add adds a new sample.
+func (chart *distribution) Add(name string, values []float64) *Sample {
+	sample := &Sample{Name: name, Values: values}
+	chart.Samples = append(chart.Samples, sample)
+	return sample
+}
+
+// values returns the sorted values of the i-th sample,
+// which can be drawn on the value axis.
+func (chart *distribution) values(i int) []float64 {
+	sorted := finite(chart.Samples[i].Values)
+	if !chart.Value.Log {
+		return sorted
+	}
+	positive := sorted[:0]
+	for _, v := range sorted {
+		if v > 0 {
+			positive = append(positive, v)
+		}
+	}
+	return positive
+}
+
+// style returns the style of the i-th sample.
+func (chart *distribution) style(i int) diagram.Style {
+	return *chart.Samples[i].Style.Or(diagram.Style{
+		Stroke: color.NRGBA{0x30, 0x30, 0x30, 0xFF},
+		Fill:   chart.Theme.Color(i),
+		Size:   1,
+	})
+}
+
+// shape draws the distribution of a single sample.
+//
+// point converts a position across the category band, in range [-1, 1],
+// and a value to canvas coordinates.
+type shape func(canvas diagram.Canvas, i int, summary Summary, point func(across, value float64) diagram.Point)
+
+// draw lays out the chart and draws each sample with fn.
+func (chart *distribution) draw(canvas diagram.Canvas, fn shape) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	names := make([]string, len(chart.Samples))
+	summaries := make([]Summary, len(chart.Samples))
+	values := newExtent()
+	for i, sample := range chart.Samples {
+		names[i] = sample.Name
+		sorted := chart.values(i)
+		summaries[i] = Summarize(sorted)
+		for _, v := range sorted {
+			values.add(v, false)
+		}
+	}
+
+	var categories *scale.Band
+	var value scale.Scale
+	area, axes := layoutAxes(bounds, func(area diagram.Rect) []*axis.Axis {
+		var categoryPosition, valuePosition axis.Position
+		var count int
+		if chart.Horizontal {
+			count = chart.Theme.tickCount(area.Size().X)
+			categories = scale.NewBand(names, area.Min.Y, area.Max.Y)
+			value = chart.Value.scale(values.min, values.max, area.Min.X, area.Max.X, count)
+			categoryPosition, valuePosition = axis.Left, axis.Bottom
+		} else {
+			count = chart.Theme.tickCount(area.Size().Y)
+			categories = scale.NewBand(names, area.Min.X, area.Max.X)
+			value = chart.Value.scale(values.min, values.max, area.Max.Y, area.Min.Y, count)
+			categoryPosition, valuePosition = axis.Bottom, axis.Left
+		}
+
+		category := chart.Category.axis(categoryPosition, categories, 0)
+		category.Theme.Grid = diagram.Style{}
+		category.Theme.TickLength = 0
+		return []*axis.Axis{category, chart.Value.axis(valuePosition, value, count)}
+	})
+	for _, a := range axes {
+		a.Draw(canvas, area)
+	}
+
+	// outliers on the edge of the area are drawn fully
+	plot := clip(canvas, area.Shrink(diagram.P(-outlierRadius-1, -outlierRadius-1)))
+	for i := range chart.Samples {
+		center := categories.MapIndex(i) + categories.Bandwidth()/2
+		half := categories.Bandwidth() * chart.Width / 2
+		point := func(across, v float64) diagram.Point {
+			if chart.Horizontal {
+				return diagram.Point{X: value.Position(v), Y: center + across*half}
+			}
+			return diagram.Point{X: center + across*half, Y: value.Position(v)}
+		}
+		fn(plot, i, summaries[i], point)
+	}
+}
+
+// BoxPlot draws the quartiles, whiskers and outliers of samples side by side.
+type BoxPlot struct{ distribution }
+
+// NewBoxPlot creates a box plot with the default theme.
+func NewBoxPlot() *BoxPlot {
+	chart := &BoxPlot{}
+	chart.Theme = DefaultTheme()
+	chart.Width = 0.5
+	return chart
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *BoxPlot) Draw(canvas diagram.Canvas) {
+	chart.draw(canvas, chart.drawBox)
+}
+
+func (chart *BoxPlot) drawBox(canvas diagram.Canvas, i int, summary Summary, point func(across, value float64) diagram.Point) {
+	if summary.Count == 0 {
+		return
+	}
+
+	style := chart.style(i)
+	style.Hint = chart.Samples[i].Name + "\n" + summary.String()
+	line := diagram.Style{Stroke: style.Stroke, Size: style.Size, Hint: style.Hint}
+
+	// whiskers
+	canvas.Poly([]diagram.Point{point(0, summary.Low), point(0, summary.Q1)}, &line)
+	canvas.Poly([]diagram.Point{point(0, summary.Q3), point(0, summary.High)}, &line)
+	canvas.Poly([]diagram.Point{point(-0.5, summary.Low), point(0.5, summary.Low)}, &line)
+	canvas.Poly([]diagram.Point{point(-0.5, summary.High), point(0.5, summary.High)}, &line)
+
+	// box
+	a, b := point(-1, summary.Q1), point(1, summary.Q3)
+	canvas.Rect(diagram.Rect{Min: a.Min(b), Max: a.Max(b)}, &style)
+
+	median := line
+	median.Size = style.Size * 2
+	canvas.Poly([]diagram.Point{point(-1, summary.Median), point(1, summary.Median)}, &median)
+
+	// outliers
+	for _, v := range summary.Outliers {
+		drawOutlier(canvas, point(0, v), &diagram.Style{
+			Stroke: style.Stroke,
+			Size:   style.Size,
+			Hint:   FormatValue(v),
+		})
+	}
+}
+
+// outlierRadius is the size of outlier markers.
+const outlierRadius = 3
+
+// drawOutlier draws a small diamond at p.
+func drawOutlier(canvas diagram.Canvas, p diagram.Point, style *diagram.Style) {
+	const r = outlierRadius
+	canvas.Poly(diagram.Ps(
+		p.X, p.Y-r,
+		p.X+r, p.Y,
+		p.X, p.Y+r,
+		p.X-r, p.Y,
+		p.X, p.Y-r,
+	), style)
+}
+
+// ViolinPlot draws the estimated density of samples side by side,
+// with the quartiles marked inside.
+type ViolinPlot struct {
+	distribution
+
+	// Bandwidth is the KDE bandwidth, 0 uses SilvermanBandwidth.
+	Bandwidth float64
+}
+
+// NewViolinPlot creates a violin plot with the default theme.
+func NewViolinPlot() *ViolinPlot {
+	chart := &ViolinPlot{}
+	chart.Theme = DefaultTheme()
+	chart.Width = 0.9
+	return chart
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *ViolinPlot) Draw(canvas diagram.Canvas) {
+	chart.draw(canvas, chart.drawViolin)
+}
+
+func (chart *ViolinPlot) drawViolin(canvas diagram.Canvas, i int, summary Summary, point func(across, value float64) diagram.Point) {
+	sorted := chart.values(i)
+	if len(sorted) == 0 {
+		return
+	}
+
+	style := chart.style(i)
+	style.Hint = chart.Samples[i].Name + "\n" + summary.String()
+
+	min, max := sorted[0], sorted[len(sorted)-1]
+	density := KDE(sorted, chart.Bandwidth)
+
+	const samples = 64
+	at := func(k int) float64 { return min + (max-min)*float64(k)/samples }
+	widths := make([]float64, samples+1)
+	largest := 0.0
+	for k := range widths {
+		widths[k] = density(at(k))
+		largest = math.Max(largest, widths[k])
+	}
+	if largest <= 0 {
+		largest = 1
+	}
+
+	outline := make([]diagram.Point, 0, 2*len(widths)+1)
+	for k := range widths {
+		outline = append(outline, point(-widths[k]/largest, at(k)))
+	}
+	for k := len(widths) - 1; k >= 0; k-- {
+		outline = append(outline, point(widths[k]/largest, at(k)))
+	}
+	outline = append(outline, outline[0])
+	canvas.Poly(outline, &style)
+
+	// quartiles inside the violin
+	inner := diagram.Style{Stroke: style.Stroke, Size: style.Size * 4, Hint: style.Hint}
+	canvas.Poly([]diagram.Point{point(0, summary.Q1), point(0, summary.Q3)}, &inner)
+	whisker := diagram.Style{Stroke: style.Stroke, Size: style.Size, Hint: style.Hint}
+	canvas.Poly([]diagram.Point{point(0, summary.Low), point(0, summary.High)}, &whisker)
+
+	median := diagram.Style{Fill: color.White, Stroke: style.Stroke, Size: style.Size, Hint: style.Hint}
+	p := point(0, summary.Median)
+	canvas.Rect(diagram.R(p.X-2, p.Y-2, p.X+2, p.Y+2), &median)
+}
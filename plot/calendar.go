@@ -0,0 +1,199 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"loov.dev/diagram"
+)
+
+// CalendarHeatmap draws a value for each day,
+// weeks are drawn as columns and weekdays as rows.
+type CalendarHeatmap struct {
+	Title string
+	// Values contains a value for each day, keyed by midnight UTC.
+	// Use Add to insert values at arbitrary times.
+	Values map[time.Time]float64
+
+	// From and To limit the drawn days, zero values use the range of Values.
+	From, To time.Time
+	// WeekStart is the first day of each column.
+	WeekStart time.Weekday
+
+	// ColorScale maps values to cell colors, defaults to Blues.
+	ColorScale ColorScale
+	// Legend configures the color legend, Min and Max override the color range.
+	Legend Axis
+	Format func(float64) string
+
+	Theme Theme
+	// MaxCell limits the size of a day cell.
+	MaxCell diagram.Length
+	// Gap is the space between cells.
+	Gap     diagram.Length
+	Missing diagram.Style
+}
+
+// NewCalendarHeatmap creates a calendar heatmap with the default theme.
+func NewCalendarHeatmap() *CalendarHeatmap {
+	chart := &CalendarHeatmap{}
+	chart.Values = map[time.Time]float64{}
+	chart.WeekStart = time.Monday
+	chart.ColorScale = Blues
+	chart.Format = FormatValue
+	chart.Theme = DefaultTheme()
+	chart.MaxCell = 24
+	chart.Gap = 2
+	chart.Missing = diagram.Style{Fill: color.NRGBA{0xF0, 0xF0, 0xF0, 0xFF}}
+	return chart
+}
+
+// day truncates t to midnight UTC of the same calendar date.
+func day(t time.Time) time.Time {
+	year, month, date := t.Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
+
+// Add adds value to the day of t.
+func (chart *CalendarHeatmap) Add(t time.Time, value float64) {
+	if chart.Values == nil {
+		chart.Values = map[time.Time]float64{}
+	}
+	chart.Values[day(t)] += value
+}
+
+// days returns the values grouped by day and the range of days.
+func (chart *CalendarHeatmap) days() (values map[time.Time]float64, from, to time.Time) {
+	values = make(map[time.Time]float64, len(chart.Values))
+	for t, v := range chart.Values {
+		if math.IsNaN(v) {
+			continue
+		}
+		t = day(t)
+		values[t] += v
+		if from.IsZero() || t.Before(from) {
+			from = t
+		}
+		if to.IsZero() || t.After(to) {
+			to = t
+		}
+	}
+	if !chart.From.IsZero() {
+		from = day(chart.From)
+	}
+	if !chart.To.IsZero() {
+		to = day(chart.To)
+	}
+	return values, from, to
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *CalendarHeatmap) Draw(canvas diagram.Canvas) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	values, from, to := chart.days()
+	if from.IsZero() || to.Before(from) {
+		return
+	}
+
+	extent := newExtent()
+	for t, v := range values {
+		if !t.Before(from) && !t.After(to) {
+			extent.add(v, chart.Legend.Log)
+		}
+	}
+	bounds, fill := chart.Theme.colorLegend(canvas, bounds, &chart.Legend, extent, chart.ColorScale)
+
+	format := chart.Format
+	if format == nil {
+		format = FormatValue
+	}
+
+	// the first column starts on WeekStart
+	first := from.AddDate(0, 0, -((int(from.Weekday())-int(chart.WeekStart))+7)%7)
+	weeks := int(to.Sub(first).Hours()/24)/7 + 1
+
+	label := chart.Theme.Label
+	label.Origin = diagram.P(1, 0)
+	labelWidth := diagram.Length(0)
+	for i := 0; i < 7; i++ {
+		name := time.Weekday((int(chart.WeekStart) + i) % 7).String()[:3]
+		labelWidth = math.Max(labelWidth, diagram.DefaultMeasurer.Measure(name, &label).Size().X)
+	}
+	labelWidth += chart.Theme.Padding / 2
+	monthHeight := label.Size * 1.5
+
+	cell := math.Min(
+		(bounds.Size().X-labelWidth)/float64(weeks),
+		(bounds.Size().Y-monthHeight)/7,
+	)
+	if chart.MaxCell > 0 {
+		cell = math.Min(cell, chart.MaxCell)
+	}
+	if cell <= 0 {
+		return
+	}
+	origin := diagram.P(bounds.Min.X+labelWidth, bounds.Min.Y+monthHeight)
+
+	// weekday labels, every other row to avoid crowding small cells
+	for i := 0; i < 7; i += 2 {
+		name := time.Weekday((int(chart.WeekStart) + i) % 7).String()[:3]
+		at := diagram.P(origin.X-chart.Theme.Padding/2, origin.Y+(float64(i)+0.5)*cell)
+		canvas.Text(name, at, &label)
+	}
+
+	// month labels above the week containing the first day of the month
+	month := chart.Theme.Label
+	month.Origin = diagram.P(-1, 1)
+	lastLabel := math.Inf(-1)
+	for week := 0; week < weeks; week++ {
+		start := first.AddDate(0, 0, week*7)
+		var name string
+		for i := 0; i < 7; i++ {
+			t := start.AddDate(0, 0, i)
+			if t.Before(from) || t.After(to) {
+				continue
+			}
+			if t.Day() == 1 || t.Equal(from) {
+				name = t.Format("Jan")
+				if t.Month() == time.January || t.Equal(from) {
+					name = t.Format("Jan 2006")
+				}
+				break
+			}
+		}
+
+		x := origin.X + float64(week)*cell
+		if name == "" || x < lastLabel {
+			continue
+		}
+		canvas.Text(name, diagram.P(x, origin.Y-chart.Theme.Padding/4), &month)
+		lastLabel = x + diagram.DefaultMeasurer.Measure(name, &month).Size().X + chart.Theme.Padding/2
+	}
+
+	for t := from; !t.After(to); t = t.AddDate(0, 0, 1) {
+		index := int(t.Sub(first).Hours()/24 + 0.5)
+		week, weekday := index/7, index%7
+		r := diagram.R(
+			origin.X+float64(week)*cell, origin.Y+float64(weekday)*cell,
+			origin.X+float64(week+1)*cell, origin.Y+float64(weekday+1)*cell,
+		).Shrink(diagram.P(chart.Gap/2, chart.Gap/2))
+
+		date := t.Format("2006-01-02")
+		v, ok := values[t]
+		if !ok || !isValue(v, chart.Legend.Log) {
+			style := chart.Missing
+			if style.Hint == "" {
+				style.Hint = date
+			}
+			canvas.Rect(r, &style)
+			continue
+		}
+
+		canvas.Rect(r, &diagram.Style{
+			Fill: fill(v),
+			Hint: date + ": " + format(v),
+		})
+	}
+}
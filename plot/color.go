@@ -0,0 +1,66 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+)
+
+// ColorScale maps a value in range [0, 1] to a color.
+type ColorScale func(t float64) color.Color
+
+// Gradient creates a color scale which linearly interpolates
+// between uniformly spaced colors.
+func Gradient(colors ...color.Color) ColorScale {
+	stops := make([]color.NRGBA, len(colors))
+	for i, c := range colors {
+		stops[i] = color.NRGBAModel.Convert(c).(color.NRGBA)
+	}
+
+	return func(t float64) color.Color {
+		switch {
+		case len(stops) == 0:
+			return color.Black
+		case len(stops) == 1 || math.IsNaN(t) || t <= 0:
+			return stops[0]
+		case t >= 1:
+			return stops[len(stops)-1]
+		}
+
+		p := t * float64(len(stops)-1)
+		i := int(p)
+		f := p - float64(i)
+		a, b := stops[i], stops[i+1]
+		lerp := func(x, y uint8) uint8 {
+			return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f))
+		}
+		return color.NRGBA{
+			R: lerp(a.R, b.R),
+			G: lerp(a.G, b.G),
+			B: lerp(a.B, b.B),
+			A: lerp(a.A, b.A),
+		}
+	}
+}
+
+// Blues is a sequential color scale from light to dark blue.
+var Blues = Gradient(
+	color.NRGBA{0xDE, 0xEB, 0xF7, 0xFF},
+	color.NRGBA{0x6B, 0xAE, 0xD6, 0xFF},
+	color.NRGBA{0x08, 0x30, 0x6B, 0xFF},
+)
+
+// Viridis is a perceptually uniform sequential color scale.
+var Viridis = Gradient(
+	color.NRGBA{0x44, 0x01, 0x54, 0xFF},
+	color.NRGBA{0x3B, 0x52, 0x8B, 0xFF},
+	color.NRGBA{0x21, 0x90, 0x8C, 0xFF},
+	color.NRGBA{0x5D, 0xC8, 0x63, 0xFF},
+	color.NRGBA{0xFD, 0xE7, 0x25, 0xFF},
+)
+
+// Diverging is a color scale from red through white to blue.
+var Diverging = Gradient(
+	color.NRGBA{0xB2, 0x18, 0x2B, 0xFF},
+	color.NRGBA{0xF7, 0xF7, 0xF7, 0xFF},
+	color.NRGBA{0x21, 0x66, 0xAC, 0xFF},
+)
@@ -0,0 +1,238 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// Heatmap draws a grid of values as colored cells.
+type Heatmap struct {
+	Title   string
+	Rows    []string
+	Columns []string
+	// Values contains a value for each row and column, Values[row][column],
+	// NaN and missing values are drawn with the Missing style.
+	Values [][]float64
+
+	// ColorScale maps values to cell colors, defaults to Blues.
+	ColorScale ColorScale
+	// Legend configures the color legend, Min and Max override the color range.
+	Legend Axis
+
+	// ValueLabels draws the value inside each cell, formatted with Format.
+	ValueLabels bool
+	Format      func(float64) string
+
+	Theme Theme
+	// Gap is the space between cells.
+	Gap     diagram.Length
+	Missing diagram.Style
+}
+
+// NewHeatmap creates a heatmap with the default theme.
+func NewHeatmap(rows, columns []string) *Heatmap {
+	chart := &Heatmap{}
+	chart.Rows = rows
+	chart.Columns = columns
+	chart.Values = make([][]float64, len(rows))
+	for i := range chart.Values {
+		chart.Values[i] = nanRow(len(columns))
+	}
+	chart.ColorScale = Blues
+	chart.Format = FormatValue
+	chart.Theme = DefaultTheme()
+	chart.Gap = 1
+	chart.Missing = diagram.Style{Fill: color.NRGBA{0xF0, 0xF0, 0xF0, 0xFF}}
+	return chart
+}
+
+// nanRow returns a row of n missing values.
+func nanRow(n int) []float64 {
+	row := make([]float64, n)
+	for i := range row {
+		row[i] = math.NaN()
+	}
+	return row
+}
+
+// Add adds value to the cell at row and column,
+// rows and columns that do not exist yet are appended.
+func (chart *Heatmap) Add(row, column string, value float64) {
+	r := indexOf(chart.Rows, row)
+	if r < 0 {
+		r = len(chart.Rows)
+		chart.Rows = append(chart.Rows, row)
+	}
+	c := indexOf(chart.Columns, column)
+	if c < 0 {
+		c = len(chart.Columns)
+		chart.Columns = append(chart.Columns, column)
+	}
+
+	for len(chart.Values) <= r {
+		chart.Values = append(chart.Values, nil)
+	}
+	if n := len(chart.Columns) - len(chart.Values[r]); n > 0 {
+		chart.Values[r] = append(chart.Values[r], nanRow(n)...)
+	}
+
+	if math.IsNaN(chart.Values[r][c]) {
+		chart.Values[r][c] = value
+	} else {
+		chart.Values[r][c] += value
+	}
+}
+
+// value returns the value at row r and column c.
+func (chart *Heatmap) value(r, c int) float64 {
+	if r < len(chart.Values) && c < len(chart.Values[r]) {
+		return chart.Values[r][c]
+	}
+	return math.NaN()
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *Heatmap) Draw(canvas diagram.Canvas) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	values := newExtent()
+	for r := range chart.Rows {
+		for c := range chart.Columns {
+			values.add(chart.value(r, c), chart.Legend.Log)
+		}
+	}
+	bounds, fill := chart.Theme.colorLegend(canvas, bounds, &chart.Legend, values, chart.ColorScale)
+
+	var rows, columns *scale.Band
+	area, axes := layoutAxes(bounds, func(area diagram.Rect) []*axis.Axis {
+		rows = scale.NewBand(chart.Rows, area.Min.Y, area.Max.Y)
+		columns = scale.NewBand(chart.Columns, area.Min.X, area.Max.X)
+		return []*axis.Axis{
+			categoryAxis(axis.Left, rows),
+			categoryAxis(axis.Bottom, columns),
+		}
+	})
+	for _, a := range axes {
+		a.Draw(canvas, area)
+	}
+
+	format := chart.Format
+	if format == nil {
+		format = FormatValue
+	}
+
+	for r, row := range chart.Rows {
+		for c, column := range chart.Columns {
+			cell := diagram.R(
+				columns.MapIndex(c), rows.MapIndex(r),
+				columns.MapIndex(c)+columns.Bandwidth(), rows.MapIndex(r)+rows.Bandwidth(),
+			).Shrink(diagram.P(chart.Gap/2, chart.Gap/2))
+
+			v := chart.value(r, c)
+			if !isValue(v, chart.Legend.Log) {
+				style := chart.Missing
+				if style.Hint == "" {
+					style.Hint = row + ", " + column
+				}
+				canvas.Rect(cell, &style)
+				continue
+			}
+
+			background := fill(v)
+			canvas.Rect(cell, &diagram.Style{
+				Fill: background,
+				Hint: row + ", " + column + ": " + format(v),
+			})
+			if chart.ValueLabels {
+				style := chart.Theme.Label
+				style.Fill = contrast(background)
+				style.Origin = diagram.P(0, 0)
+				canvas.Text(format(v), cell.UnitLocation(diagram.P(0, 0)), &style)
+			}
+		}
+	}
+}
+
+// indexOf returns the index of value in list, -1 when missing.
+func indexOf(list []string, value string) int {
+	for i, v := range list {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// isValue returns whether v can be mapped to a color.
+func isValue(v float64, log bool) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0) && !(log && v <= 0)
+}
+
+// categoryAxis creates an axis for labeled bands without ticks or grid.
+func categoryAxis(pos axis.Position, s *scale.Band) *axis.Axis {
+	a := scale.NewAxis(pos, s, 0)
+	a.Theme.Line = diagram.Style{}
+	a.Theme.Grid = diagram.Style{}
+	a.Theme.TickLength = 0
+	return a
+}
+
+// contrast returns a text color readable on background.
+func contrast(background color.Color) color.Color {
+	if background == nil {
+		return color.NRGBA{0x20, 0x20, 0x20, 0xFF}
+	}
+	c := color.NRGBAModel.Convert(background).(color.NRGBA)
+	luminance := 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+	if luminance < 140 {
+		return color.White
+	}
+	return color.NRGBA{0x20, 0x20, 0x20, 0xFF}
+}
+
+// colorLegend draws a color bar for values on the right side of bounds,
+// returns the remaining space and the mapping from values to colors.
+func (theme *Theme) colorLegend(canvas diagram.Canvas, bounds diagram.Rect, config *Axis, values extent, colors ColorScale) (diagram.Rect, func(float64) color.Color) {
+	if colors == nil {
+		colors = Blues
+	}
+
+	const barWidth = 12
+	const maxHeight = 240
+
+	// leave space for the labels at the ends
+	inset := theme.Label.Size
+	top := bounds.Min.Y + inset
+	bottom := math.Min(bounds.Max.Y-inset, top+maxHeight)
+
+	count := theme.tickCount(bottom - top)
+	s := config.scale(values.min, values.max, bottom, top, count)
+	a := config.axis(axis.Right, s, count)
+	a.Theme.Grid = diagram.Style{}
+
+	left := bounds.Max.X - barWidth - a.Extent()
+	bar := diagram.R(left, top, left+barWidth, bottom)
+
+	fill := func(v float64) color.Color {
+		t := (s.Position(v) - bottom) / (top - bottom)
+		return colors(math.Max(0, math.Min(t, 1)))
+	}
+
+	const steps = 64
+	for i := 0; i < steps; i++ {
+		y0 := bottom + (top-bottom)*float64(i)/steps
+		y1 := bottom + (top-bottom)*float64(i+1)/steps
+		// overlap stripes to avoid antialiasing seams
+		canvas.Rect(diagram.R(bar.Min.X, y1-0.5, bar.Max.X, y0), &diagram.Style{
+			Fill: colors((float64(i) + 0.5) / steps),
+		})
+	}
+	a.Draw(canvas, bar)
+
+	bounds.Max.X = left - theme.Padding
+	return bounds, fill
+}
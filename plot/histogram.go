@@ -0,0 +1,252 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// Binning returns the bin width for sorted values.
+type Binning func(sorted []float64) float64
+
+// Sturges chooses the number of bins as log2(n) + 1.
+func Sturges(sorted []float64) float64 {
+	n := float64(len(sorted))
+	if n == 0 {
+		return 1
+	}
+	bins := math.Ceil(math.Log2(n)) + 1
+	return (sorted[len(sorted)-1] - sorted[0]) / bins
+}
+
+// FreedmanDiaconis chooses the bin width as 2*IQR/cbrt(n), which is
+// robust to outliers. It falls back to Sturges when IQR is zero.
+func FreedmanDiaconis(sorted []float64) float64 {
+	iqr := Quantile(sorted, 0.75) - Quantile(sorted, 0.25)
+	if iqr <= 0 {
+		return Sturges(sorted)
+	}
+	return 2 * iqr / math.Cbrt(float64(len(sorted)))
+}
+
+// FixedWidth uses bins of the specified width.
+func FixedWidth(width float64) Binning {
+	return func([]float64) float64 { return width }
+}
+
+// MaxBins limits the number of bins, the bin width is widened
+// when the values would need more bins.
+const MaxBins = 1000
+
+// Bin is a range of values [Min, Max) with the number of values in it.
+type Bin struct {
+	Min, Max float64
+	Count    int
+}
+
+// Bins groups values into bins of equal width starting from the smallest value,
+// the last bin includes the largest value. NaN and infinite values are ignored.
+// At most MaxBins bins are returned.
+func Bins(values []float64, binning Binning) []Bin {
+	sorted := finite(values)
+	if len(sorted) == 0 {
+		return nil
+	}
+	if binning == nil {
+		binning = Sturges
+	}
+
+	min, max := sorted[0], sorted[len(sorted)-1]
+	width := binning(sorted)
+	if !(width > 0) || math.IsInf(width, 0) {
+		width = 1
+	}
+	if min == max {
+		min, max = min-width/2, max+width/2
+	}
+
+	if (max-min)/width > MaxBins {
+		width = (max - min) / MaxBins
+	}
+
+	count := int(math.Ceil((max - min) / width))
+	if count > MaxBins {
+		count = MaxBins
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	bins := make([]Bin, count)
+	for i := range bins {
+		bins[i].Min = min + float64(i)*width
+		bins[i].Max = min + float64(i+1)*width
+	}
+	for _, v := range sorted {
+		i := int((v - min) / width)
+		if i >= count {
+			i = count - 1
+		}
+		bins[i].Count++
+	}
+	return bins
+}
+
+// Histogram draws the distribution of values.
+//
+// Values can be converted from integer slices using IntsToFloat64s
+// or Int64sToFloat64s.
+type Histogram struct {
+	Title  string
+	Values []float64
+
+	// Binning chooses the bin width, defaults to Sturges.
+	Binning Binning
+	// Density scales bars such that their total area is 1.
+	Density bool
+	// Cumulative draws the running total of bins.
+	Cumulative bool
+	// KDE draws a gaussian kernel density estimate over the bars.
+	KDE bool
+	// Bandwidth is the KDE bandwidth, 0 uses SilvermanBandwidth.
+	Bandwidth float64
+
+	X, Y Axis
+
+	Theme Theme
+	Bar   diagram.Style
+	Curve diagram.Style
+}
+
+// NewHistogram creates a histogram with the default theme.
+func NewHistogram(values []float64) *Histogram {
+	chart := &Histogram{}
+	chart.Values = values
+	chart.Theme = DefaultTheme()
+	chart.Bar = diagram.Style{
+		Fill:   chart.Theme.Color(0),
+		Stroke: color.White,
+		Size:   1,
+	}
+	chart.Curve = diagram.Style{
+		Stroke: chart.Theme.Color(1),
+		Size:   2,
+	}
+	return chart
+}
+
+// heights returns the height of each bar.
+func (chart *Histogram) heights(bins []Bin, total int) []float64 {
+	heights := make([]float64, len(bins))
+	running := 0
+	for i, bin := range bins {
+		count := bin.Count
+		if chart.Cumulative {
+			running += bin.Count
+			count = running
+		}
+
+		heights[i] = float64(count)
+		if chart.Density {
+			if chart.Cumulative {
+				heights[i] /= float64(total)
+			} else {
+				heights[i] /= float64(total) * (bin.Max - bin.Min)
+			}
+		}
+	}
+	return heights
+}
+
+// curve returns the KDE overlay in the same units as bar heights.
+func (chart *Histogram) curve(sorted []float64, width float64) func(float64) float64 {
+	bandwidth := chart.Bandwidth
+	if bandwidth <= 0 {
+		bandwidth = SilvermanBandwidth(sorted)
+	}
+
+	scale := 1.0
+	if !chart.Density {
+		scale = float64(len(sorted))
+		if !chart.Cumulative {
+			scale *= width
+		}
+	}
+
+	if chart.Cumulative {
+		cdf := kdeCumulative(sorted, bandwidth)
+		return func(x float64) float64 { return cdf(x) * scale }
+	}
+	pdf := KDE(sorted, bandwidth)
+	return func(x float64) float64 { return pdf(x) * scale }
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *Histogram) Draw(canvas diagram.Canvas) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	sorted := finite(chart.Values)
+	bins := Bins(sorted, chart.Binning)
+	heights := chart.heights(bins, len(sorted))
+
+	var curve func(float64) float64
+	if chart.KDE && len(bins) > 0 {
+		curve = chart.curve(sorted, bins[0].Max-bins[0].Min)
+	}
+
+	xs, ys := newExtent(), newExtent()
+	ys.add(0, false)
+	for i, bin := range bins {
+		xs.add(bin.Min, false)
+		xs.add(bin.Max, false)
+		ys.add(heights[i], false)
+	}
+	if curve != nil {
+		for i := 0; i <= 64; i++ {
+			ys.add(curve(xs.min+(xs.max-xs.min)*float64(i)/64), false)
+		}
+	}
+
+	xaxis, yaxis := chart.X, chart.Y
+	xaxis.Log, yaxis.Log = false, false
+
+	var x, y scale.Scale
+	area, axes := layoutAxes(bounds, func(area diagram.Rect) []*axis.Axis {
+		xcount := chart.Theme.tickCount(area.Size().X)
+		ycount := chart.Theme.tickCount(area.Size().Y)
+		x = xaxis.scale(xs.min, xs.max, area.Min.X, area.Max.X, xcount)
+		y = yaxis.scale(ys.min, ys.max, area.Max.Y, area.Min.Y, ycount)
+		return []*axis.Axis{
+			xaxis.axis(axis.Bottom, x, xcount),
+			yaxis.axis(axis.Left, y, ycount),
+		}
+	})
+	for _, a := range axes {
+		a.Draw(canvas, area)
+	}
+
+	plot := clip(canvas, area)
+	for i, bin := range bins {
+		style := chart.Bar
+		if style.Hint == "" {
+			style.Hint = "[" + FormatValue(bin.Min) + ", " + FormatValue(bin.Max) + "): " + FormatValue(heights[i])
+		}
+		plot.Rect(diagram.R(
+			x.Position(bin.Min), y.Position(heights[i]),
+			x.Position(bin.Max), y.Position(0),
+		), &style)
+	}
+
+	if curve != nil {
+		const samples = 128
+		line := make([]diagram.Point, 0, samples+1)
+		for i := 0; i <= samples; i++ {
+			vx := xs.min + (xs.max-xs.min)*float64(i)/samples
+			line = append(line, diagram.Point{X: x.Position(vx), Y: y.Position(curve(vx))})
+		}
+		plot.Poly(line, &chart.Curve)
+	}
+}
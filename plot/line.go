@@ -0,0 +1,121 @@
+package plot
+
+import (
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// Series is a named sequence of data points.
+type Series struct {
+	Name   string
+	Points []diagram.Point
+	// Style overrides the line style, the default uses palette colors.
+	Style diagram.Style
+}
+
+// LineChart draws series as lines on a cartesian plane.
+type LineChart struct {
+	Title  string
+	Series []*Series
+
+	X, Y Axis
+
+	Theme     Theme
+	LineWidth diagram.Length
+}
+
+// NewLineChart creates a line chart with the default theme.
+func NewLineChart() *LineChart {
+	chart := &LineChart{}
+	chart.Theme = DefaultTheme()
+	chart.LineWidth = 2
+	return chart
+}
+
+// Add adds a new series to the chart.
+//
+// Points with NaN coordinates, or non-positive coordinates
+// on logarithmic axes, leave a gap in the line.
+func (chart *LineChart) Add(name string, points []diagram.Point) *Series {
+	series := &Series{Name: name, Points: points}
+	chart.Series = append(chart.Series, series)
+	return series
+}
+
+// style returns the line style of the i-th series.
+func (chart *LineChart) style(i int) *diagram.Style {
+	return chart.Series[i].Style.Or(diagram.Style{
+		Stroke: chart.Theme.Color(i),
+		Size:   chart.LineWidth,
+	})
+}
+
+// valid returns whether p can be drawn on the chart axes.
+func (chart *LineChart) valid(p diagram.Point) bool {
+	if math.IsNaN(p.X) || math.IsNaN(p.Y) {
+		return false
+	}
+	return !(chart.X.Log && p.X <= 0) && !(chart.Y.Log && p.Y <= 0)
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *LineChart) Draw(canvas diagram.Canvas) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	var entries []legendEntry
+	for i, series := range chart.Series {
+		if series.Name != "" {
+			entries = append(entries, legendEntry{name: series.Name, style: *chart.style(i)})
+		}
+	}
+	bounds = chart.Theme.legend(canvas, bounds, entries)
+
+	xs, ys := newExtent(), newExtent()
+	for _, series := range chart.Series {
+		for _, p := range series.Points {
+			if math.IsNaN(p.X) || math.IsNaN(p.Y) {
+				continue
+			}
+			xs.add(p.X, chart.X.Log)
+			ys.add(p.Y, chart.Y.Log)
+		}
+	}
+
+	var x, y scale.Scale
+	area, axes := layoutAxes(bounds, func(area diagram.Rect) []*axis.Axis {
+		xcount := chart.Theme.tickCount(area.Size().X)
+		ycount := chart.Theme.tickCount(area.Size().Y)
+		x = chart.X.scale(xs.min, xs.max, area.Min.X, area.Max.X, xcount)
+		y = chart.Y.scale(ys.min, ys.max, area.Max.Y, area.Min.Y, ycount)
+		return []*axis.Axis{
+			chart.X.axis(axis.Bottom, x, xcount),
+			chart.Y.axis(axis.Left, y, ycount),
+		}
+	})
+	for _, a := range axes {
+		a.Draw(canvas, area)
+	}
+
+	plot := clip(canvas, area)
+	for i, series := range chart.Series {
+		style := chart.style(i)
+
+		var line []diagram.Point
+		for _, p := range series.Points {
+			if !chart.valid(p) {
+				if len(line) > 0 {
+					plot.Poly(line, style)
+				}
+				line = nil
+				continue
+			}
+			line = append(line, diagram.Point{X: x.Position(p.X), Y: y.Position(p.Y)})
+		}
+		if len(line) > 0 {
+			plot.Poly(line, style)
+		}
+	}
+}
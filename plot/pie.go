@@ -0,0 +1,289 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"loov.dev/diagram"
+)
+
+// PieSlice is a named value in a pie chart.
+type PieSlice struct {
+	Name  string
+	Value float64
+	// Style overrides the slice style, the default uses palette colors.
+	Style diagram.Style
+}
+
+// PieChart draws the share of each value as a slice of a circle.
+//
+// Slices too small to fit a label are labeled outside of the
+// circle with a leader line.
+type PieChart struct {
+	Title  string
+	Slices []*PieSlice
+
+	// Hole is the radius of the center hole as a fraction of the radius,
+	// a non-zero value draws a donut chart.
+	Hole float64
+	// StartAngle is the angle of the first slice in radians,
+	// measured clockwise from the positive X axis.
+	StartAngle float64
+
+	// Labels draws slice names.
+	Labels bool
+	// Percentages draws the share of each slice.
+	Percentages bool
+	// Legend draws slice names in a legend.
+	Legend bool
+	// MinInsideAngle is the smallest slice angle, in radians,
+	// where the label is drawn inside the slice.
+	MinInsideAngle float64
+
+	Theme Theme
+	// Separator is drawn between slices.
+	Separator diagram.Style
+	// Leader is the style of lines to outside labels.
+	Leader diagram.Style
+}
+
+// NewPieChart creates a pie chart with the default theme.
+func NewPieChart() *PieChart {
+	chart := &PieChart{}
+	chart.StartAngle = -math.Pi / 2
+	chart.Labels = true
+	chart.Percentages = true
+	chart.MinInsideAngle = 0.35
+	chart.Theme = DefaultTheme()
+	chart.Separator = diagram.Style{Stroke: color.White, Size: 1.5}
+	chart.Leader = diagram.Style{Stroke: color.NRGBA{0x80, 0x80, 0x80, 0xFF}, Size: 1}
+	return chart
+}
+
+// NewDonutChart creates a pie chart with a hole in the center.
+func NewDonutChart() *PieChart {
+	chart := NewPieChart()
+	chart.Hole = 0.55
+	return chart
+}
+
+// Add adds a new slice to the chart.
+func (chart *PieChart) Add(name string, value float64) *PieSlice {
+	slice := &PieSlice{Name: name, Value: value}
+	chart.Slices = append(chart.Slices, slice)
+	return slice
+}
+
+// style returns the style of the i-th slice.
+func (chart *PieChart) style(i int) *diagram.Style {
+	return chart.Slices[i].Style.Or(diagram.Style{
+		Fill:   chart.Theme.Color(i),
+		Stroke: chart.Separator.Stroke,
+		Size:   chart.Separator.Size,
+	})
+}
+
+// value returns the value of the slice,
+// negative and invalid values are treated as zero.
+func (slice *PieSlice) value() float64 {
+	if math.IsNaN(slice.Value) || math.IsInf(slice.Value, 0) || slice.Value < 0 {
+		return 0
+	}
+	return slice.Value
+}
+
+// FormatPercent formats a fraction as a percentage with one decimal.
+func FormatPercent(fraction float64) string {
+	s := strconv.FormatFloat(fraction*100, 'f', 1, 64)
+	return strings.TrimSuffix(s, ".0") + "%"
+}
+
+// pieLeader is the length of leader lines to outside labels.
+const pieLeader = 20
+
+// pieLabel is the label of a slice.
+type pieLabel struct {
+	slice   int
+	text    string
+	mid     float64
+	size    diagram.Point
+	outside bool
+	// right is set for outside labels on the right side.
+	right bool
+	y     diagram.Length
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *PieChart) Draw(canvas diagram.Canvas) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	if chart.Legend {
+		var entries []legendEntry
+		for i, slice := range chart.Slices {
+			entries = append(entries, legendEntry{name: slice.Name, style: diagram.Style{Fill: chart.style(i).Fill}, box: true})
+		}
+		bounds = chart.Theme.legend(canvas, bounds, entries)
+	}
+
+	total, nonzero := 0.0, 0
+	for _, slice := range chart.Slices {
+		total += slice.value()
+		if slice.value() > 0 {
+			nonzero++
+		}
+	}
+	if total <= 0 {
+		return
+	}
+
+	style := chart.Theme.Label
+	style.Origin = diagram.P(0, 0)
+
+	// collect labels to find the space needed outside of the circle
+	var labels []pieLabel
+	angle := chart.StartAngle
+	outsideWidth, outsideHeight := diagram.Length(0), diagram.Length(0)
+	for i, slice := range chart.Slices {
+		sweep := 2 * math.Pi * slice.value() / total
+		mid := angle + sweep/2
+		angle += sweep
+		if sweep == 0 {
+			continue
+		}
+
+		var lines []string
+		if chart.Labels && !chart.Legend && slice.Name != "" {
+			lines = append(lines, slice.Name)
+		}
+		if chart.Percentages {
+			lines = append(lines, FormatPercent(slice.value()/total))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		label := pieLabel{
+			slice:   i,
+			text:    strings.Join(lines, "\n"),
+			mid:     mid,
+			outside: sweep < chart.MinInsideAngle,
+			right:   math.Cos(mid) >= 0,
+		}
+		label.size = diagram.DefaultMeasurer.Measure(label.text, &style).Size()
+		if label.outside {
+			outsideWidth = math.Max(outsideWidth, label.size.X)
+			outsideHeight = math.Max(outsideHeight, label.size.Y)
+		}
+		labels = append(labels, label)
+	}
+
+	size := bounds.Size()
+	radius := math.Min(size.X, size.Y) / 2
+	if outsideWidth > 0 {
+		radius = math.Min(
+			size.X/2-outsideWidth-pieLeader-chart.Theme.Padding/2,
+			size.Y/2-outsideHeight-pieLeader/2,
+		)
+	}
+	if radius <= 0 {
+		return
+	}
+	center := bounds.UnitLocation(diagram.P(0, 0))
+	hole := radius * math.Max(0, math.Min(chart.Hole, 0.95))
+
+	angle = chart.StartAngle
+	for i, slice := range chart.Slices {
+		sweep := 2 * math.Pi * slice.value() / total
+		if sweep == 0 {
+			continue
+		}
+		style := *chart.style(i)
+		style.Hint = slice.Name + ": " + FormatValue(slice.Value) + " (" + FormatPercent(slice.value()/total) + ")"
+		if nonzero == 1 {
+			// a full circle has no separators
+			style.Stroke = nil
+		}
+		canvas.Path(diagram.Sector(center, hole, radius, angle, angle+sweep), &style)
+		angle += sweep
+	}
+
+	chart.drawLabels(canvas, bounds, center, hole, radius, labels)
+}
+
+// drawLabels draws labels inside slices or on the sides with leader lines.
+func (chart *PieChart) drawLabels(canvas diagram.Canvas, bounds diagram.Rect, center diagram.Point, hole, radius diagram.Length, labels []pieLabel) {
+	var left, right []*pieLabel
+	for i := range labels {
+		label := &labels[i]
+		if !label.outside {
+			style := chart.Theme.Label
+			style.Origin = diagram.P(0, 0)
+			style.Fill = contrast(chart.style(label.slice).Fill)
+			canvas.Text(label.text, diagram.Polar(center, (hole+radius)/2, label.mid), &style)
+			continue
+		}
+
+		label.y = diagram.Polar(center, radius+pieLeader/2, label.mid).Y
+		if label.right {
+			right = append(right, label)
+		} else {
+			left = append(left, label)
+		}
+	}
+
+	for _, side := range [][]*pieLabel{left, right} {
+		spreadLabels(side, bounds.Min.Y, bounds.Max.Y)
+		for _, label := range side {
+			edge := diagram.Polar(center, radius, label.mid)
+			elbow := diagram.Polar(center, radius+pieLeader/2, label.mid)
+			elbow.Y = label.y
+
+			style := chart.Theme.Label
+			end := diagram.P(center.X+radius+pieLeader, label.y)
+			style.Origin = diagram.P(-1, 0)
+			if !label.right {
+				end.X = center.X - radius - pieLeader
+				style.Origin = diagram.P(1, 0)
+			}
+			canvas.Poly([]diagram.Point{edge, elbow, end}, &chart.Leader)
+
+			at := end
+			if label.right {
+				at.X += chart.Theme.Padding / 4
+			} else {
+				at.X -= chart.Theme.Padding / 4
+			}
+			canvas.Text(label.text, at, &style)
+		}
+	}
+}
+
+// spreadLabels moves labels vertically, such that they don't overlap
+// and stay between top and bottom.
+func spreadLabels(labels []*pieLabel, top, bottom diagram.Length) {
+	sort.SliceStable(labels, func(i, k int) bool { return labels[i].y < labels[k].y })
+
+	// move overlapping neighbours apart until they settle
+	for iteration := 0; iteration < 100; iteration++ {
+		moved := false
+		for i := 1; i < len(labels); i++ {
+			a, b := labels[i-1], labels[i]
+			overlap := a.y + (a.size.Y+b.size.Y)/2 - b.y
+			if overlap > 0.01 {
+				a.y -= overlap / 2
+				b.y += overlap / 2
+				moved = true
+			}
+		}
+		for _, label := range labels {
+			label.y = math.Max(label.y, top+label.size.Y/2)
+			label.y = math.Min(label.y, bottom-label.size.Y/2)
+		}
+		if !moved {
+			break
+		}
+	}
+}
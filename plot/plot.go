@@ -0,0 +1,278 @@
+// Package plot implements charts that can be drawn onto a diagram.Canvas.
+//
+// Charts fill the bounds of the canvas they are drawn on, to embed
+// a chart inside another diagram, draw it on canvas.Context(r).
+package plot
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// DefaultPalette is the default list of colors for series.
+var DefaultPalette = []color.Color{
+	color.NRGBA{0x1F, 0x77, 0xB4, 0xFF},
+	color.NRGBA{0xFF, 0x7F, 0x0E, 0xFF},
+	color.NRGBA{0x2C, 0xA0, 0x2C, 0xFF},
+	color.NRGBA{0xD6, 0x27, 0x28, 0xFF},
+	color.NRGBA{0x94, 0x67, 0xBD, 0xFF},
+	color.NRGBA{0x8C, 0x56, 0x4B, 0xFF},
+	color.NRGBA{0xE3, 0x77, 0xC2, 0xFF},
+	color.NRGBA{0x7F, 0x7F, 0x7F, 0xFF},
+	color.NRGBA{0xBC, 0xBD, 0x22, 0xFF},
+	color.NRGBA{0x17, 0xBE, 0xCF, 0xFF},
+}
+
+// Theme contains the common styling of charts.
+type Theme struct {
+	Padding diagram.Length
+	// TickSpacing is the approximate distance between major ticks.
+	TickSpacing diagram.Length
+
+	Background diagram.Style
+	Title      diagram.Style
+	Legend     diagram.Style
+	// Label is used for value labels.
+	Label diagram.Style
+
+	Palette []color.Color
+}
+
+// DefaultTheme returns the default chart theme.
+func DefaultTheme() Theme {
+	return Theme{
+		Padding:     10,
+		TickSpacing: 80,
+		Title: diagram.Style{
+			Fill: color.NRGBA{0x20, 0x20, 0x20, 0xFF},
+			Size: 16,
+		},
+		Legend: diagram.Style{
+			Fill: color.NRGBA{0x20, 0x20, 0x20, 0xFF},
+			Size: 12,
+		},
+		Label: diagram.Style{
+			Fill: color.NRGBA{0x40, 0x40, 0x40, 0xFF},
+			Size: 11,
+		},
+		Palette: DefaultPalette,
+	}
+}
+
+// Color returns the i-th palette color.
+func (theme *Theme) Color(i int) color.Color {
+	palette := theme.Palette
+	if len(palette) == 0 {
+		palette = DefaultPalette
+	}
+	return palette[i%len(palette)]
+}
+
+// tickCount returns the number of ticks for an axis of the specified length.
+func (theme *Theme) tickCount(length diagram.Length) int {
+	spacing := theme.TickSpacing
+	if spacing <= 0 {
+		spacing = 80
+	}
+	count := int(math.Abs(length) / spacing)
+	if count < 2 {
+		count = 2
+	}
+	return count
+}
+
+// frame draws the background and the title,
+// returns the remaining space for the chart.
+func (theme *Theme) frame(canvas diagram.Canvas, title string) diagram.Rect {
+	bounds := canvas.Bounds()
+	if !theme.Background.IsZero() {
+		canvas.Layer(-1).Rect(bounds, &theme.Background)
+	}
+
+	bounds = bounds.Shrink(diagram.P(theme.Padding, theme.Padding))
+	if title != "" {
+		style := theme.Title
+		style.Origin = diagram.P(0, -1)
+		canvas.Text(title, diagram.P((bounds.Min.X+bounds.Max.X)/2, bounds.Min.Y), &style)
+
+		size := diagram.DefaultMeasurer.Measure(title, &style).Size()
+		bounds.Min.Y += size.Y + theme.Padding
+	}
+	return bounds
+}
+
+// Axis configures a chart axis.
+type Axis struct {
+	Title string
+	// Min and Max override the automatic range when they differ.
+	Min, Max float64
+	// Log uses a logarithmic scale.
+	Log bool
+}
+
+// domain returns the axis range, using the data range [min, max] when
+// the range is not specified.
+func (config *Axis) domain(min, max float64) (float64, float64, bool) {
+	if config.Min != config.Max {
+		return config.Min, config.Max, false
+	}
+	if math.IsInf(min, 0) || math.IsInf(max, 0) {
+		// no data, log scales need a positive domain
+		if config.Log {
+			min, max = 1, 10
+		} else {
+			min, max = 0, 1
+		}
+	}
+	if min == max {
+		if config.Log {
+			min, max = min/10, max*10
+		} else {
+			min, max = min-1, max+1
+		}
+	}
+	return min, max, true
+}
+
+// scale returns a continuous scale for the axis, automatic ranges
+// are extended to nice values.
+func (config *Axis) scale(min, max float64, from, to diagram.Length, count int) scale.Scale {
+	min, max, auto := config.domain(min, max)
+	if config.Log {
+		s := scale.NewLog(min, max, from, to)
+		if auto {
+			s.Nice()
+		}
+		return s
+	}
+
+	s := scale.NewLinear(min, max, from, to)
+	if auto {
+		s.Nice(count)
+	}
+	return s
+}
+
+// axis creates an axis drawing for scale s.
+func (config *Axis) axis(pos axis.Position, s scale.Scale, count int) *axis.Axis {
+	a := scale.NewAxis(pos, s, count)
+	a.Title = config.Title
+	return a
+}
+
+// extent tracks the range of values.
+type extent struct{ min, max float64 }
+
+func newExtent() extent { return extent{math.Inf(1), math.Inf(-1)} }
+
+// add includes v in the range, positive only ignores non-positive values.
+func (e *extent) add(v float64, positiveOnly bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) || (positiveOnly && v <= 0) {
+		return
+	}
+	e.min = math.Min(e.min, v)
+	e.max = math.Max(e.max, v)
+}
+
+// layoutAxes finds the plot area inside bounds, which leaves space for axes
+// created by the axes func.
+func layoutAxes(bounds diagram.Rect, axes func(area diagram.Rect) []*axis.Axis) (diagram.Rect, []*axis.Axis) {
+	area := bounds
+	var list []*axis.Axis
+	for iteration := 0; iteration < 3; iteration++ {
+		list = axes(area)
+
+		used := area
+		for _, a := range list {
+			r := a.Bounds(area)
+			used.Min = used.Min.Min(r.Min)
+			used.Max = used.Max.Max(r.Max)
+		}
+
+		overflowMin := bounds.Min.Sub(used.Min)
+		overflowMax := used.Max.Sub(bounds.Max)
+		if overflowMin.X <= 0 && overflowMin.Y <= 0 && overflowMax.X <= 0 && overflowMax.Y <= 0 {
+			break
+		}
+		area.Min = area.Min.Add(overflowMin.Max(diagram.Point{}))
+		area.Max = area.Max.Sub(overflowMax.Max(diagram.Point{}))
+	}
+	return area, list
+}
+
+// clip returns a canvas clipped to area, which uses the same coordinates as canvas.
+func clip(canvas diagram.Canvas, area diagram.Rect) diagram.Canvas {
+	return canvas.Clip(area).Transform(diagram.Translate(area.Min.Neg()))
+}
+
+// legendEntry is a single item in a legend.
+type legendEntry struct {
+	name  string
+	style diagram.Style
+	// box draws a filled swatch instead of a line.
+	box bool
+	// shape draws a point shape instead of a line.
+	shape Shape
+}
+
+// legend draws entries on the right side of bounds,
+// returns the remaining space.
+func (theme *Theme) legend(canvas diagram.Canvas, bounds diagram.Rect, entries []legendEntry) diagram.Rect {
+	if len(entries) == 0 {
+		return bounds
+	}
+
+	style := theme.Legend
+	style.Origin = diagram.P(-1, 0)
+
+	const swatch = 16
+	lineHeight := style.Size * 1.5
+	if lineHeight == 0 {
+		lineHeight = 18
+	}
+
+	width := diagram.Length(0)
+	for _, entry := range entries {
+		width = math.Max(width, diagram.DefaultMeasurer.Measure(entry.name, &style).Size().X)
+	}
+	width += swatch + theme.Padding/2
+
+	left := bounds.Max.X - width
+	for i, entry := range entries {
+		y := bounds.Min.Y + (float64(i)+0.5)*lineHeight
+		switch {
+		case entry.box:
+			canvas.Rect(diagram.R(left, y-swatch/4-2, left+swatch, y+swatch/4+2), &entry.style)
+		case entry.shape != "":
+			canvas.Path(entry.shape.Path(diagram.P(left+swatch/2, y), swatch/4), &entry.style)
+		default:
+			canvas.Poly(diagram.Ps(left, y, left+swatch, y), &entry.style)
+		}
+		canvas.Text(entry.name, diagram.P(left+swatch+theme.Padding/2, y), &style)
+	}
+
+	bounds.Max.X = left - theme.Padding
+	return bounds
+}
+
+// FormatValue formats a value label with at most two decimals,
+// very large and small values use exponent notation.
+func FormatValue(v float64) string {
+	abs := math.Abs(v)
+	if abs != 0 && (abs >= 1e6 || abs < 1e-2) {
+		return strconv.FormatFloat(v, 'g', 3, 64)
+	}
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
@@ -0,0 +1,1073 @@
+package plot_test
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/plot"
+)
+
+// record draws chart on a 400x300 canvas and returns the operations
+// in drawing order with coordinates relative to the canvas.
+func record(t *testing.T, chart interface{ Draw(diagram.Canvas) }) []diagram.Op {
+	t.Helper()
+
+	rec := diagram.NewRecorder(400, 300)
+	chart.Draw(rec)
+
+	var ops []diagram.Op
+	flatten(rec, diagram.Identity(), &ops)
+
+	for _, op := range ops {
+		for _, p := range opPoints(op) {
+			if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.IsInf(p.X, 0) || math.IsInf(p.Y, 0) {
+				t.Fatalf("invalid coordinates in %v %q: %v", op.Kind, op.Text, p)
+			}
+		}
+	}
+	return ops
+}
+
+// flatten appends operations of rec and its contexts transformed by m.
+func flatten(rec *diagram.Recorder, m diagram.Affine, ops *[]diagram.Op) {
+	for _, layer := range rec.Layers {
+		if layer.Order < 0 {
+			flatten(layer, m, ops)
+		}
+	}
+	for _, op := range rec.Ops {
+		switch op.Kind {
+		case diagram.OpContext:
+			next := m
+			if op.Rect != nil {
+				next = diagram.Translate(op.Rect.Min).Then(m)
+			}
+			if op.Transform != nil {
+				next = op.Transform.Then(m)
+			}
+			flatten(op.Context, next, ops)
+			continue
+		case diagram.OpText:
+			at := m.Apply(*op.At)
+			op.At = &at
+		case diagram.OpPoly:
+			op.Points = m.ApplyPoints(op.Points)
+		case diagram.OpRect:
+			r := m.ApplyRect(*op.Rect)
+			op.Rect = &r
+		case diagram.OpPath:
+			op.Path = m.ApplyPath(op.Path)
+		}
+		*ops = append(*ops, op)
+	}
+	for _, layer := range rec.Layers {
+		if layer.Order > 0 {
+			flatten(layer, m, ops)
+		}
+	}
+}
+
+// opPoints returns the coordinates used by op.
+func opPoints(op diagram.Op) []diagram.Point {
+	switch op.Kind {
+	case diagram.OpText:
+		return []diagram.Point{*op.At}
+	case diagram.OpPoly:
+		return op.Points
+	case diagram.OpRect:
+		return []diagram.Point{op.Rect.Min, op.Rect.Max}
+	case diagram.OpPath:
+		var points []diagram.Point
+		for _, cmd := range op.Path.Commands {
+			points = append(points, cmd.Points...)
+		}
+		return points
+	}
+	return nil
+}
+
+// filter returns operations of kind that match fn.
+func filter(ops []diagram.Op, kind diagram.OpKind, fn func(op diagram.Op) bool) []diagram.Op {
+	var result []diagram.Op
+	for _, op := range ops {
+		if op.Kind == kind && (fn == nil || fn(op)) {
+			result = append(result, op)
+		}
+	}
+	return result
+}
+
+// stroked returns polylines drawn with stroke.
+func stroked(ops []diagram.Op, stroke color.Color) [][]diagram.Point {
+	var lines [][]diagram.Point
+	for _, op := range filter(ops, diagram.OpPoly, func(op diagram.Op) bool { return op.Style.Stroke == stroke }) {
+		lines = append(lines, op.Points)
+	}
+	return lines
+}
+
+// text returns the position of the first text op with content text.
+func text(t *testing.T, ops []diagram.Op, content string) diagram.Point {
+	t.Helper()
+	texts := filter(ops, diagram.OpText, func(op diagram.Op) bool { return op.Text == content })
+	if len(texts) == 0 {
+		t.Fatalf("missing text %q", content)
+	}
+	return *texts[0].At
+}
+
+// labels returns value labels drawn with the default theme.
+func labels(ops []diagram.Op) []diagram.Op {
+	size := plot.DefaultTheme().Label.Size
+	return filter(ops, diagram.OpText, func(op diagram.Op) bool { return op.Style.Size == size })
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func distance(a, b diagram.Point) float64 { return math.Hypot(a.X-b.X, a.Y-b.Y) }
+
+func TestLineChart(t *testing.T) {
+	chart := plot.NewLineChart()
+	chart.Title = "Title"
+	chart.Add("a", diagram.Points(nil, []float64{1, 2, math.NaN(), 4}))
+	chart.Add("b", diagram.Points(nil, []float64{0, 1, 10, 100}))
+	ops := record(t, chart)
+
+	if title := text(t, ops, "Title"); title.X != 200 {
+		t.Errorf("title should be centered, got %v", title)
+	}
+
+	// the first line of each color is the legend swatch
+	a, b := stroked(ops, plot.DefaultPalette[0]), stroked(ops, plot.DefaultPalette[1])
+	for i, lines := range [][][]diagram.Point{a, b} {
+		name := []string{"a", "b"}[i]
+		label := text(t, ops, name)
+		swatch := lines[0]
+		if len(swatch) != 2 || swatch[0].Y != label.Y || swatch[1].Y != label.Y || swatch[1].X >= label.X {
+			t.Errorf("%v: swatch %v should be left of label %v", name, swatch, label)
+		}
+	}
+
+	// NaN splits the line
+	if len(a) != 3 || len(a[1]) != 2 || len(a[2]) != 1 {
+		t.Fatalf("expected a gap in a, got %v", a[1:])
+	}
+	if len(b) != 2 || len(b[1]) != 4 {
+		t.Fatalf("expected a single line for b, got %v", b[1:])
+	}
+	line := b[1]
+	legend := a[0][0].X
+	for i, p := range line {
+		if p.X >= legend {
+			t.Errorf("line overlaps the legend: %v", p)
+		}
+		if i > 0 && !near(p.X-line[i-1].X, line[1].X-line[0].X) {
+			t.Errorf("points should be evenly spaced: %v", line)
+		}
+	}
+	// shared y scale, values grow upwards
+	if !near(a[1][0].Y, line[1].Y) || !near(a[2][0].Y, line[0].Y-4*(line[0].Y-line[1].Y)) {
+		t.Errorf("a %v does not match scale of b %v", a[1:], line)
+	}
+	if !near((line[0].Y-line[1].Y)*100, line[0].Y-line[3].Y) {
+		t.Errorf("expected linear scale: %v", line)
+	}
+
+	// zero is not valid on a logarithmic axis
+	chart.Y.Log = true
+	b = stroked(record(t, chart), plot.DefaultPalette[1])
+	if len(b) != 2 || len(b[1]) != 3 {
+		t.Fatalf("expected zero to be skipped, got %v", b[1:])
+	}
+	line = b[1]
+	if !near(line[0].Y-line[1].Y, line[1].Y-line[2].Y) || line[1].Y >= line[0].Y {
+		t.Errorf("expected logarithmic scale: %v", line)
+	}
+
+	empty := record(t, plot.NewLineChart())
+	if len(filter(empty, diagram.OpText, func(op diagram.Op) bool { return op.Style.Size == 16 })) != 0 {
+		t.Errorf("empty chart should not have a title")
+	}
+
+	// an empty series on a logarithmic axis falls back to 1..10
+	logEmpty := plot.NewLineChart()
+	logEmpty.Y.Log = true
+	logEmpty.Add("a", diagram.Points(nil, []float64{0, math.NaN()}))
+	ops = record(t, logEmpty)
+	if one, ten := text(t, ops, "1"), text(t, ops, "10"); ten.Y >= one.Y {
+		t.Errorf("expected ticks 1 below 10, got %v %v", one, ten)
+	}
+}
+
+// bars returns bar rectangles by their hint.
+func bars(ops []diagram.Op) map[string]diagram.Rect {
+	result := map[string]diagram.Rect{}
+	for _, op := range filter(ops, diagram.OpRect, func(op diagram.Op) bool { return op.Style.Hint != "" }) {
+		result[op.Style.Hint] = *op.Rect
+	}
+	return result
+}
+
+func TestBarChart(t *testing.T) {
+	newChart := func(stacked, horizontal bool) *plot.BarChart {
+		chart := plot.NewBarChart("a", "b", "c")
+		chart.Stacked = stacked
+		chart.Horizontal = horizontal
+		chart.ValueLabels = true
+		chart.Add("x", []float64{1, -2, 3})
+		chart.Add("y", []float64{2, math.NaN()})
+		return chart
+	}
+
+	t.Run("grouped", func(t *testing.T) {
+		ops := record(t, newChart(false, false))
+		b := bars(ops)
+		if len(b) != 6 {
+			t.Fatalf("expected 6 bars, got %v", b)
+		}
+		ax, ay, bx, by := b["a x: 1"], b["a y: 2"], b["b x: -2"], b["b y: 0"]
+
+		zero := ax.Max.Y
+		if ay.Max.Y != zero || bx.Min.Y != zero || by.Min.Y != zero || by.Max.Y != zero {
+			t.Errorf("bars should start at zero %v: %v %v %v", zero, ay, bx, by)
+		}
+		if !near(ay.Size().Y, 2*ax.Size().Y) || !near(bx.Size().Y, 2*ax.Size().Y) {
+			t.Errorf("heights should be proportional: %v %v %v", ax, ay, bx)
+		}
+		if !(ax.Max.X < ay.Min.X && ay.Max.X < bx.Min.X) || !near(ax.Size().X, ay.Size().X) {
+			t.Errorf("bars should be side by side: %v %v %v", ax, ay, bx)
+		}
+
+		// negative values have a zero line
+		// ticks use the same style, but are short
+		axisLine := axis.New(axis.Left, nil, nil).Theme.Line.Stroke
+		if len(filter(ops, diagram.OpPoly, func(op diagram.Op) bool {
+			return op.Style.Stroke == axisLine && near(op.Points[0].Y, zero) && near(op.Points[1].Y, zero) &&
+				op.Points[0].X < ax.Min.X && op.Points[1].X > by.Max.X
+		})) != 1 {
+			t.Errorf("missing zero line at %v", zero)
+		}
+
+		values := labels(ops)
+		if label := text(t, values, "1"); label.Y >= ax.Min.Y || !near(label.X, (ax.Min.X+ax.Max.X)/2) {
+			t.Errorf("label %v should be above %v", label, ax)
+		}
+		if label := text(t, values, "-2"); label.Y <= bx.Max.Y {
+			t.Errorf("label %v should be below %v", label, bx)
+		}
+	})
+
+	t.Run("stacked", func(t *testing.T) {
+		b := bars(record(t, newChart(true, false)))
+		ax, ay, bx := b["a x: 1"], b["a y: 2"], b["b x: -2"]
+		if ax.Min.X != ay.Min.X || ax.Max.X != ay.Max.X {
+			t.Errorf("stacked bars should have the same position: %v %v", ax, ay)
+		}
+		if !near(ay.Max.Y, ax.Min.Y) || !near(ay.Size().Y, 2*ax.Size().Y) {
+			t.Errorf("y should be on top of x: %v %v", ax, ay)
+		}
+		if !near(bx.Min.Y, ax.Max.Y) {
+			t.Errorf("negative bar should start at zero: %v %v", ax, bx)
+		}
+	})
+
+	t.Run("horizontal", func(t *testing.T) {
+		ops := record(t, newChart(false, true))
+		b := bars(ops)
+		ax, ay, bx := b["a x: 1"], b["a y: 2"], b["b x: -2"]
+		zero := ax.Min.X
+		if ay.Min.X != zero || bx.Max.X != zero {
+			t.Errorf("bars should start at zero %v: %v %v", zero, ay, bx)
+		}
+		if !near(ay.Size().X, 2*ax.Size().X) || !near(bx.Size().X, 2*ax.Size().X) {
+			t.Errorf("widths should be proportional: %v %v %v", ax, ay, bx)
+		}
+		if !(ax.Max.Y < ay.Min.Y && ay.Max.Y < bx.Min.Y) {
+			t.Errorf("categories should go downwards: %v %v %v", ax, ay, bx)
+		}
+		values := labels(ops)
+		if label := text(t, values, "2"); label.X <= ay.Max.X {
+			t.Errorf("label %v should be right of %v", label, ay)
+		}
+		if label := text(t, values, "-2"); label.X >= bx.Min.X {
+			t.Errorf("label %v should be left of %v", label, bx)
+		}
+	})
+
+	t.Run("horizontal stacked", func(t *testing.T) {
+		b := bars(record(t, newChart(true, true)))
+		ax, ay := b["a x: 1"], b["a y: 2"]
+		if !near(ay.Min.X, ax.Max.X) || ax.Min.Y != ay.Min.Y {
+			t.Errorf("y should continue after x: %v %v", ax, ay)
+		}
+	})
+}
+
+// points returns the paths drawn for the points of a scatter plot,
+// skipping legend shapes.
+func points(ops []diagram.Op, legend int) []diagram.Op {
+	return filter(ops, diagram.OpPath, nil)[legend:]
+}
+
+func center(op diagram.Op) diagram.Point {
+	return op.Path.Bounds().UnitLocation(diagram.Point{})
+}
+
+func TestScatter(t *testing.T) {
+	newChart := func() (*plot.Scatter, *plot.ScatterSeries) {
+		chart := plot.NewScatter()
+		series := chart.Add("a", diagram.Points(nil, []float64{1, 3, 2, 5}))
+		return chart, series
+	}
+
+	t.Run("positions", func(t *testing.T) {
+		chart, _ := newChart()
+		ops := record(t, chart)
+		list := points(ops, 1)
+		if len(list) != 4 {
+			t.Fatalf("expected 4 points, got %d", len(list))
+		}
+		p0, p1, p2, p3 := center(list[0]), center(list[1]), center(list[2]), center(list[3])
+		if !near(p1.X-p0.X, p3.X-p2.X) || p1.X <= p0.X {
+			t.Errorf("expected evenly spaced points: %v %v %v %v", p0, p1, p2, p3)
+		}
+		if !near(p0.Y-p1.Y, 2*(p0.Y-p2.Y)) || !near(p0.Y-p3.Y, 4*(p0.Y-p2.Y)) {
+			t.Errorf("expected linear y: %v %v %v %v", p0, p1, p2, p3)
+		}
+		for _, op := range list {
+			if op.Style.Fill != plot.DefaultPalette[0] || !near(op.Path.Bounds().Size().X, 2*chart.MarkerSize) {
+				t.Errorf("invalid default point %v %v", op.Style, op.Path.Bounds())
+			}
+		}
+	})
+
+	t.Run("encodings", func(t *testing.T) {
+		chart, series := newChart()
+		series.Sizes = []float64{1, 2, 3, 4}
+		series.Colors = []float64{4, 3, math.NaN(), 1}
+		list := points(record(t, chart), 1)
+
+		var widths []float64
+		for _, op := range list {
+			widths = append(widths, op.Path.Bounds().Size().X)
+		}
+		if !near(widths[0], 2*chart.MinSize) || !near(widths[3], 2*chart.MaxSize) {
+			t.Errorf("sizes should span the range: %v", widths)
+		}
+		// area is proportional to the value
+		area := func(w float64) float64 { return w * w }
+		if !near(area(widths[1])-area(widths[0]), (area(widths[3])-area(widths[0]))/3) {
+			t.Errorf("expected area encoding: %v", widths)
+		}
+
+		for i, expected := range []color.Color{plot.Viridis(1), plot.Viridis(2.0 / 3), plot.DefaultPalette[0], plot.Viridis(0)} {
+			if fill := list[i].Style.Fill; fill != expected {
+				t.Errorf("point %d: expected %v, got %v", i, expected, fill)
+			}
+		}
+	})
+
+	t.Run("shapes", func(t *testing.T) {
+		chart, series := newChart()
+		series.Shapes = []plot.Shape{plot.ShapeCross, plot.ShapeSquare}
+		list := points(record(t, chart), 1)
+
+		if cross := list[0].Style; cross.Stroke != plot.DefaultPalette[0] || cross.Fill != nil {
+			t.Errorf("cross should be stroked: %+v", cross)
+		}
+		if square := list[1].Path.Commands; len(square) != 5 || list[1].Style.Fill == nil {
+			t.Errorf("expected a filled square, got %v", square)
+		}
+		if circle := list[2].Path.Commands; circle[1].Verb != diagram.PathArcTo {
+			t.Errorf("expected default circle, got %v", circle)
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		chart, _ := newChart()
+		expected := points(record(t, chart), 1)
+
+		chart.Jitter, chart.Seed = 2, 1
+		jittered := points(record(t, chart), 1)
+		moved := false
+		for i := range expected {
+			d := center(jittered[i]).Sub(center(expected[i]))
+			if math.Abs(d.X) > chart.Jitter+1e-6 || math.Abs(d.Y) > chart.Jitter+1e-6 {
+				t.Errorf("point %d moved by %v", i, d)
+			}
+			moved = moved || d != diagram.Point{}
+		}
+		if !moved {
+			t.Errorf("expected jitter to move points")
+		}
+
+		again := points(record(t, chart), 1)
+		for i := range again {
+			if center(again[i]) != center(jittered[i]) {
+				t.Errorf("jitter should be reproducible with the same seed")
+			}
+		}
+	})
+
+	t.Run("trend", func(t *testing.T) {
+		chart := plot.NewScatter()
+		chart.TrendLine = true
+		chart.Add("a", diagram.Ps(0, 1, 1, 3, 2, 5, 3, 7))
+		chart.Add("b", diagram.Ps(1, 2))
+		ops := record(t, chart)
+
+		lines := filter(ops, diagram.OpPoly, func(op diagram.Op) bool { return len(op.Style.Dash) > 0 })
+		if len(lines) != 1 {
+			t.Fatalf("expected a single trend line, got %d", len(lines))
+		}
+		trend := lines[0]
+		if trend.Style.Stroke != plot.DefaultPalette[0] {
+			t.Errorf("trend line should use the series color")
+		}
+
+		list := points(ops, 2)
+		first, last := center(list[0]), center(list[3])
+		start, end := trend.Points[0], trend.Points[len(trend.Points)-1]
+		if math.Abs(start.X-first.X) > 0.01 || math.Abs(start.Y-first.Y) > 0.01 ||
+			math.Abs(end.X-last.X) > 0.01 || math.Abs(end.Y-last.Y) > 0.01 {
+			t.Errorf("trend %v-%v should go through %v and %v", start, end, first, last)
+		}
+
+		equation := filter(ops, diagram.OpText, func(op diagram.Op) bool { return op.Style.Fill == plot.DefaultPalette[0] })
+		if len(equation) != 1 || equation[0].Text != "y = 2x + 1, R^2 = 1" {
+			t.Errorf("invalid equation %v", equation)
+		}
+	})
+}
+
+func TestLinearRegression(t *testing.T) {
+	slope, intercept, r2, ok := plot.LinearRegression(diagram.Ps(0, 1, 1, 3, 2, 5, 3, 7))
+	if !ok || slope != 2 || intercept != 1 || r2 != 1 {
+		t.Errorf("got %v %v %v %v", slope, intercept, r2, ok)
+	}
+
+	slope, intercept, _, ok = plot.LinearRegression(diagram.Ps(0, 0, 1, 1, 2, 0, 3, 1))
+	if !ok || math.Abs(slope-0.2) > 1e-9 || math.Abs(intercept-0.2) > 1e-9 {
+		t.Errorf("got %v %v %v", slope, intercept, ok)
+	}
+
+	if _, _, _, ok := plot.LinearRegression(diagram.Ps(1, 0, 1, 1)); ok {
+		t.Errorf("expected failure for vertical points")
+	}
+}
+
+func TestBins(t *testing.T) {
+	values := []float64{0, 1, 1, 2, 3, 4, math.NaN(), 5}
+
+	bins := plot.Bins(values, plot.FixedWidth(2))
+	counts := []int{3, 2, 2}
+	if len(bins) != len(counts) {
+		t.Fatalf("got %v", bins)
+	}
+	for i, bin := range bins {
+		if bin.Count != counts[i] || bin.Min != float64(i*2) {
+			t.Errorf("bin %d: got %v", i, bin)
+		}
+	}
+
+	// 7 values result in ceil(log2(7)) + 1 = 4 bins
+	if bins := plot.Bins(values, plot.Sturges); len(bins) != 4 {
+		t.Errorf("sturges: got %v", bins)
+	}
+
+	same := plot.Bins([]float64{3, 3, 3}, plot.FreedmanDiaconis)
+	if len(same) != 1 || same[0].Count != 3 {
+		t.Errorf("same values: got %v", same)
+	}
+
+	// bins are widened instead of allocating too many
+	wide := plot.Bins([]float64{0, 1e12}, plot.FixedWidth(1e-3))
+	if len(wide) != plot.MaxBins || wide[0].Count != 1 || wide[len(wide)-1].Count != 1 {
+		t.Errorf("wide range: got %d bins", len(wide))
+	}
+
+	// a small IQR with an outlier asks for millions of bins
+	outlier := []float64{1e9}
+	for i := 0; i < 100; i++ {
+		outlier = append(outlier, float64(i%4)*0.01)
+	}
+	bins = plot.Bins(outlier, plot.FreedmanDiaconis)
+	total := 0
+	for _, bin := range bins {
+		total += bin.Count
+	}
+	if len(bins) > plot.MaxBins || total != len(outlier) {
+		t.Errorf("outlier: got %d bins with %d values", len(bins), total)
+	}
+}
+
+// hinted returns rectangles with a hint in drawing order.
+func hinted(ops []diagram.Op) []diagram.Op {
+	return filter(ops, diagram.OpRect, func(op diagram.Op) bool { return op.Style.Hint != "" })
+}
+
+func TestHistogram(t *testing.T) {
+	newChart := func() *plot.Histogram {
+		chart := plot.NewHistogram([]float64{1, 2, 2, 3, 3, 3, 4, 4, 5, math.NaN()})
+		chart.Binning = plot.FixedWidth(1)
+		return chart
+	}
+	// heights returns bar heights relative to the first bar.
+	heights := func(bars []diagram.Op) []float64 {
+		var result []float64
+		for _, bar := range bars {
+			result = append(result, bar.Rect.Size().Y/bars[0].Rect.Size().Y)
+		}
+		return result
+	}
+	checkHeights := func(t *testing.T, bars []diagram.Op, expected ...float64) {
+		t.Helper()
+		got := heights(bars)
+		if len(got) != len(expected) {
+			t.Fatalf("expected %d bars, got %v", len(expected), got)
+		}
+		for i := range got {
+			if !near(got[i], expected[i]) {
+				t.Errorf("expected relative heights %v, got %v", expected, got)
+				return
+			}
+		}
+	}
+
+	t.Run("counts", func(t *testing.T) {
+		bars := hinted(record(t, newChart()))
+		checkHeights(t, bars, 1, 2, 3, 3)
+		for i, bar := range bars {
+			if bar.Rect.Max.Y != bars[0].Rect.Max.Y {
+				t.Errorf("bars should share the baseline: %v", bar.Rect)
+			}
+			if i > 0 && !near(bar.Rect.Min.X, bars[i-1].Rect.Max.X) {
+				t.Errorf("bars should be adjacent: %v %v", bars[i-1].Rect, bar.Rect)
+			}
+		}
+		if hint := bars[3].Style.Hint; hint != "[4, 5): 3" {
+			t.Errorf("invalid hint %q", hint)
+		}
+	})
+
+	t.Run("cumulative", func(t *testing.T) {
+		chart := newChart()
+		chart.Cumulative = true
+		bars := hinted(record(t, chart))
+		checkHeights(t, bars, 1, 3, 6, 9)
+
+		chart.Density = true
+		bars = hinted(record(t, chart))
+		checkHeights(t, bars, 1, 3, 6, 9)
+		if hint := bars[3].Style.Hint; hint != "[4, 5): 1" {
+			t.Errorf("cumulative density should end at 1, got %q", hint)
+		}
+	})
+
+	t.Run("density", func(t *testing.T) {
+		chart := newChart()
+		chart.Density = true
+		bars := hinted(record(t, chart))
+		checkHeights(t, bars, 1, 2, 3, 3)
+		if hint := bars[0].Style.Hint; hint != "[1, 2): 0.11" {
+			t.Errorf("invalid hint %q", hint)
+		}
+	})
+
+	t.Run("kde", func(t *testing.T) {
+		chart := newChart()
+		chart.KDE = true
+		ops := record(t, chart)
+		bars := hinted(ops)
+		curves := stroked(ops, chart.Curve.Stroke)
+		if len(curves) != 1 {
+			t.Fatalf("expected a single curve, got %d", len(curves))
+		}
+		curve := curves[0]
+
+		baseline := bars[0].Rect.Max.Y
+		if !near(curve[0].X, bars[0].Rect.Min.X) || !near(curve[len(curve)-1].X, bars[3].Rect.Max.X) {
+			t.Errorf("curve should span the bars")
+		}
+
+		// the curve has about the same area as the bars,
+		// except for the tails outside of the bins
+		var curveArea, barArea float64
+		for i := 1; i < len(curve); i++ {
+			curveArea += (curve[i].X - curve[i-1].X) * (2*baseline - curve[i].Y - curve[i-1].Y) / 2
+		}
+		for _, bar := range bars {
+			barArea += bar.Rect.Size().X * bar.Rect.Size().Y
+		}
+		if ratio := curveArea / barArea; ratio < 0.8 || ratio > 1 {
+			t.Errorf("curve area should match bars, got ratio %v", ratio)
+		}
+
+		peak := curve[0]
+		for _, p := range curve {
+			if p.Y < peak.Y {
+				peak = p
+			}
+		}
+		if peak.X < bars[1].Rect.Max.X || peak.X > bars[3].Rect.Min.X {
+			t.Errorf("peak %v should be in the third bin", peak)
+		}
+
+		chart.Cumulative = true
+		ops = record(t, chart)
+		bars = hinted(ops)
+		curve = stroked(ops, chart.Curve.Stroke)[0]
+		for i := 1; i < len(curve); i++ {
+			if curve[i].Y > curve[i-1].Y+1e-9 {
+				t.Fatalf("cumulative curve should not decrease at %v", curve[i])
+			}
+		}
+		top := bars[3].Rect.Min.Y
+		if end := curve[len(curve)-1]; (end.Y-top)/(baseline-top) > 0.1 {
+			t.Errorf("cumulative curve should end near the last bar %v, got %v", top, end)
+		}
+	})
+
+	if bars := hinted(record(t, plot.NewHistogram(nil))); len(bars) != 0 {
+		t.Errorf("empty histogram should not have bars")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summary := plot.Summarize([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 100, math.NaN()})
+	if summary.Count != 10 || summary.Median != 5.5 {
+		t.Errorf("got %+v", summary)
+	}
+	if summary.Q1 != 3.25 || summary.Q3 != 7.75 {
+		t.Errorf("quartiles: got %v %v", summary.Q1, summary.Q3)
+	}
+	if summary.Low != 1 || summary.High != 9 {
+		t.Errorf("whiskers: got %v %v", summary.Low, summary.High)
+	}
+	if len(summary.Outliers) != 1 || summary.Outliers[0] != 100 {
+		t.Errorf("outliers: got %v", summary.Outliers)
+	}
+}
+
+// sampleOps returns operations of the sample called name.
+func sampleOps(ops []diagram.Op, name string) []diagram.Op {
+	var result []diagram.Op
+	for _, op := range ops {
+		if op.Style != nil && strings.HasPrefix(op.Style.Hint, name+"\n") {
+			result = append(result, op)
+		}
+	}
+	return result
+}
+
+func TestBoxPlot(t *testing.T) {
+	box := plot.NewBoxPlot()
+	box.Add("a", []float64{1, 2, 3, 4, 5, 20})
+	box.Add("b", []float64{3, 3, 3})
+	box.Add("empty", nil)
+
+	ops := record(t, box)
+	a := sampleOps(ops, "a")
+	rects := filter(a, diagram.OpRect, nil)
+	lines := filter(a, diagram.OpPoly, nil)
+	if len(rects) != 1 || len(lines) != 5 {
+		t.Fatalf("expected box, 4 whisker lines and median, got %v", a)
+	}
+	r := rects[0].Rect
+	lowWhisker, highWhisker, median := lines[0].Points, lines[1].Points, lines[4].Points
+
+	// q1 = 2.25, median = 3.5, q3 = 4.75, low = 1, high = 5
+	height := r.Size().Y
+	if !near(median[0].Y, (r.Min.Y+r.Max.Y)/2) || median[0].X != r.Min.X || median[1].X != r.Max.X {
+		t.Errorf("median %v should be in the middle of %v", median, r)
+	}
+	if !near(lowWhisker[1].Y, r.Max.Y) || !near(lowWhisker[0].Y-lowWhisker[1].Y, height/2) {
+		t.Errorf("invalid low whisker %v for %v", lowWhisker, r)
+	}
+	if !near(highWhisker[0].Y, r.Min.Y) || !near(highWhisker[0].Y-highWhisker[1].Y, height/10) {
+		t.Errorf("invalid high whisker %v for %v", highWhisker, r)
+	}
+
+	outliers := filter(ops, diagram.OpPoly, func(op diagram.Op) bool { return op.Style.Hint == "20" })
+	if len(outliers) != 1 {
+		t.Fatalf("expected an outlier, got %v", outliers)
+	}
+	if top := outliers[0].Points[0]; !near(top.X, (r.Min.X+r.Max.X)/2) || top.Y >= highWhisker[1].Y {
+		t.Errorf("outlier %v should be above the whisker", top)
+	}
+
+	if b := filter(sampleOps(ops, "b"), diagram.OpRect, nil); len(b) != 1 || b[0].Rect.Size().Y != 0 || b[0].Rect.Min.X <= r.Max.X {
+		t.Errorf("expected flat box right of a, got %v", b)
+	}
+	if empty := sampleOps(ops, "empty"); len(empty) != 0 {
+		t.Errorf("empty sample should not be drawn")
+	}
+
+	box.Horizontal = true
+	horizontal := filter(sampleOps(record(t, box), "a"), diagram.OpPoly, nil)
+	low, high := horizontal[0].Points, horizontal[1].Points
+	if low[0].Y != low[1].Y || !(low[0].X < low[1].X && low[1].X < high[0].X && high[0].X < high[1].X) {
+		t.Errorf("expected whiskers along the x axis, got %v %v", low, high)
+	}
+}
+
+func TestViolinPlot(t *testing.T) {
+	violin := plot.NewViolinPlot()
+	violin.Add("a", []float64{1, 2, 2, 3, 3, 3, 4, 4, 5})
+
+	ops := sampleOps(record(t, violin), "a")
+	outline := filter(ops, diagram.OpPoly, func(op diagram.Op) bool { return op.Style.Fill != nil })
+	if len(outline) != 1 {
+		t.Fatalf("expected an outline, got %v", outline)
+	}
+	points := outline[0].Points
+	if points[0] != points[len(points)-1] {
+		t.Errorf("outline should be closed")
+	}
+
+	// symmetric around the center, widest at the mode
+	n := (len(points) - 1) / 2
+	center := (points[0].X + points[len(points)-2].X) / 2
+	widest := points[0]
+	for k := 0; k < n; k++ {
+		left, right := points[k], points[len(points)-2-k]
+		if !near(left.Y, right.Y) || !near(center-left.X, right.X-center) {
+			t.Fatalf("outline should be symmetric: %v %v", left, right)
+		}
+		if left.X < widest.X {
+			widest = left
+		}
+	}
+	if !(points[n-1].Y < widest.Y && widest.Y < points[0].Y) {
+		t.Errorf("widest point %v should be inside the range", widest)
+	}
+
+	medians := filter(ops, diagram.OpRect, nil)
+	if len(medians) != 1 || !near(medians[0].Rect.UnitLocation(diagram.Point{}).X, center) {
+		t.Errorf("median should be on the center line")
+	}
+}
+
+func TestDistributionLog(t *testing.T) {
+	values := []float64{-1, 0, 1, 10, 100, 1000}
+
+	box := plot.NewBoxPlot()
+	box.Value.Log = true
+	box.Add("a", values)
+	ops := sampleOps(record(t, box), "a")
+	rects := filter(ops, diagram.OpRect, nil)
+	lines := filter(ops, diagram.OpPoly, nil)
+	if len(rects) != 1 || len(lines) != 5 {
+		t.Fatalf("expected a box, got %v", ops)
+	}
+	if hint := rects[0].Style.Hint; !strings.Contains(hint, "n=4 ") || !strings.Contains(hint, "low=1 ") {
+		t.Errorf("non-positive values should be ignored: %q", hint)
+	}
+	// low = 1, q1 = 7.75, q3 = high = 325 on a logarithmic scale
+	low, high := lines[0].Points[0], lines[1].Points[1]
+	r := rects[0].Rect
+	if !near((low.Y-high.Y)/r.Size().Y, math.Log10(325)/(math.Log10(325)-math.Log10(7.75))) {
+		t.Errorf("expected logarithmic scale, got %v %v %v", low, high, r)
+	}
+
+	violin := plot.NewViolinPlot()
+	violin.Value.Log = true
+	violin.Add("a", values)
+	if outline := filter(sampleOps(record(t, violin), "a"), diagram.OpPoly, nil); len(outline) == 0 {
+		t.Errorf("expected a violin")
+	}
+}
+
+// cells returns rectangles by their hint.
+func cells(ops []diagram.Op) map[string]diagram.Op {
+	result := map[string]diagram.Op{}
+	for _, op := range hinted(ops) {
+		result[op.Style.Hint] = op
+	}
+	return result
+}
+
+// luminance returns the perceived brightness of c.
+func luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+func TestHeatmap(t *testing.T) {
+	chart := plot.NewHeatmap([]string{"a"}, []string{"x", "y"})
+	chart.Add("a", "x", 1)
+	chart.Add("a", "x", 2)
+	chart.Add("b", "z", 4)
+	if got := chart.Values[0][0]; got != 3 {
+		t.Errorf("accumulated: got %v", got)
+	}
+	if len(chart.Rows) != 2 || len(chart.Columns) != 3 || len(chart.Values[1]) != 3 {
+		t.Errorf("got %v %v %v", chart.Rows, chart.Columns, chart.Values)
+	}
+	chart.ValueLabels = true
+	ops := record(t, chart)
+
+	c := cells(ops)
+	if len(c) != 6 {
+		t.Fatalf("expected 6 cells, got %v", c)
+	}
+	ax, ay, bx, bz := c["a, x: 3"], c["a, y"], c["b, x"], c["b, z: 4"]
+	if ay.Style.Fill != chart.Missing.Fill || bx.Style.Fill != chart.Missing.Fill {
+		t.Errorf("missing values should use the missing style")
+	}
+	if luminance(bz.Style.Fill) >= luminance(ax.Style.Fill) {
+		t.Errorf("larger values should be darker")
+	}
+
+	// cells form a grid with gaps
+	if !near(ax.Rect.Size().X, bz.Rect.Size().X) || !near(ax.Rect.Size().Y, bz.Rect.Size().Y) || ax.Rect.Min.X != bx.Rect.Min.X || ax.Rect.Min.Y != ay.Rect.Min.Y {
+		t.Errorf("cells should be aligned: %v %v %v", ax.Rect, bx.Rect, ay.Rect)
+	}
+	if gap := ay.Rect.Min.X - ax.Rect.Max.X; !near(gap, chart.Gap) {
+		t.Errorf("expected gap %v, got %v", chart.Gap, gap)
+	}
+	if gap := bx.Rect.Min.Y - ax.Rect.Max.Y; !near(gap, chart.Gap) {
+		t.Errorf("expected gap %v, got %v", chart.Gap, gap)
+	}
+
+	label := filter(labels(ops), diagram.OpText, func(op diagram.Op) bool { return op.Text == "3" })
+	if len(label) != 1 || *label[0].At != ax.Rect.UnitLocation(diagram.Point{}) {
+		t.Errorf("expected label in the center of %v, got %v", ax.Rect, label)
+	}
+
+	// color legend is right of the cells
+	stripes := filter(ops, diagram.OpRect, func(op diagram.Op) bool { return op.Style.Hint == "" })
+	if len(stripes) != 64 || stripes[0].Rect.Min.X <= bz.Rect.Max.X {
+		t.Errorf("expected color legend right of cells")
+	}
+
+	// zero is missing on logarithmic scales
+	chart.Add("b", "y", 0)
+	chart.Legend.Log = true
+	if by := cells(record(t, chart))["b, y"]; by.Style == nil || by.Style.Fill != chart.Missing.Fill {
+		t.Errorf("zero should be missing on logarithmic scale")
+	}
+
+	if c := cells(record(t, plot.NewHeatmap(nil, nil))); len(c) != 0 {
+		t.Errorf("empty heatmap should not have cells")
+	}
+}
+
+func TestCalendarHeatmap(t *testing.T) {
+	chart := plot.NewCalendarHeatmap()
+	start := time.Date(2026, 1, 30, 23, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		chart.Add(start.Add(time.Duration(i)*12*time.Hour), 1)
+	}
+	if got := chart.Values[time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)]; got != 2 {
+		t.Errorf("grouped by day: got %v", got)
+	}
+	ops := record(t, chart)
+
+	c := cells(ops)
+	if len(c) != 31 {
+		t.Fatalf("expected 31 days, got %d", len(c))
+	}
+	friday, saturday, sunday, monday := c["2026-01-30: 1"], c["2026-01-31: 2"], c["2026-02-01: 2"], c["2026-02-02: 2"]
+	last := c["2026-03-01: 1"]
+	if friday.Style == nil || last.Style == nil {
+		t.Fatalf("missing first or last day")
+	}
+	size := friday.Rect.Size()
+	if size.X != size.Y || size.X > chart.MaxCell-chart.Gap {
+		t.Errorf("invalid cell size %v", size)
+	}
+
+	// weeks are columns starting on Monday
+	step := size.X + chart.Gap
+	if saturday.Rect.Min.X != friday.Rect.Min.X || sunday.Rect.Min.X != friday.Rect.Min.X ||
+		!near(saturday.Rect.Min.Y-friday.Rect.Min.Y, step) || !near(sunday.Rect.Min.Y-friday.Rect.Min.Y, 2*step) {
+		t.Errorf("weekend should be below friday: %v %v %v", friday.Rect, saturday.Rect, sunday.Rect)
+	}
+	if !near(monday.Rect.Min.X-friday.Rect.Min.X, step) || !near(friday.Rect.Min.Y-monday.Rect.Min.Y, 4*step) {
+		t.Errorf("monday should start a new column: %v %v", friday.Rect, monday.Rect)
+	}
+	if !near(last.Rect.Min.X-friday.Rect.Min.X, 4*step) {
+		t.Errorf("expected 5 weeks, last day at %v", last.Rect)
+	}
+
+	text := labels(ops)
+	for _, name := range []string{"Mon", "Wed", "Fri", "Sun", "Jan 2026", "Mar"} {
+		if len(filter(text, diagram.OpText, func(op diagram.Op) bool { return op.Text == name })) != 1 {
+			t.Errorf("missing label %q", name)
+		}
+	}
+	if fri := filter(text, diagram.OpText, func(op diagram.Op) bool { return op.Text == "Fri" }); len(fri) == 1 &&
+		!near(fri[0].At.Y, friday.Rect.UnitLocation(diagram.Point{}).Y) {
+		t.Errorf("weekday label should be aligned with the row")
+	}
+
+	if c := cells(record(t, plot.NewCalendarHeatmap())); len(c) != 0 {
+		t.Errorf("empty calendar should not have cells")
+	}
+}
+
+// sector describes the outline of a pie slice.
+type sector struct {
+	start, end diagram.Point
+	// inner is the first point on the inner radius,
+	// or the center for pie slices.
+	inner diagram.Point
+}
+
+// sectorOf returns the corners of the sector drawn by op.
+func sectorOf(op diagram.Op) sector {
+	var s sector
+	commands := op.Path.Commands
+	s.start = commands[0].Points[0]
+	for _, cmd := range commands[1:] {
+		if cmd.Verb == diagram.PathLineTo {
+			s.inner = cmd.Points[0]
+			break
+		}
+		s.end = cmd.Points[0]
+	}
+	return s
+}
+
+// angle returns the direction from center to p, in range [0, 2π).
+func angle(center, p diagram.Point) float64 {
+	a := math.Atan2(p.Y-center.Y, p.X-center.X)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+func TestPieChart(t *testing.T) {
+	chart := plot.NewPieChart()
+	chart.Add("large", 90)
+	chart.Add("small", 1)
+	chart.Add("tiny", 0.5)
+	chart.Add("invalid", math.NaN())
+	ops := record(t, chart)
+
+	slices := filter(ops, diagram.OpPath, nil)
+	if len(slices) != 3 {
+		t.Fatalf("expected 3 slices, got %d", len(slices))
+	}
+	if hint := slices[0].Style.Hint; hint != "large: 90 (98.4%)" {
+		t.Errorf("invalid hint %q", hint)
+	}
+
+	center := sectorOf(slices[0]).inner
+	radius := distance(center, sectorOf(slices[0]).start)
+	first := sectorOf(slices[0]).start
+	if !near(first.X, center.X) || first.Y >= center.Y {
+		t.Errorf("first slice should start at the top: %v %v", center, first)
+	}
+
+	total := 91.5
+	previous := first
+	for i, value := range []float64{90, 1, 0.5} {
+		s := sectorOf(slices[i])
+		if s.inner != center {
+			t.Errorf("slice %d: expected center %v, got %v", i, center, s.inner)
+		}
+		if distance(s.start, previous) > 1e-6 {
+			t.Errorf("slice %d should start where the previous ended: %v %v", i, s.start, previous)
+		}
+		if !near(distance(center, s.end), radius) {
+			t.Errorf("slice %d: end %v is not on the circle", i, s.end)
+		}
+		sweep := math.Mod(angle(center, s.end)-angle(center, s.start)+2*math.Pi, 2*math.Pi)
+		if math.Abs(sweep-2*math.Pi*value/total) > 1e-6 {
+			t.Errorf("slice %d: expected sweep %v, got %v", i, 2*math.Pi*value/total, sweep)
+		}
+		previous = s.end
+	}
+
+	// large slice is labeled inside
+	inside := filter(ops, diagram.OpText, func(op diagram.Op) bool { return op.Text == "large\n98.4%" })
+	if len(inside) != 1 || !near(distance(center, *inside[0].At), radius/2) {
+		t.Errorf("expected label inside the large slice, got %v", inside)
+	}
+
+	// small slices are labeled outside with leader lines
+	leaders := stroked(ops, chart.Leader.Stroke)
+	if len(leaders) != 2 {
+		t.Fatalf("expected 2 leader lines, got %d", len(leaders))
+	}
+	var outside []diagram.Op
+	for _, name := range []string{"small\n1.1%", "tiny\n0.5%"} {
+		label := filter(ops, diagram.OpText, func(op diagram.Op) bool { return op.Text == name })
+		if len(label) != 1 {
+			t.Fatalf("missing label %q", name)
+		}
+		outside = append(outside, label[0])
+
+		var leader []diagram.Point
+		for _, line := range leaders {
+			if line[len(line)-1].Y == label[0].At.Y {
+				leader = line
+			}
+		}
+		if leader == nil {
+			t.Fatalf("missing leader line for %q", name)
+		}
+		edge, end := leader[0], leader[len(leader)-1]
+		if !near(distance(center, edge), radius) {
+			t.Errorf("leader %v should start on the circle", leader)
+		}
+		if !near(math.Abs(end.X-center.X), radius+20) || distance(end, *label[0].At) > chart.Theme.Padding/4+1e-6 {
+			t.Errorf("leader %v should end at the label %v", leader, *label[0].At)
+		}
+		if distance(center, *label[0].At) <= radius {
+			t.Errorf("label %q should be outside of the circle", name)
+		}
+	}
+	height := diagram.DefaultMeasurer.Measure(outside[0].Text, outside[0].Style).Size().Y
+	if math.Abs(outside[0].At.Y-outside[1].At.Y) < height-0.01 {
+		t.Errorf("outside labels overlap: %v %v", *outside[0].At, *outside[1].At)
+	}
+
+	donut := plot.NewDonutChart()
+	donut.Legend = true
+	donut.Add("only", 1)
+	ops = record(t, donut)
+
+	slices = filter(ops, diagram.OpPath, nil)
+	if len(slices) != 1 || slices[0].Style.Stroke != nil {
+		t.Fatalf("expected a single slice without separators, got %v", slices)
+	}
+	bounds := slices[0].Path.Bounds()
+	s := sectorOf(slices[0])
+	outer := bounds.Size().X / 2
+	if !near(distance(bounds.UnitLocation(diagram.Point{}), s.inner), outer*donut.Hole) {
+		t.Errorf("expected hole with radius %v", outer*donut.Hole)
+	}
+	if len(filter(ops, diagram.OpText, func(op diagram.Op) bool { return op.Text == "only" })) != 1 ||
+		len(filter(ops, diagram.OpText, func(op diagram.Op) bool { return op.Text == "100%" })) != 1 {
+		t.Errorf("expected name in the legend and percentage in the slice")
+	}
+
+	if slices := filter(record(t, plot.NewPieChart()), diagram.OpPath, nil); len(slices) != 0 {
+		t.Errorf("empty chart should not have slices")
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	for _, test := range []struct {
+		fraction float64
+		want     string
+	}{
+		{0, "0%"},
+		{0.5, "50%"},
+		{0.1234, "12.3%"},
+		{1, "100%"},
+	} {
+		if got := plot.FormatPercent(test.fraction); got != test.want {
+			t.Errorf("FormatPercent(%v) = %q, want %q", test.fraction, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+	"loov.dev/diagram/scale"
+)
+
+// Shape is the shape of a scatter plot point.
+type Shape string
+
+const (
+	ShapeCircle   Shape = "circle"
+	ShapeSquare   Shape = "square"
+	ShapeTriangle Shape = "triangle"
+	ShapeDiamond  Shape = "diamond"
+	ShapeCross    Shape = "cross"
+	ShapePlus     Shape = "plus"
+)
+
+// stroked returns whether the shape is drawn with lines instead of filled.
+func (shape Shape) stroked() bool { return shape == ShapeCross || shape == ShapePlus }
+
+// Path returns the shape centered at center with the specified radius.
+func (shape Shape) Path(center diagram.Point, radius diagram.Length) *diagram.Path {
+	x, y, r := center.X, center.Y, radius
+	switch shape {
+	case ShapeSquare:
+		r *= 0.85
+		return diagram.NewPath().Polyline(diagram.Ps(x-r, y-r, x+r, y-r, x+r, y+r, x-r, y+r)...).Close()
+	case ShapeTriangle:
+		h := r * math.Sqrt(3) / 2
+		return diagram.NewPath().Polyline(diagram.Ps(x, y-r, x+h, y+r/2, x-h, y+r/2)...).Close()
+	case ShapeDiamond:
+		r *= 1.2
+		return diagram.NewPath().Polyline(diagram.Ps(x, y-r, x+r*0.8, y, x, y+r, x-r*0.8, y)...).Close()
+	case ShapeCross:
+		d := r * 0.8
+		return diagram.NewPath().
+			MoveTo(diagram.P(x-d, y-d)).LineTo(diagram.P(x+d, y+d)).
+			MoveTo(diagram.P(x-d, y+d)).LineTo(diagram.P(x+d, y-d))
+	case ShapePlus:
+		return diagram.NewPath().
+			MoveTo(diagram.P(x-r, y)).LineTo(diagram.P(x+r, y)).
+			MoveTo(diagram.P(x, y-r)).LineTo(diagram.P(x, y+r))
+	default:
+		return diagram.Circle(center, r)
+	}
+}
+
+// ScatterSeries is a named set of points.
+type ScatterSeries struct {
+	Name   string
+	Points []diagram.Point
+
+	// Shape is the shape of points, defaults to ShapeCircle.
+	Shape Shape
+	// Shapes overrides the shape of individual points.
+	Shapes []Shape
+
+	// Sizes encodes a value for each point as the point size.
+	Sizes []float64
+	// Colors encodes a value for each point as the point color,
+	// using Scatter.ColorScale.
+	Colors []float64
+
+	// Style overrides the point style, the default uses palette colors.
+	Style diagram.Style
+}
+
+// Scatter draws series of points on a cartesian plane.
+type Scatter struct {
+	Title  string
+	Series []*ScatterSeries
+
+	X, Y Axis
+
+	// Jitter randomly offsets points by up to Jitter in each direction,
+	// to reveal overlapping points. Seed makes the offsets reproducible.
+	Jitter diagram.Length
+	Seed   int64
+
+	// TrendLine draws the least-squares line with its equation for each series.
+	TrendLine bool
+
+	Theme Theme
+	// MarkerSize is the point radius, when Sizes are not used.
+	MarkerSize diagram.Length
+	// MinSize and MaxSize are the point radius range for Sizes.
+	MinSize, MaxSize diagram.Length
+	// ColorScale is used for Colors, defaults to Viridis.
+	ColorScale ColorScale
+}
+
+// NewScatter creates a scatter plot with the default theme.
+func NewScatter() *Scatter {
+	chart := &Scatter{}
+	chart.Theme = DefaultTheme()
+	chart.MarkerSize = 4
+	chart.MinSize = 2
+	chart.MaxSize = 12
+	chart.ColorScale = Viridis
+	return chart
+}
+
+// Add adds a new series to the chart.
+func (chart *Scatter) Add(name string, points []diagram.Point) *ScatterSeries {
+	series := &ScatterSeries{Name: name, Points: points}
+	chart.Series = append(chart.Series, series)
+	return series
+}
+
+// shape returns the shape of the i-th point, -1 returns the series shape.
+func (series *ScatterSeries) shape(i int) Shape {
+	if i >= 0 && i < len(series.Shapes) && series.Shapes[i] != "" {
+		return series.Shapes[i]
+	}
+	if series.Shape != "" {
+		return series.Shape
+	}
+	return ShapeCircle
+}
+
+// style returns the point style of the i-th series for the specified shape.
+func (chart *Scatter) style(i int, shape Shape, fill color.Color) diagram.Style {
+	series := chart.Series[i]
+	if !series.Style.IsZero() {
+		return series.Style
+	}
+	if fill == nil {
+		fill = chart.Theme.Color(i)
+	}
+	if shape.stroked() {
+		return diagram.Style{Stroke: fill, Size: 1.5}
+	}
+	return diagram.Style{Fill: fill}
+}
+
+// valid returns whether p can be drawn on the chart axes.
+func (chart *Scatter) valid(p diagram.Point) bool {
+	if math.IsNaN(p.X) || math.IsNaN(p.Y) {
+		return false
+	}
+	return !(chart.X.Log && p.X <= 0) && !(chart.Y.Log && p.Y <= 0)
+}
+
+// Draw draws the chart filling the bounds of canvas.
+func (chart *Scatter) Draw(canvas diagram.Canvas) {
+	bounds := chart.Theme.frame(canvas, chart.Title)
+
+	var entries []legendEntry
+	for i, series := range chart.Series {
+		if series.Name != "" {
+			shape := series.shape(-1)
+			entries = append(entries, legendEntry{name: series.Name, style: chart.style(i, shape, nil), shape: shape})
+		}
+	}
+	bounds = chart.Theme.legend(canvas, bounds, entries)
+
+	xs, ys := newExtent(), newExtent()
+	sizes, colors := newExtent(), newExtent()
+	for _, series := range chart.Series {
+		for i, p := range series.Points {
+			if !chart.valid(p) {
+				continue
+			}
+			xs.add(p.X, chart.X.Log)
+			ys.add(p.Y, chart.Y.Log)
+			if i < len(series.Sizes) {
+				sizes.add(series.Sizes[i], false)
+			}
+			if i < len(series.Colors) {
+				colors.add(series.Colors[i], false)
+			}
+		}
+	}
+
+	var x, y scale.Scale
+	area, axes := layoutAxes(bounds, func(area diagram.Rect) []*axis.Axis {
+		xcount := chart.Theme.tickCount(area.Size().X)
+		ycount := chart.Theme.tickCount(area.Size().Y)
+		x = chart.X.scale(xs.min, xs.max, area.Min.X, area.Max.X, xcount)
+		y = chart.Y.scale(ys.min, ys.max, area.Max.Y, area.Min.Y, ycount)
+		return []*axis.Axis{
+			chart.X.axis(axis.Bottom, x, xcount),
+			chart.Y.axis(axis.Left, y, ycount),
+		}
+	})
+	for _, a := range axes {
+		a.Draw(canvas, area)
+	}
+
+	// radius uses the area of the point to encode the value
+	radius := func(v float64) diagram.Length {
+		if sizes.max <= sizes.min {
+			return (chart.MinSize + chart.MaxSize) / 2
+		}
+		t := (v - sizes.min) / (sizes.max - sizes.min)
+		return math.Sqrt(chart.MinSize*chart.MinSize + t*(chart.MaxSize*chart.MaxSize-chart.MinSize*chart.MinSize))
+	}
+	fill := func(v float64) color.Color {
+		t := 0.5
+		if colors.max > colors.min {
+			t = (v - colors.min) / (colors.max - colors.min)
+		}
+		if chart.ColorScale == nil {
+			return Viridis(t)
+		}
+		return chart.ColorScale(t)
+	}
+
+	rng := rand.New(rand.NewSource(chart.Seed))
+	jitter := func() diagram.Length {
+		if chart.Jitter <= 0 {
+			return 0
+		}
+		return (rng.Float64()*2 - 1) * chart.Jitter
+	}
+
+	// points on the edge of the area are drawn fully
+	margin := math.Max(chart.MarkerSize, chart.MaxSize) + chart.Jitter
+	plot := clip(canvas, area.Shrink(diagram.P(-margin, -margin)))
+	trends := canvas.Layer(1)
+	trend := 0
+	for k, series := range chart.Series {
+		for i, p := range series.Points {
+			if !chart.valid(p) {
+				continue
+			}
+			at := diagram.Point{
+				X: x.Position(p.X) + jitter(),
+				Y: y.Position(p.Y) + jitter(),
+			}
+
+			r := chart.MarkerSize
+			if i < len(series.Sizes) && !math.IsNaN(series.Sizes[i]) {
+				r = radius(series.Sizes[i])
+			}
+			var c color.Color
+			if i < len(series.Colors) && !math.IsNaN(series.Colors[i]) {
+				c = fill(series.Colors[i])
+			}
+
+			shape := series.shape(i)
+			style := chart.style(k, shape, c)
+			plot.Path(shape.Path(at, r), &style)
+		}
+
+		if chart.TrendLine && chart.drawTrend(trends, area, k, trend, x, y) {
+			trend++
+		}
+	}
+}
+
+// drawTrend draws the least-squares line of the k-th series,
+// the equation is drawn in the corner of area on the specified line.
+func (chart *Scatter) drawTrend(canvas diagram.Canvas, area diagram.Rect, k, line int, x, y scale.Scale) bool {
+	series := chart.Series[k]
+
+	var points []diagram.Point
+	for _, p := range series.Points {
+		if chart.valid(p) {
+			points = append(points, p)
+		}
+	}
+	slope, intercept, r2, ok := LinearRegression(points)
+	if !ok {
+		return false
+	}
+
+	xs := newExtent()
+	for _, p := range points {
+		xs.add(p.X, chart.X.Log)
+	}
+
+	// sample the line, so it is drawn correctly on logarithmic axes
+	const samples = 32
+	var trend []diagram.Point
+	for i := 0; i <= samples; i++ {
+		vx := xs.min + (xs.max-xs.min)*float64(i)/samples
+		vy := slope*vx + intercept
+		if !chart.valid(diagram.Point{X: vx, Y: vy}) {
+			continue
+		}
+		trend = append(trend, diagram.Point{X: x.Position(vx), Y: y.Position(vy)})
+	}
+	if len(trend) < 2 {
+		return false
+	}
+
+	lineColor := chart.Theme.Color(k)
+	if series.Style.Stroke != nil {
+		lineColor = series.Style.Stroke
+	} else if series.Style.Fill != nil {
+		lineColor = series.Style.Fill
+	}
+	clip(canvas, area).Poly(trend, &diagram.Style{
+		Stroke: lineColor,
+		Size:   1.5,
+		Dash:   []diagram.Length{6, 3},
+	})
+
+	equation := "y = " + FormatValue(slope) + "x"
+	if intercept < 0 {
+		equation += " - " + FormatValue(-intercept)
+	} else {
+		equation += " + " + FormatValue(intercept)
+	}
+	equation += ", R^2 = " + FormatValue(r2)
+
+	style := chart.Theme.Label
+	style.Fill = lineColor
+	style.Origin = diagram.P(1, -1)
+	at := diagram.P(area.Max.X-4, area.Min.Y+4+float64(line)*style.Size*1.4)
+	canvas.Text(equation, at, &style)
+	return true
+}
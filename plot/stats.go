@@ -0,0 +1,152 @@
+package plot
+
+import (
+	"math"
+	"sort"
+
+	"loov.dev/diagram"
+)
+
+// LinearRegression computes the least-squares line y = slope*x + intercept,
+// r2 is the coefficient of determination.
+//
+// Points with NaN coordinates are ignored, ok is false when
+// there are less than two distinct x values.
+func LinearRegression(points []diagram.Point) (slope, intercept, r2 float64, ok bool) {
+	var n, sx, sy float64
+	for _, p := range points {
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) {
+			continue
+		}
+		n++
+		sx += p.X
+		sy += p.Y
+	}
+	if n < 2 {
+		return 0, 0, 0, false
+	}
+
+	mx, my := sx/n, sy/n
+	var sxx, sxy, syy float64
+	for _, p := range points {
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) {
+			continue
+		}
+		dx, dy := p.X-mx, p.Y-my
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return 0, 0, 0, false
+	}
+
+	slope = sxy / sxx
+	intercept = my - slope*mx
+	if syy == 0 {
+		r2 = 1
+	} else {
+		r2 = sxy * sxy / (sxx * syy)
+	}
+	return slope, intercept, r2, true
+}
+
+// Quantile returns the q-th quantile of sorted values,
+// using linear interpolation between closest ranks.
+func Quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return sorted[0]
+	}
+	if q >= 1 {
+		return sorted[len(sorted)-1]
+	}
+
+	p := q * float64(len(sorted)-1)
+	i := int(p)
+	f := p - float64(i)
+	if i+1 >= len(sorted) {
+		return sorted[i]
+	}
+	return sorted[i] + (sorted[i+1]-sorted[i])*f
+}
+
+// finite returns sorted values without NaN and infinities.
+func finite(values []float64) []float64 {
+	result := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			result = append(result, v)
+		}
+	}
+	sort.Float64s(result)
+	return result
+}
+
+// SilvermanBandwidth returns the rule-of-thumb bandwidth
+// for a gaussian kernel density estimate of values.
+func SilvermanBandwidth(values []float64) float64 {
+	sorted := finite(values)
+	n := float64(len(sorted))
+	if n < 2 {
+		return 1
+	}
+
+	var mean, variance float64
+	for _, v := range sorted {
+		mean += v
+	}
+	mean /= n
+	for _, v := range sorted {
+		variance += (v - mean) * (v - mean)
+	}
+	deviation := math.Sqrt(variance / (n - 1))
+
+	iqr := Quantile(sorted, 0.75) - Quantile(sorted, 0.25)
+	spread := deviation
+	if iqr > 0 && iqr/1.34 < spread {
+		spread = iqr / 1.34
+	}
+	if spread == 0 {
+		return 1
+	}
+	return 0.9 * spread * math.Pow(n, -0.2)
+}
+
+// KDE returns the gaussian kernel density estimate of values,
+// bandwidth 0 uses SilvermanBandwidth.
+func KDE(values []float64, bandwidth float64) func(x float64) float64 {
+	sorted := finite(values)
+	if bandwidth <= 0 {
+		bandwidth = SilvermanBandwidth(sorted)
+	}
+	norm := 1 / (float64(len(sorted)) * bandwidth * math.Sqrt(2*math.Pi))
+	return func(x float64) float64 {
+		if len(sorted) == 0 {
+			return 0
+		}
+		total := 0.0
+		for _, v := range sorted {
+			u := (x - v) / bandwidth
+			total += math.Exp(-u * u / 2)
+		}
+		return total * norm
+	}
+}
+
+// kdeCumulative returns the cumulative distribution of the
+// gaussian kernel density estimate of sorted values.
+func kdeCumulative(sorted []float64, bandwidth float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		if len(sorted) == 0 {
+			return 0
+		}
+		total := 0.0
+		for _, v := range sorted {
+			total += 0.5 * math.Erfc(-(x-v)/(bandwidth*math.Sqrt2))
+		}
+		return total / float64(len(sorted))
+	}
+}
@@ -0,0 +1,319 @@
+package diagram
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// rasterizer accumulates anti-aliased coverage of polygons.
+//
+// The accumulation approach is the same as used by font rasterizers:
+// each line segment adds signed area to the cells it crosses and
+// the coverage is computed by a running sum along a row.
+type rasterizer struct {
+	width, height int
+	stride        int
+	area          []float32
+	dirty         image.Rectangle
+
+	// transform is applied to all added polygons
+	transform Affine
+}
+
+func newRasterizer(width, height int) *rasterizer {
+	z := &rasterizer{}
+	z.width, z.height = width, height
+	z.stride = width + 2
+	z.area = make([]float32, z.stride*height)
+	z.transform = Identity()
+	return z
+}
+
+// polygon adds a closed polygon.
+func (z *rasterizer) polygon(points []Point) {
+	if len(points) < 2 {
+		return
+	}
+	prev := z.transform.Apply(points[len(points)-1])
+	for _, p := range points {
+		p = z.transform.Apply(p)
+		z.line(prev, p)
+		prev = p
+	}
+}
+
+func (z *rasterizer) line(p0, p1 Point) {
+	if p0.Y == p1.Y {
+		return
+	}
+
+	dir := float32(1)
+	if p0.Y > p1.Y {
+		dir = -1
+		p0, p1 = p1, p0
+	}
+
+	width := float64(z.width)
+	clampx := func(x float64) float64 {
+		if x < 0 {
+			return 0
+		}
+		if x > width {
+			return width
+		}
+		return x
+	}
+
+	z.dirty = z.dirty.Union(image.Rect(
+		int(math.Floor(math.Min(p0.X, p1.X))), int(math.Floor(p0.Y)),
+		int(math.Ceil(math.Max(p0.X, p1.X)))+1, int(math.Ceil(p1.Y)),
+	))
+	z.dirty.Min.X = 0
+
+	dxdy := (p1.X - p0.X) / (p1.Y - p0.Y)
+	x := p0.X
+	y0 := 0
+	if p0.Y < 0 {
+		x -= p0.Y * dxdy
+	} else {
+		y0 = int(p0.Y)
+	}
+	y1 := int(math.Ceil(p1.Y))
+	if y1 > z.height {
+		y1 = z.height
+	}
+
+	for y := y0; y < y1; y++ {
+		row := z.area[y*z.stride : (y+1)*z.stride]
+
+		dy := math.Min(float64(y+1), p1.Y) - math.Max(float64(y), p0.Y)
+		xnext := x + dxdy*dy
+		d := float32(dy) * dir
+
+		x0, x1 := clampx(x), clampx(xnext)
+		if x0 > x1 {
+			x0, x1 = x1, x0
+		}
+
+		x0floor := math.Floor(x0)
+		x0i := int(x0floor)
+		x1ceil := math.Ceil(x1)
+		x1i := int(x1ceil)
+
+		if x1i <= x0i+1 {
+			xmf := float32(0.5*(x0+x1) - x0floor)
+			row[x0i] += d - d*xmf
+			row[x0i+1] += d * xmf
+		} else {
+			s := float32(1 / (x1 - x0))
+			x0f := float32(x0 - x0floor)
+			a0 := 0.5 * s * (1 - x0f) * (1 - x0f)
+			x1f := float32(x1 - x1ceil + 1)
+			am := 0.5 * s * x1f * x1f
+
+			row[x0i] += d * a0
+			if x1i == x0i+2 {
+				row[x0i+1] += d * (1 - a0 - am)
+			} else {
+				a1 := s * (1.5 - x0f)
+				row[x0i+1] += d * (a1 - a0)
+				for xi := x0i + 2; xi < x1i-1; xi++ {
+					row[xi] += d * s
+				}
+				a2 := a1 + float32(x1i-x0i-3)*s
+				row[x1i-1] += d * (1 - a2 - am)
+			}
+			row[x1i] += d * am
+		}
+
+		x = xnext
+	}
+}
+
+// bounds returns the area touched since the last reset.
+func (z *rasterizer) bounds() image.Rectangle {
+	return z.dirty.Intersect(image.Rect(0, 0, z.width, z.height))
+}
+
+// mask converts accumulated coverage into an alpha mask and
+// resets the rasterizer. When clip is not nil, the coverage is
+// multiplied with it.
+func (z *rasterizer) mask(clip *image.Alpha) *image.Alpha {
+	bounds := z.bounds()
+	mask := image.NewAlpha(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := z.area[y*z.stride : (y+1)*z.stride]
+
+		var acc float32
+		for x := 0; x < bounds.Max.X; x++ {
+			acc += row[x]
+			if x < bounds.Min.X {
+				continue
+			}
+			a := acc
+			if a < 0 {
+				a = -a
+			}
+			if a > 1 {
+				a = 1
+			}
+			v := uint32(a*0xFF + 0.5)
+			if clip != nil {
+				v = (v*uint32(clip.AlphaAt(x, y).A) + 0x7F) / 0xFF
+			}
+			mask.Pix[mask.PixOffset(x, y)] = uint8(v)
+		}
+		for x := range row {
+			row[x] = 0
+		}
+	}
+	z.dirty = image.Rectangle{}
+	return mask
+}
+
+// draw composites the accumulated coverage onto dst.
+func (z *rasterizer) draw(dst draw.Image, src color.Color, clip *image.Alpha) {
+	mask := z.mask(clip)
+	if mask.Rect.Empty() {
+		return
+	}
+	draw.DrawMask(dst, mask.Rect, image.NewUniform(src), image.Point{}, mask, mask.Rect.Min, draw.Over)
+}
+
+// flattenTolerance is the maximum distance in pixels between
+// a curve and its flattened approximation.
+const flattenTolerance = 0.2
+
+// circlePolygon approximates a circle with a polygon.
+func circlePolygon(center Point, radius Length) []Point {
+	n := int(math.Ceil(math.Pi / math.Acos(1-flattenTolerance/math.Max(radius, flattenTolerance))))
+	if n < 8 {
+		n = 8
+	}
+	points := make([]Point, n)
+	for i := range points {
+		sn, cs := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		points[i] = Point{center.X + cs*radius, center.Y + sn*radius}
+	}
+	return points
+}
+
+// polygonArea returns the signed area of the polygon.
+func polygonArea(points []Point) float64 {
+	area := 0.0
+	prev := points[len(points)-1]
+	for _, p := range points {
+		area += prev.X*p.Y - p.X*prev.Y
+		prev = p
+	}
+	return area * 0.5
+}
+
+// orient makes all polygons have the same winding,
+// so that overlapping pieces do not cancel out.
+func orient(polygons [][]Point) [][]Point {
+	for _, poly := range polygons {
+		if len(poly) > 2 && polygonArea(poly) < 0 {
+			for i, k := 0, len(poly)-1; i < k; i, k = i+1, k-1 {
+				poly[i], poly[k] = poly[k], poly[i]
+			}
+		}
+	}
+	return polygons
+}
+
+// strokePolyline converts a polyline into polygons covering its stroke.
+// Segments use butt caps and joins are rounded.
+func strokePolyline(points []Point, width Length, closed bool) [][]Point {
+	radius := width * 0.5
+
+	var polygons [][]Point
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		d := b.Sub(a)
+		n := math.Hypot(d.X, d.Y)
+		if n == 0 {
+			continue
+		}
+		off := Point{-d.Y / n * radius, d.X / n * radius}
+		polygons = append(polygons, []Point{
+			a.Add(off), b.Add(off),
+			b.Sub(off), a.Sub(off),
+		})
+	}
+
+	joins := points
+	if !closed && len(joins) > 2 {
+		joins = joins[1 : len(joins)-1]
+	} else if !closed {
+		joins = nil
+	}
+	if radius > 0.5 {
+		for _, p := range joins {
+			polygons = append(polygons, circlePolygon(p, radius))
+		}
+	}
+
+	return orient(polygons)
+}
+
+// dashPolyline splits a polyline into dashes.
+func dashPolyline(points []Point, dash []Length, offset Length) [][]Point {
+	total := 0.0
+	for _, v := range dash {
+		total += v
+	}
+	if total <= 0 || len(points) < 2 {
+		return [][]Point{points}
+	}
+	if len(dash)%2 == 1 {
+		dash = append(append([]Length{}, dash...), dash...)
+	}
+
+	index := 0
+	offset = math.Mod(offset, total)
+	if offset < 0 {
+		offset += total
+	}
+	for offset >= dash[index] {
+		offset -= dash[index]
+		index = (index + 1) % len(dash)
+	}
+	remaining := dash[index] - offset
+
+	var dashes [][]Point
+	var current []Point
+	if index%2 == 0 {
+		current = []Point{points[0]}
+	}
+
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		d := b.Sub(a)
+		n := math.Hypot(d.X, d.Y)
+		at := 0.0
+		for n-at > remaining {
+			at += remaining
+			p := a.Add(d.Scale(at / n))
+			if index%2 == 0 {
+				dashes = append(dashes, append(current, p))
+				current = nil
+			} else {
+				current = []Point{p}
+			}
+			index = (index + 1) % len(dash)
+			remaining = dash[index]
+		}
+		remaining -= n - at
+		if index%2 == 0 {
+			current = append(current, b)
+		}
+	}
+	if len(current) > 1 {
+		dashes = append(dashes, current)
+	}
+
+	return dashes
+}
@@ -0,0 +1,204 @@
+package diagram
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Recorder is a Canvas that records drawing operations.
+//
+// The recording can be replayed onto any other Canvas,
+// serialized as JSON and compared with other recordings.
+type Recorder struct {
+	// Order is the layer index.
+	Order int `json:",omitempty"`
+	// Clipped indicates that the drawing is clipped to Area.
+	Clipped bool `json:",omitempty"`
+	// Area is the bounds in local coordinates.
+	Area Rect
+
+	Ops    []Op        `json:",omitempty"`
+	Layers []*Recorder `json:",omitempty"`
+}
+
+// OpKind is the type of a recorded operation.
+type OpKind string
+
+const (
+	OpText    OpKind = "text"
+	OpPoly    OpKind = "poly"
+	OpRect    OpKind = "rect"
+	OpPath    OpKind = "path"
+	OpContext OpKind = "context"
+)
+
+// Op is a single recorded drawing operation.
+type Op struct {
+	Kind OpKind
+
+	// OpText
+	Text string `json:",omitempty"`
+	At   *Point `json:",omitempty"`
+	// OpPoly
+	Points []Point `json:",omitempty"`
+	// OpRect and OpContext created with Context or Clip
+	Rect *Rect `json:",omitempty"`
+	// OpPath
+	Path *Path `json:",omitempty"`
+
+	Style *Style `json:",omitempty"`
+
+	// OpContext
+	Context *Recorder `json:",omitempty"`
+	// OpContext created with Transform
+	Transform *Affine `json:",omitempty"`
+}
+
+func NewRecorder(width, height Length) *Recorder {
+	rec := &Recorder{}
+	rec.Area.Max.X = width
+	rec.Area.Max.Y = height
+	return rec
+}
+
+func (rec *Recorder) SetSize(width, height Length) {
+	rec.Area.Max.X = width
+	rec.Area.Max.Y = height
+}
+
+func (rec *Recorder) Bounds() Rect { return rec.Area }
+func (rec *Recorder) Size() Point  { return rec.Area.Size() }
+
+func (rec *Recorder) context(r Rect, clip bool) Canvas {
+	context := &Recorder{}
+	context.Clipped = clip
+	context.Area = r.Zero()
+	rec.Ops = append(rec.Ops, Op{
+		Kind:    OpContext,
+		Rect:    &r,
+		Context: context,
+	})
+	return context
+}
+
+func (rec *Recorder) Context(r Rect) Canvas { return rec.context(r, false) }
+func (rec *Recorder) Clip(r Rect) Canvas    { return rec.context(r, true) }
+
+func (rec *Recorder) Transform(m Affine) Canvas {
+	m = m.orIdentity()
+	inv, _ := m.Invert()
+	context := &Recorder{}
+	context.Area = inv.ApplyRect(rec.Area)
+	rec.Ops = append(rec.Ops, Op{
+		Kind:      OpContext,
+		Transform: &m,
+		Context:   context,
+	})
+	return context
+}
+
+func (rec *Recorder) Layer(index int) Canvas {
+	if index == 0 {
+		return rec
+	}
+
+	i := sort.Search(len(rec.Layers), func(i int) bool {
+		return rec.Layers[i].Order > index
+	})
+	if i > 0 && rec.Layers[i-1].Order == index {
+		return rec.Layers[i-1]
+	}
+
+	layer := &Recorder{}
+	layer.Order = index
+	layer.Area = rec.Area
+
+	rec.Layers = append(rec.Layers, layer)
+	copy(rec.Layers[i+1:], rec.Layers[i:])
+	rec.Layers[i] = layer
+	return layer
+}
+
+func (rec *Recorder) Text(text string, at Point, style *Style) {
+	style.mustExist()
+	copy := *style
+	rec.Ops = append(rec.Ops, Op{
+		Kind:  OpText,
+		Text:  text,
+		At:    &at,
+		Style: &copy,
+	})
+}
+
+func (rec *Recorder) Poly(points []Point, style *Style) {
+	style.mustExist()
+	copy := *style
+	rec.Ops = append(rec.Ops, Op{
+		Kind:   OpPoly,
+		Points: append([]Point{}, points...),
+		Style:  &copy,
+	})
+}
+
+func (rec *Recorder) Rect(r Rect, style *Style) {
+	style.mustExist()
+	copy := *style
+	rec.Ops = append(rec.Ops, Op{
+		Kind:  OpRect,
+		Rect:  &r,
+		Style: &copy,
+	})
+}
+
+func (rec *Recorder) Path(path *Path, style *Style) {
+	style.mustExist()
+	if path == nil {
+		return
+	}
+	copy := *style
+	rec.Ops = append(rec.Ops, Op{
+		Kind:  OpPath,
+		Path:  path.clone(),
+		Style: &copy,
+	})
+}
+
+// Replay draws the recorded operations onto dst.
+func (rec *Recorder) Replay(dst Canvas) {
+	for i := range rec.Ops {
+		op := &rec.Ops[i]
+		switch op.Kind {
+		case OpText:
+			dst.Text(op.Text, *op.At, op.Style)
+		case OpPoly:
+			dst.Poly(op.Points, op.Style)
+		case OpRect:
+			dst.Rect(*op.Rect, op.Style)
+		case OpPath:
+			dst.Path(op.Path, op.Style)
+		case OpContext:
+			switch {
+			case op.Transform != nil:
+				op.Context.Replay(dst.Transform(*op.Transform))
+			case op.Context.Clipped:
+				op.Context.Replay(dst.Clip(*op.Rect))
+			default:
+				op.Context.Replay(dst.Context(*op.Rect))
+			}
+		}
+	}
+
+	for _, layer := range rec.Layers {
+		layer.Replay(dst.Layer(layer.Order))
+	}
+}
+
+// Equal checks whether two recordings contain the same operations.
+//
+// Colors are compared by their value rather than their type.
+func (rec *Recorder) Equal(other *Recorder) bool {
+	a, erra := json.Marshal(rec)
+	b, errb := json.Marshal(other)
+	return erra == nil && errb == nil && bytes.Equal(a, b)
+}
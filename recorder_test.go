@@ -0,0 +1,97 @@
+package diagram_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+func draw(canvas diagram.Canvas) {
+	canvas.Layer(-1).Rect(canvas.Bounds(), &diagram.Style{Fill: color.Gray{0xF0}})
+	canvas.Poly(diagram.Ps(0, 0, 10, 10, 20, 0), &diagram.Style{
+		Stroke: color.RGBA{R: 0xFF, A: 0xFF},
+		Size:   2,
+		Dash:   []diagram.Length{4, 2},
+	})
+
+	clip := canvas.Clip(diagram.R(10, 10, 50, 30))
+	clip.Text("hello", diagram.P(20, 10), &diagram.Style{
+		Fill:   color.NRGBA{0, 0, 0x80, 0x80},
+		Size:   12,
+		Origin: diagram.P(0, 0),
+		Hint:   "greeting",
+	})
+
+	canvas.Layer(2).Context(diagram.R(5, 5, 15, 15)).Rect(diagram.R(0, 0, 5, 5), &diagram.Style{Stroke: color.Black})
+	canvas.Layer(1).Path(diagram.RoundedRect(diagram.R(0, 0, 20, 10), 3), &diagram.Style{Fill: color.White})
+	rotated := canvas.Transform(diagram.RotateAround(diagram.P(50, 25), 0.3))
+	rotated.Clip(diagram.R(40, 20, 60, 30)).Rect(rotated.Bounds(), &diagram.Style{Fill: color.Black})
+	canvas.Layer(1).Text("middle", diagram.P(1, 1), &diagram.Style{})
+}
+
+func TestRecorderReplay(t *testing.T) {
+	rec := diagram.NewRecorder(100, 50)
+	draw(rec)
+
+	direct := diagram.NewSVG(100, 50)
+	draw(direct)
+
+	replayed := diagram.NewSVG(100, 50)
+	rec.Replay(replayed)
+
+	if !bytes.Equal(direct.Bytes(), replayed.Bytes()) {
+		t.Errorf("replay differs:\n%s\n%s", direct.Bytes(), replayed.Bytes())
+	}
+
+	again := diagram.NewRecorder(100, 50)
+	rec.Replay(again)
+	if !rec.Equal(again) {
+		t.Errorf("replay onto recorder differs")
+	}
+}
+
+func TestRecorderJSON(t *testing.T) {
+	rec := diagram.NewRecorder(100, 50)
+	draw(rec)
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded diagram.Recorder
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !rec.Equal(&decoded) {
+		t.Errorf("decoded recording differs")
+	}
+
+	direct := diagram.NewSVG(100, 50)
+	rec.Replay(direct)
+	replayed := diagram.NewSVG(100, 50)
+	decoded.Replay(replayed)
+	if !bytes.Equal(direct.Bytes(), replayed.Bytes()) {
+		t.Errorf("replay of decoded differs:\n%s\n%s", direct.Bytes(), replayed.Bytes())
+	}
+}
+
+func TestRecorderEqual(t *testing.T) {
+	a := diagram.NewRecorder(100, 50)
+	a.Rect(diagram.R(0, 0, 10, 10), &diagram.Style{Fill: color.Gray{0xFF}})
+
+	b := diagram.NewRecorder(100, 50)
+	b.Rect(diagram.R(0, 0, 10, 10), &diagram.Style{Fill: color.White})
+	if !a.Equal(b) {
+		t.Errorf("expected equal recordings")
+	}
+
+	b.Layer(1).Rect(diagram.R(0, 0, 10, 10), &diagram.Style{})
+	if a.Equal(b) {
+		t.Errorf("expected different recordings")
+	}
+}
@@ -0,0 +1,192 @@
+package scale
+
+import (
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+)
+
+// Band splits range [From, To] into uniform bands for each domain value.
+//
+// Band scales are typically used for bar charts.
+type Band struct {
+	Domain   []string
+	From, To diagram.Length
+
+	// PaddingInner is the space between bands as a fraction of step.
+	PaddingInner float64
+	// PaddingOuter is the space before the first and after the last band
+	// as a fraction of step.
+	PaddingOuter float64
+	// Align distributes the outer space, 0 places bands at the start of
+	// the range, 0.5 centers them and 1 places them at the end.
+	Align float64
+}
+
+// NewBand creates a band scale with centered bands.
+func NewBand(domain []string, from, to diagram.Length) *Band {
+	return &Band{
+		Domain: domain,
+		From:   from, To: to,
+		Align: 0.5,
+	}
+}
+
+// Index returns the index of value in domain or -1 when missing.
+func (s *Band) Index(value string) int {
+	return indexOf(s.Domain, value)
+}
+
+// Step returns the distance between starts of adjacent bands.
+func (s *Band) Step() diagram.Length {
+	n := float64(len(s.Domain)) - s.PaddingInner + 2*s.PaddingOuter
+	return math.Abs(s.To-s.From) / math.Max(1, n)
+}
+
+// Bandwidth returns the width of a single band.
+func (s *Band) Bandwidth() diagram.Length {
+	return s.Step() * (1 - s.PaddingInner)
+}
+
+// MapIndex returns the start of the i-th band.
+func (s *Band) MapIndex(i int) diagram.Length {
+	n := len(s.Domain)
+	lo, hi := s.From, s.To
+	if lo > hi {
+		lo, hi = hi, lo
+		i = n - 1 - i
+	}
+
+	step := s.Step()
+	start := lo + (hi-lo-step*(float64(n)-s.PaddingInner))*s.Align
+	return start + float64(i)*step
+}
+
+// Map returns the start of the band for value,
+// values outside of domain return NaN.
+func (s *Band) Map(value string) diagram.Length {
+	i := s.Index(value)
+	if i < 0 {
+		return math.NaN()
+	}
+	return s.MapIndex(i)
+}
+
+// Invert returns the domain value whose band contains p,
+// returns "" when p is in the padding or outside of the range.
+func (s *Band) Invert(p diagram.Length) string {
+	bandwidth := s.Bandwidth()
+	for i, value := range s.Domain {
+		start := s.MapIndex(i)
+		if p >= start && p <= start+bandwidth {
+			return value
+		}
+	}
+	return ""
+}
+
+// Ticks returns a tick for each domain value at its index,
+// count is ignored.
+func (s *Band) Ticks(count int) []axis.Tick {
+	return categoryTicks(s.Domain)
+}
+
+// Position returns the center of band at the index value.
+func (s *Band) Position(value float64) diagram.Length {
+	return s.MapIndex(int(value)) + s.Bandwidth()/2
+}
+
+// Ordinal maps domain values to range values by index,
+// the range is repeated when it is shorter than the domain.
+type Ordinal struct {
+	Domain []string
+	Range  []diagram.Length
+}
+
+// NewOrdinal creates an ordinal scale with an explicit range.
+func NewOrdinal(domain []string, values []diagram.Length) *Ordinal {
+	return &Ordinal{Domain: domain, Range: values}
+}
+
+// NewPoints creates an ordinal scale, which distributes domain
+// values uniformly over [from, to]. padding is the space before the
+// first and after the last point as a fraction of the distance
+// between points.
+func NewPoints(domain []string, from, to diagram.Length, padding float64) *Ordinal {
+	s := &Ordinal{Domain: domain}
+	n := float64(len(domain)) - 1 + 2*padding
+	step := (to - from) / math.Max(1, n)
+	if len(domain) == 1 {
+		from += (to - from - step*(2*padding)) / 2
+	}
+	for i := range domain {
+		s.Range = append(s.Range, from+step*(padding+float64(i)))
+	}
+	return s
+}
+
+// Index returns the index of value in domain or -1 when missing.
+func (s *Ordinal) Index(value string) int {
+	return indexOf(s.Domain, value)
+}
+
+// MapIndex returns the range value for the i-th domain value,
+// the range repeats in both directions.
+func (s *Ordinal) MapIndex(i int) diagram.Length {
+	n := len(s.Range)
+	if n == 0 {
+		return math.NaN()
+	}
+	return s.Range[(i%n+n)%n]
+}
+
+// Map returns the range value for value,
+// values outside of domain return NaN.
+func (s *Ordinal) Map(value string) diagram.Length {
+	i := s.Index(value)
+	if i < 0 {
+		return math.NaN()
+	}
+	return s.MapIndex(i)
+}
+
+// Invert returns the domain value mapped closest to p.
+func (s *Ordinal) Invert(p diagram.Length) string {
+	best, bestDistance := "", math.Inf(1)
+	for i, value := range s.Domain {
+		distance := math.Abs(s.MapIndex(i) - p)
+		if distance < bestDistance {
+			best, bestDistance = value, distance
+		}
+	}
+	return best
+}
+
+// Ticks returns a tick for each domain value at its index,
+// count is ignored.
+func (s *Ordinal) Ticks(count int) []axis.Tick {
+	return categoryTicks(s.Domain)
+}
+
+// Position returns the range value at the index value.
+func (s *Ordinal) Position(value float64) diagram.Length {
+	return s.MapIndex(int(value))
+}
+
+func indexOf(domain []string, value string) int {
+	for i, v := range domain {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func categoryTicks(domain []string) []axis.Tick {
+	ticks := make([]axis.Tick, len(domain))
+	for i, value := range domain {
+		ticks[i] = axis.Tick{Value: float64(i), Label: value}
+	}
+	return ticks
+}
@@ -0,0 +1,125 @@
+package scale
+
+import (
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+)
+
+// Linear maps domain [Min, Max] linearly to range [From, To].
+type Linear struct {
+	Min, Max float64
+	From, To diagram.Length
+	// Clamp limits results to the range and inverted values to the domain.
+	Clamp bool
+}
+
+// NewLinear creates a linear scale.
+func NewLinear(min, max float64, from, to diagram.Length) *Linear {
+	return &Linear{
+		Min: min, Max: max,
+		From: from, To: to,
+	}
+}
+
+// Map returns the position of v.
+func (s *Linear) Map(v float64) diagram.Length {
+	if s.Clamp {
+		v = clamp(v, s.Min, s.Max)
+	}
+	return lerp(v, s.Min, s.Max, s.From, s.To)
+}
+
+// Invert returns the value at position p.
+func (s *Linear) Invert(p diagram.Length) float64 {
+	if s.Clamp {
+		p = clamp(p, s.From, s.To)
+	}
+	return lerp(p, s.From, s.To, s.Min, s.Max)
+}
+
+// Nice extends the domain to nice round values,
+// for approximately count ticks.
+func (s *Linear) Nice(count int) *Linear {
+	if s.Min <= s.Max {
+		s.Min, s.Max, _ = axis.Nice(s.Min, s.Max, count)
+	} else {
+		s.Max, s.Min, _ = axis.Nice(s.Max, s.Min, count)
+	}
+	return s
+}
+
+// Ticks returns approximately count ticks for the domain.
+func (s *Linear) Ticks(count int) []axis.Tick {
+	return axis.Linear(math.Min(s.Min, s.Max), math.Max(s.Min, s.Max), count)
+}
+
+// Position implements Scale.
+func (s *Linear) Position(value float64) diagram.Length { return s.Map(value) }
+
+// Log maps domain [Min, Max] logarithmically to range [From, To].
+//
+// The domain must be positive.
+type Log struct {
+	Min, Max float64
+	From, To diagram.Length
+	// Base is used for ticks and nice domains, defaults to 10.
+	Base float64
+	// Clamp limits results to the range and inverted values to the domain.
+	Clamp bool
+}
+
+// NewLog creates a base 10 logarithmic scale.
+func NewLog(min, max float64, from, to diagram.Length) *Log {
+	return &Log{
+		Min: min, Max: max,
+		From: from, To: to,
+		Base: 10,
+	}
+}
+
+func (s *Log) base() float64 {
+	if s.Base <= 1 {
+		return 10
+	}
+	return s.Base
+}
+
+// Map returns the position of v.
+func (s *Log) Map(v float64) diagram.Length {
+	if s.Clamp {
+		v = clamp(v, s.Min, s.Max)
+	}
+	return lerp(math.Log(v), math.Log(s.Min), math.Log(s.Max), s.From, s.To)
+}
+
+// Invert returns the value at position p.
+func (s *Log) Invert(p diagram.Length) float64 {
+	if s.Clamp {
+		p = clamp(p, s.From, s.To)
+	}
+	return math.Exp(lerp(p, s.From, s.To, math.Log(s.Min), math.Log(s.Max)))
+}
+
+// Nice extends the domain to powers of base.
+func (s *Log) Nice() *Log {
+	base := s.base()
+	floor := func(v float64) float64 { return math.Pow(base, math.Floor(math.Log(v)/math.Log(base)+1e-9)) }
+	ceil := func(v float64) float64 { return math.Pow(base, math.Ceil(math.Log(v)/math.Log(base)-1e-9)) }
+	if s.Min <= s.Max {
+		s.Min, s.Max = floor(s.Min), ceil(s.Max)
+	} else {
+		s.Min, s.Max = ceil(s.Min), floor(s.Max)
+	}
+	return s
+}
+
+// Ticks returns ticks at powers of base and their multiples,
+// count is ignored.
+func (s *Log) Ticks(count int) []axis.Tick {
+	return axis.Log(math.Min(s.Min, s.Max), math.Max(s.Min, s.Max), s.base())
+}
+
+// Position implements Scale.
+func (s *Log) Position(value float64) diagram.Length { return s.Map(value) }
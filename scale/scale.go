@@ -0,0 +1,46 @@
+// Package scale implements mappings from data values to lengths.
+package scale
+
+import (
+	"math"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+)
+
+// Scale is a mapping that can be drawn as an axis.
+type Scale interface {
+	// Ticks returns approximately count ticks for the domain.
+	Ticks(count int) []axis.Tick
+	// Position returns the position of a tick value.
+	Position(value float64) diagram.Length
+}
+
+// NewAxis creates an axis for scale with approximately count ticks.
+func NewAxis(pos axis.Position, scale Scale, count int) *axis.Axis {
+	return axis.New(pos, scale.Ticks(count), scale.Position)
+}
+
+var (
+	_ Scale = (*Linear)(nil)
+	_ Scale = (*Log)(nil)
+	_ Scale = (*Time)(nil)
+	_ Scale = (*Band)(nil)
+	_ Scale = (*Ordinal)(nil)
+)
+
+// lerp maps v from [min, max] to [from, to].
+func lerp(v, min, max float64, from, to diagram.Length) diagram.Length {
+	if min == max {
+		return (from + to) / 2
+	}
+	return from + (v-min)/(max-min)*(to-from)
+}
+
+// clamp limits v to the range between a and b.
+func clamp(v, a, b float64) float64 {
+	if a > b {
+		a, b = b, a
+	}
+	return math.Max(a, math.Min(v, b))
+}
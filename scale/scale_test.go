@@ -0,0 +1,146 @@
+package scale_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/scale"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestLinear(t *testing.T) {
+	s := scale.NewLinear(10, 20, 100, 0)
+	tests := []struct{ v, p float64 }{
+		{10, 100}, {15, 50}, {20, 0}, {25, -50},
+	}
+	for _, test := range tests {
+		if p := s.Map(test.v); !near(p, test.p) {
+			t.Errorf("Map(%v) = %v expected %v", test.v, p, test.p)
+		}
+		if v := s.Invert(test.p); !near(v, test.v) {
+			t.Errorf("Invert(%v) = %v expected %v", test.p, v, test.v)
+		}
+	}
+
+	s.Clamp = true
+	if p := s.Map(25); !near(p, 0) {
+		t.Errorf("clamped Map(25) = %v", p)
+	}
+	if v := s.Invert(-50); !near(v, 20) {
+		t.Errorf("clamped Invert(-50) = %v", v)
+	}
+
+	nice := scale.NewLinear(0.13, 9.7, 0, 1).Nice(5)
+	if nice.Min != 0 || nice.Max != 10 {
+		t.Errorf("Nice() = [%v, %v]", nice.Min, nice.Max)
+	}
+
+	reversed := scale.NewLinear(9.7, 0.13, 0, 1).Nice(5)
+	if reversed.Min != 10 || reversed.Max != 0 {
+		t.Errorf("reversed Nice() = [%v, %v]", reversed.Min, reversed.Max)
+	}
+}
+
+func TestLog(t *testing.T) {
+	s := scale.NewLog(1, 1000, 0, 300)
+	tests := []struct{ v, p float64 }{
+		{1, 0}, {10, 100}, {100, 200}, {1000, 300},
+	}
+	for _, test := range tests {
+		if p := s.Map(test.v); !near(p, test.p) {
+			t.Errorf("Map(%v) = %v expected %v", test.v, p, test.p)
+		}
+		if v := s.Invert(test.p); !near(v, test.v) {
+			t.Errorf("Invert(%v) = %v expected %v", test.p, v, test.v)
+		}
+	}
+
+	nice := scale.NewLog(3, 420, 0, 1).Nice()
+	if !near(nice.Min, 1) || !near(nice.Max, 1000) {
+		t.Errorf("Nice() = [%v, %v]", nice.Min, nice.Max)
+	}
+}
+
+func TestTime(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 10, 17, 0, 0, time.UTC)
+	s := scale.NewTime(t0, t0.Add(time.Hour), 0, 60)
+
+	if p := s.Map(t0.Add(30 * time.Minute)); !near(p, 30) {
+		t.Errorf("Map(+30m) = %v", p)
+	}
+	if at := s.Invert(15); !at.Equal(t0.Add(15 * time.Minute)) {
+		t.Errorf("Invert(15) = %v", at)
+	}
+	if p := s.Position(float64(t0.Add(45 * time.Minute).UnixNano())); !near(p, 45) {
+		t.Errorf("Position(+45m) = %v", p)
+	}
+
+	s.Clamp = true
+	if p := s.Map(t0.Add(-time.Hour)); !near(p, 0) {
+		t.Errorf("clamped Map(-1h) = %v", p)
+	}
+
+	s.Nice(6)
+	if !s.Min.Equal(time.Date(2020, 1, 1, 10, 10, 0, 0, time.UTC)) || !s.Max.Equal(time.Date(2020, 1, 1, 11, 20, 0, 0, time.UTC)) {
+		t.Errorf("Nice() = [%v, %v]", s.Min, s.Max)
+	}
+}
+
+func TestBand(t *testing.T) {
+	s := scale.NewBand([]string{"a", "b", "c", "d"}, 0, 100)
+	if s.Bandwidth() != 25 || s.Map("c") != 50 {
+		t.Errorf("got bandwidth %v, c at %v", s.Bandwidth(), s.Map("c"))
+	}
+
+	s.PaddingInner = 0.2
+	s.PaddingOuter = 0.4
+	// step = 100 / (4 - 0.2 + 0.8)
+	step := 100 / 4.6
+	if !near(s.Step(), step) || !near(s.Bandwidth(), step*0.8) {
+		t.Errorf("got step %v, bandwidth %v", s.Step(), s.Bandwidth())
+	}
+	if p := s.Map("a"); !near(p, step*0.4) {
+		t.Errorf("a at %v", p)
+	}
+	if v := s.Invert(s.Map("b") + 1); v != "b" {
+		t.Errorf("Invert = %q", v)
+	}
+	if v := s.Invert(1); v != "" {
+		t.Errorf("Invert in padding = %q", v)
+	}
+	if !math.IsNaN(s.Map("x")) {
+		t.Errorf("expected NaN for unknown value")
+	}
+
+	reversed := scale.NewBand([]string{"a", "b"}, 100, 0)
+	if reversed.Map("a") != 50 || reversed.Map("b") != 0 {
+		t.Errorf("reversed a at %v, b at %v", reversed.Map("a"), reversed.Map("b"))
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	s := scale.NewPoints([]string{"a", "b", "c"}, 0, 100, 0.5)
+	expected := []diagram.Length{100.0 / 6, 50, 500.0 / 6}
+	for i, v := range s.Domain {
+		if p := s.Map(v); !near(p, expected[i]) {
+			t.Errorf("Map(%q) = %v expected %v", v, p, expected[i])
+		}
+	}
+	if v := s.Invert(60); v != "b" {
+		t.Errorf("Invert(60) = %q", v)
+	}
+
+	cycle := scale.NewOrdinal([]string{"a", "b", "c"}, []diagram.Length{1, 2})
+	if cycle.Map("c") != 1 {
+		t.Errorf("expected range to repeat, got %v", cycle.Map("c"))
+	}
+	if v := cycle.MapIndex(-1); v != 2 {
+		t.Errorf("MapIndex(-1) = %v expected 2", v)
+	}
+	if v := cycle.Position(-4); v != 1 {
+		t.Errorf("Position(-4) = %v expected 1", v)
+	}
+}
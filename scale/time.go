@@ -0,0 +1,64 @@
+package scale
+
+import (
+	"time"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/axis"
+)
+
+// Time maps time domain [Min, Max] linearly to range [From, To].
+type Time struct {
+	Min, Max time.Time
+	From, To diagram.Length
+	// Clamp limits results to the range and inverted values to the domain.
+	Clamp bool
+}
+
+// NewTime creates a time scale.
+func NewTime(min, max time.Time, from, to diagram.Length) *Time {
+	return &Time{
+		Min: min, Max: max,
+		From: from, To: to,
+	}
+}
+
+// Map returns the position of t.
+func (s *Time) Map(t time.Time) diagram.Length {
+	if s.Clamp {
+		if t.Before(s.Min) {
+			t = s.Min
+		}
+		if t.After(s.Max) {
+			t = s.Max
+		}
+	}
+	return lerp(float64(t.Sub(s.Min)), 0, float64(s.Max.Sub(s.Min)), s.From, s.To)
+}
+
+// Invert returns the time at position p.
+func (s *Time) Invert(p diagram.Length) time.Time {
+	if s.Clamp {
+		p = clamp(p, s.From, s.To)
+	}
+	offset := lerp(p, s.From, s.To, 0, float64(s.Max.Sub(s.Min)))
+	return s.Min.Add(time.Duration(offset))
+}
+
+// Nice extends the domain to calendar boundaries,
+// for approximately count ticks.
+func (s *Time) Nice(count int) *Time {
+	s.Min, s.Max = axis.NiceTime(s.Min, s.Max, count)
+	return s
+}
+
+// Ticks returns approximately count ticks for the domain.
+func (s *Time) Ticks(count int) []axis.Tick {
+	return axis.Time(s.Min, s.Max, count)
+}
+
+// Position returns the position of a tick value
+// in nanoseconds since Unix epoch.
+func (s *Time) Position(value float64) diagram.Length {
+	return s.Map(time.Unix(0, int64(value)))
+}
@@ -0,0 +1,189 @@
+package sequence
+
+import (
+	"strings"
+
+	"loov.dev/diagram"
+)
+
+// Activation is a period when a lane is busy processing.
+//
+// Activations that overlap on the same lane are nested,
+// such as re-entrant calls.
+type Activation struct {
+	Lane Role
+
+	// Start and End are explicit times of the activation.
+	Start Time
+	End   Time
+
+	// Request, when set, starts the activation when it arrives.
+	Request *Message
+	// Response, when set, ends the activation when it's sent.
+	Response *Message
+
+	Style diagram.Style
+
+	// start and end are the resolved times.
+	start, end Time
+	depth      int
+}
+
+func (activation *Activation) Styled(style diagram.Style) *Activation {
+	activation.Style = style
+	return activation
+}
+
+// open returns whether the end of the activation is unspecified.
+func (activation *Activation) open() bool {
+	return IsAutomatic(activation.End) && activation.Response == nil
+}
+
+// Activate starts an activation on lane at the specified time.
+// It ends at the end of the diagram, unless deactivated.
+func (dia *Diagram) Activate(lane Role, start Time) *Activation {
+	activation := &Activation{
+		Lane:  lane,
+		Start: start,
+		End:   Automatic,
+	}
+	dia.Activations = append(dia.Activations, activation)
+	return activation
+}
+
+// Deactivate ends the innermost open activation on lane at the specified time.
+func (dia *Diagram) Deactivate(lane Role, end Time) *Activation {
+	activation := dia.innermost(lane)
+	if activation != nil {
+		activation.End = end
+	}
+	return activation
+}
+
+// Call adds an activation on the receiver of request,
+// starting when request arrives and ending when response is sent.
+func (dia *Diagram) Call(request, response *Message) *Activation {
+	activation := &Activation{
+		Lane:     request.To,
+		Start:    Automatic,
+		End:      Automatic,
+		Request:  request,
+		Response: response,
+	}
+	dia.Activations = append(dia.Activations, activation)
+	return activation
+}
+
+// activateAfter starts an activation on lane when request arrives,
+// request may be nil to start at the beginning of the diagram.
+func (dia *Diagram) activateAfter(lane Role, request *Message) *Activation {
+	activation := dia.Activate(lane, Automatic)
+	activation.Request = request
+	return activation
+}
+
+// deactivateAt ends the innermost open activation on lane when response is sent.
+func (dia *Diagram) deactivateAt(lane Role, response *Message) *Activation {
+	activation := dia.innermost(lane)
+	if activation != nil {
+		activation.Response = response
+	}
+	return activation
+}
+
+// innermost returns the last open activation on lane.
+func (dia *Diagram) innermost(lane Role) *Activation {
+	for i := len(dia.Activations) - 1; i >= 0; i-- {
+		activation := dia.Activations[i]
+		if strings.EqualFold(activation.Lane, lane) && activation.open() {
+			return activation
+		}
+	}
+	return nil
+}
+
+// lastMessage returns the most recently added message.
+func (dia *Diagram) lastMessage() *Message {
+	if len(dia.Messages) == 0 {
+		return nil
+	}
+	return dia.Messages[len(dia.Messages)-1]
+}
+
+// normalizeActivations resolves times derived from messages,
+// it must be called after message times are known.
+func (dia *Diagram) normalizeActivations() {
+	for _, activation := range dia.Activations {
+		activation.start = activation.Start
+		if activation.Request != nil {
+			activation.start = activation.Request.End()
+		}
+		activation.end = activation.End
+		if activation.Response != nil {
+			activation.end = activation.Response.Start()
+		}
+	}
+}
+
+// nestActivations fills in unspecified times and computes nesting,
+// it must be called after the diagram time range is known.
+func (dia *Diagram) nestActivations() {
+	for _, activation := range dia.Activations {
+		if IsAutomatic(activation.start) {
+			activation.start = dia.Start
+		}
+		if IsAutomatic(activation.end) {
+			activation.end = dia.End
+		}
+	}
+
+	for i, a := range dia.Activations {
+		a.depth = 0
+		for k, b := range dia.Activations {
+			if i == k || !strings.EqualFold(a.Lane, b.Lane) {
+				continue
+			}
+			// b is active when a starts
+			if b.start > a.start || a.start >= b.end {
+				continue
+			}
+			// activations starting together are nested by length and order
+			if b.start == a.start && (b.end < a.end || (b.end == a.end && k > i)) {
+				continue
+			}
+			a.depth++
+		}
+	}
+}
+
+// activationBounds returns the horizontal extent of activation.
+func (dia *Diagram) activationBounds(activation *Activation) (left, right diagram.Length) {
+	width := dia.Theme.ActivationWidth
+	left = dia.Lane(activation.Lane).Center - width/2 + diagram.Length(activation.depth)*width/2
+	return left, left + width
+}
+
+// port returns where a message at time t on lane connects,
+// when the message is coming from or going towards x.
+func (dia *Diagram) port(lane *Lane, t Time, x diagram.Length) diagram.Length {
+	var top *Activation
+	for _, activation := range dia.Activations {
+		if !strings.EqualFold(activation.Lane, lane.Name) {
+			continue
+		}
+		if activation.start <= t && t <= activation.end {
+			if top == nil || activation.depth > top.depth {
+				top = activation
+			}
+		}
+	}
+	if top == nil {
+		return lane.Center
+	}
+
+	left, right := dia.activationBounds(top)
+	if x < lane.Center {
+		return left
+	}
+	return right
+}
@@ -0,0 +1,66 @@
+package sequence_test
+
+import (
+	"testing"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/sequence"
+)
+
+func TestActivation(t *testing.T) {
+	dia := sequence.New()
+
+	request := sequence.Send("Client", "Server", "request")
+	callback := sequence.Send("Server", "Client", "callback")
+	reentrant := sequence.Send("Client", "Server", "reentrant")
+	done := sequence.Send("Server", "Client", "done")
+	response := sequence.Send("Server", "Client", "response")
+	dia.Add(request, callback, reentrant, done, response)
+
+	outer := dia.Call(request, response)
+	inner := dia.Call(reentrant, done)
+	dia.Activate("Client", 0)
+	if dia.Deactivate("Client", 10) == nil {
+		t.Fatal("expected open activation")
+	}
+	if dia.Deactivate("Client", 10) != nil {
+		t.Fatal("expected no open activation")
+	}
+
+	rec := diagram.NewRecorder(dia.Size())
+	dia.Draw(rec)
+
+	var bars []diagram.Rect
+	for _, op := range rec.Ops {
+		if op.Kind == diagram.OpRect {
+			bars = append(bars, *op.Rect)
+		}
+	}
+	if len(bars) != 3 {
+		t.Fatalf("expected 3 activations, got %v", bars)
+	}
+
+	outerBar, innerBar, clientBar := bars[0], bars[1], bars[2]
+	if outer.Lane != "Server" || inner.Lane != "Server" {
+		t.Errorf("activations should be on the receiver")
+	}
+	if !(outerBar.Min.Y < innerBar.Min.Y && innerBar.Max.Y < outerBar.Max.Y) {
+		t.Errorf("inner %v should be within outer %v vertically", innerBar, outerBar)
+	}
+	if !(outerBar.Min.X < innerBar.Min.X && outerBar.Max.X < innerBar.Max.X) {
+		t.Errorf("inner %v should be offset from outer %v", innerBar, outerBar)
+	}
+	if clientBar.Max.X > outerBar.Min.X {
+		t.Errorf("client %v should be left of server %v", clientBar, outerBar)
+	}
+
+	// messages connect to the edge of the innermost activation
+	for _, op := range rec.Ops {
+		if op.Kind != diagram.OpPoly || len(op.Points) != 2 {
+			continue
+		}
+		if end := op.Points[1]; end.Y == innerBar.Min.Y && end.X != innerBar.Min.X {
+			t.Errorf("reentrant should end at %v, got %v", innerBar.Min.X, end.X)
+		}
+	}
+}
@@ -7,27 +7,49 @@ import (
 	"strings"
 
 	"loov.dev/diagram"
+	"loov.dev/diagram/scale"
 )
 
 type Diagram struct {
 	Start, End Time
 
-	Lanes    []*Lane
-	Messages []*Message
+	Lanes       []*Lane
+	Messages    []*Message
+	Activations []*Activation
+	Notes       []*Note
+	Fragments   []*Fragment
 
 	AutoSleep Time
 	AutoDelay Time
 
+	// open are the fragments that include added messages.
+	open []*Fragment
+
+	// Measurer is used to size lanes to fit captions.
+	Measurer diagram.Measurer
+
 	Theme struct {
 		TimeScale     diagram.Length // length per time-unit
 		CaptionHeight diagram.Length
-		LaneWidth     diagram.Length
+		LaneWidth     diagram.Length // minimum lane width
 		LanePadding   diagram.Length
 
-		Time    diagram.Style
-		Caption diagram.Style
-		Message diagram.Style
-		Send    diagram.Style
+		ActivationWidth diagram.Length
+		NoteWidth       diagram.Length // maximum note width
+		NotePadding     diagram.Length
+		FragmentPadding diagram.Length
+
+		Time        diagram.Style
+		Caption     diagram.Style
+		Message     diagram.Style
+		Send        diagram.Style
+		Activation  diagram.Style
+		Note        diagram.Style
+		NoteCaption diagram.Style
+
+		Fragment        diagram.Style
+		FragmentLabel   diagram.Style
+		FragmentCaption diagram.Style
 	}
 }
 
@@ -39,6 +61,8 @@ func New() *Diagram {
 	dia.AutoSleep = 0.5
 	dia.AutoDelay = 0.5
 
+	dia.Measurer = diagram.DefaultMeasurer
+
 	const fontSize = 12
 	const lineHeight = 16
 
@@ -46,6 +70,10 @@ func New() *Diagram {
 	dia.Theme.CaptionHeight = lineHeight * 2
 	dia.Theme.LaneWidth = 200
 	dia.Theme.LanePadding = lineHeight
+	dia.Theme.ActivationWidth = 10
+	dia.Theme.NoteWidth = 160
+	dia.Theme.NotePadding = 6
+	dia.Theme.FragmentPadding = 4
 
 	dia.Theme.Caption = diagram.Style{
 		Stroke: nil,
@@ -63,9 +91,36 @@ func New() *Diagram {
 		Size:   12,
 	}
 	dia.Theme.Send = diagram.Style{
+		Stroke:    color.NRGBA{0, 0, 0, 255},
+		Size:      1.3,
+		EndMarker: diagram.MarkerArrow,
+	}
+	dia.Theme.Activation = diagram.Style{
 		Stroke: color.NRGBA{0, 0, 0, 255},
-		Size:   1.3,
-		// TODO: arrow
+		Fill:   color.NRGBA{235, 235, 235, 255},
+		Size:   1,
+	}
+	dia.Theme.Note = diagram.Style{
+		Stroke: color.NRGBA{0x60, 0x60, 0x30, 255},
+		Fill:   color.NRGBA{0xFF, 0xF8, 0xC8, 255},
+		Size:   1,
+	}
+	dia.Theme.NoteCaption = diagram.Style{
+		Fill: color.NRGBA{0, 0, 0, 255},
+		Size: fontSize,
+	}
+	dia.Theme.Fragment = diagram.Style{
+		Stroke: color.NRGBA{0x50, 0x50, 0x50, 255},
+		Size:   1,
+	}
+	dia.Theme.FragmentLabel = diagram.Style{
+		Stroke: color.NRGBA{0x50, 0x50, 0x50, 255},
+		Fill:   color.NRGBA{0xF4, 0xF4, 0xF4, 255},
+		Size:   1,
+	}
+	dia.Theme.FragmentCaption = diagram.Style{
+		Fill: color.NRGBA{0, 0, 0, 255},
+		Size: fontSize,
 	}
 
 	return dia
@@ -81,6 +136,7 @@ type Lane struct {
 	End   Time
 
 	Center diagram.Length
+	Width  diagram.Length
 
 	Caption diagram.Style
 	Line    diagram.Style
@@ -127,6 +183,9 @@ func (message *Message) EndAlign() *Message   { message.align = 1; return messag
 
 func (dia *Diagram) Add(messages ...*Message) {
 	dia.Messages = append(dia.Messages, messages...)
+	for _, fragment := range dia.open {
+		fragment.include(messages...)
+	}
 }
 
 func (dia *Diagram) Lane(name string) *Lane {
@@ -148,24 +207,83 @@ func (dia *Diagram) Lane(name string) *Lane {
 }
 
 func (dia *Diagram) normalize() {
-	dia.normalizeTimes()
+	index := dia.messageIndex()
+	dia.normalizeTimes(index)
+	dia.normalizeActivations()
 	dia.normalizeLanes()
+	dia.nestActivations()
+	dia.layoutLanes(index)
 }
 
-func (dia *Diagram) normalizeTimes() {
-	last := &Message{}
+func (dia *Diagram) normalizeTimes(index map[*Message]int) {
+	dia.prepareFragments(index)
+	starts, ends := dia.fragmentBoundaries()
+
+	end := Time(0)
+
+	placed := map[*Note]bool{}
+	placeNotes := func(after *Message) {
+		for _, note := range dia.Notes {
+			if note.After != after || placed[note] {
+				continue
+			}
+			placed[note] = true
+
+			note.duration = dia.noteSize(note).Y / dia.Theme.TimeScale
+			if IsAutomatic(note.When) {
+				if !IsAutomatic(note.Sleep) {
+					note.When = end + note.Sleep
+				} else {
+					note.When = end + dia.AutoSleep
+				}
+			}
+			end = note.End()
+		}
+	}
+
+	placeNotes(nil)
 	for _, message := range dia.Messages {
+		// fragment headers are stacked above the message,
+		// leaving space for the message caption
+		var headers Time
+		for _, start := range starts[message] {
+			headers += dia.fragmentHeader(start.fragment) / dia.Theme.TimeScale
+		}
+		if headers > 0 {
+			headers += message.Caption.Or(dia.Theme.Message).Size / dia.Theme.TimeScale
+		}
+
 		if IsAutomatic(message.When) {
 			if !IsAutomatic(message.Sleep) {
-				message.When = last.End() + message.Sleep
+				message.When = end + message.Sleep + headers
 			} else {
-				message.When = last.End() + dia.AutoSleep
+				message.When = end + dia.AutoSleep + headers
 			}
 		}
 		if IsAutomatic(message.Delay) {
 			message.Delay = dia.AutoDelay
 		}
-		last = message
+
+		top := message.When - headers
+		for _, start := range starts[message] {
+			start.operand.top = top
+			if start.operand == start.fragment.operands()[0] {
+				start.fragment.top = top
+			}
+			top += dia.fragmentHeader(start.fragment) / dia.Theme.TimeScale
+		}
+
+		end = message.End()
+		placeNotes(message)
+
+		for _, fragment := range ends[message] {
+			end += dia.Theme.FragmentPadding * 2 / dia.Theme.TimeScale
+			fragment.bottom = end
+		}
+	}
+	// notes following messages that were removed
+	for _, note := range dia.Notes {
+		placeNotes(note.After)
 	}
 
 	sort.Slice(dia.Messages, func(i, k int) bool {
@@ -190,12 +308,105 @@ func (dia *Diagram) normalizeLanes() {
 		to.Start = Min(to.Start, message.Start())
 		to.End = Max(to.End, message.End())
 	}
+
+	for _, note := range dia.Notes {
+		dia.Start = Min(dia.Start, note.Start())
+		dia.End = Max(dia.End, note.End())
+		for _, name := range note.Lanes {
+			lane := dia.Lane(name)
+			lane.Start = Min(lane.Start, note.Start())
+			lane.End = Max(lane.End, note.End())
+		}
+	}
+
+	for _, fragment := range dia.Fragments {
+		if !fragment.empty() {
+			dia.Start = Min(dia.Start, fragment.top)
+			dia.End = Max(dia.End, fragment.bottom)
+		}
+	}
+
+	for _, activation := range dia.Activations {
+		lane := dia.Lane(activation.Lane)
+		for _, t := range []Time{activation.start, activation.end} {
+			if IsAutomatic(t) {
+				continue
+			}
+			dia.Start = Min(dia.Start, t)
+			dia.End = Max(dia.End, t)
+			lane.Start = Min(lane.Start, t)
+			lane.End = Max(lane.End, t)
+		}
+	}
+}
+
+// layoutLanes sizes lanes to fit their captions and messages between them.
+func (dia *Diagram) layoutLanes(index map[*Message]int) {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+
+	padding := dia.Theme.LanePadding
+	for _, lane := range dia.Lanes {
+		caption := measurer.Measure(lane.Name, lane.Caption.Or(dia.Theme.Caption))
+		lane.Width = math.Max(dia.Theme.LaneWidth, caption.Size().X+2*padding)
+	}
+
+	for _, message := range dia.Messages {
+		if message.Text == "" {
+			continue
+		}
+
+		a, b := dia.Lane(message.From).Order, dia.Lane(message.To).Order
+		if a > b {
+			a, b = b, a
+		}
+		if a == b {
+			continue
+		}
+
+		caption := measurer.Measure(message.Text, message.Caption.Or(dia.Theme.Message))
+		needed := caption.Size().X + 2*padding
+
+		available := (dia.Lanes[a].Width + dia.Lanes[b].Width) * 0.5
+		for _, lane := range dia.Lanes[a+1 : b] {
+			available += lane.Width
+		}
+
+		if available < needed {
+			extra := (needed - available) / float64(b-a)
+			for _, lane := range dia.Lanes[a : b+1] {
+				lane.Width += extra
+			}
+		}
+	}
+
+	dia.layoutNotes()
+	dia.centerLanes()
+
+	dia.layoutFragments(index)
+	if dia.fitFragments() {
+		dia.centerLanes()
+		dia.layoutFragments(index)
+	}
+}
+
+// centerLanes places lanes next to each other.
+func (dia *Diagram) centerLanes() {
+	x := diagram.Length(0)
+	for _, lane := range dia.Lanes {
+		lane.Center = x + lane.Width*0.5
+		x += lane.Width
+	}
 }
 
 func (dia *Diagram) Size() (width, height float64) {
 	dia.normalize()
 
-	width = float64(len(dia.Lanes)) * dia.Theme.LaneWidth
+	for _, lane := range dia.Lanes {
+		width += lane.Width
+	}
 	height = dia.Theme.CaptionHeight + 2*dia.Theme.LanePadding + (dia.End-dia.Start)*dia.Theme.TimeScale
 	return width, height
 }
@@ -208,10 +419,9 @@ func (dia *Diagram) Draw(canvas diagram.Canvas) {
 	texts := canvas.Layer(1)
 
 	y0 := dia.Theme.CaptionHeight + dia.Theme.LanePadding
-	y1 := y0 + (dia.End-dia.Start)*dia.Theme.TimeScale + dia.Theme.LanePadding
+	times := scale.NewLinear(dia.Start, dia.End, y0, y0+(dia.End-dia.Start)*dia.Theme.TimeScale)
+	y1 := times.To + dia.Theme.LanePadding
 	for _, lane := range dia.Lanes {
-		lane.Center = (float64(lane.Order) + 0.5) * dia.Theme.LaneWidth
-
 		guide.Poly(diagram.Ps(lane.Center, y0-dia.Theme.LanePadding, lane.Center, y1),
 			lane.Line.Or(dia.Theme.Time))
 
@@ -219,12 +429,33 @@ func (dia *Diagram) Draw(canvas diagram.Canvas) {
 			lane.Caption.Or(dia.Theme.Caption))
 	}
 
-	for _, message := range dia.Messages {
-		fromx := dia.Lane(message.From).Center
-		fromy := y0 + (message.Start()-dia.Start)*dia.Theme.TimeScale
+	for _, activation := range dia.Activations {
+		left, right := dia.activationBounds(activation)
+		top, bottom := times.Map(activation.start), times.Map(activation.end)
+		sends.Rect(diagram.R(left, top, right, bottom), activation.Style.Or(dia.Theme.Activation))
+	}
 
-		tox := dia.Lane(message.To).Center
-		toy := y0 + (message.End()-dia.Start)*dia.Theme.TimeScale
+	for _, fragment := range dia.Fragments {
+		if !fragment.empty() {
+			dia.drawFragment(sends, fragment, times.Map)
+		}
+	}
+
+	for _, note := range dia.Notes {
+		if len(note.Lanes) > 0 {
+			dia.drawNote(texts, note, times.Map(note.Start()))
+		}
+	}
+
+	for _, message := range dia.Messages {
+		from, to := dia.Lane(message.From), dia.Lane(message.To)
+		fromx, tox := from.Center, to.Center
+		if from != to {
+			fromx = dia.port(from, message.Start(), to.Center)
+			tox = dia.port(to, message.End(), from.Center)
+		}
+		fromy := times.Map(message.Start())
+		toy := times.Map(message.End())
 		if message.failed {
 			if fromx < tox {
 				tox -= dia.Theme.LaneWidth * 0.2
@@ -234,26 +465,14 @@ func (dia *Diagram) Draw(canvas diagram.Canvas) {
 		}
 
 		lineStyle := message.Line.Or(dia.Theme.Send)
+		if message.failed {
+			lineStyle.EndMarker = diagram.MarkerCross
+		}
 		sends.Poly(diagram.Ps(fromx, fromy, tox, toy), lineStyle)
 
 		dx, dy := tox-fromx, toy-fromy
 		angle := math.Atan2(dy, dx)
 
-		var s = lineStyle.Size * 4
-		var sn, cs float64
-
-		if !message.failed {
-			sn, cs = math.Sincos(angle - math.Pi + math.Pi/8)
-			sends.Poly(diagram.Ps(tox, toy, tox+cs*s, toy+sn*s), lineStyle)
-			sn, cs = math.Sincos(angle - math.Pi - math.Pi/8)
-			sends.Poly(diagram.Ps(tox, toy, tox+cs*s, toy+sn*s), lineStyle)
-		} else {
-			sn, cs = math.Sincos(angle - math.Pi + math.Pi/4)
-			sends.Poly(diagram.Ps(tox-cs*s, toy-sn*s, tox+cs*s, toy+sn*s), lineStyle)
-			sn, cs = math.Sincos(angle - math.Pi - math.Pi/4)
-			sends.Poly(diagram.Ps(tox-cs*s, toy-sn*s, tox+cs*s, toy+sn*s), lineStyle)
-		}
-
 		if message.Text != "" {
 			textstyle := message.Caption.Or(dia.Theme.Message)
 
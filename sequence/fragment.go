@@ -0,0 +1,386 @@
+package sequence
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"loov.dev/diagram"
+)
+
+// Operator is the kind of a combined fragment.
+type Operator string
+
+const (
+	// Alt chooses one of the operands based on guards.
+	Alt Operator = "alt"
+	// Opt runs the only operand when the guard holds.
+	Opt Operator = "opt"
+	// Loop repeats the only operand.
+	Loop Operator = "loop"
+	// Par runs the operands in parallel.
+	Par Operator = "par"
+	// Critical runs the operand atomically.
+	Critical Operator = "critical"
+	// Break runs the operand instead of the rest of the enclosing fragment.
+	Break Operator = "break"
+)
+
+// Fragment is a combined fragment, which frames a range of messages.
+//
+// Fragments nest when the range of one contains the other.
+type Fragment struct {
+	Operator Operator
+	Operands []*Operand
+
+	// Lanes are included in the frame in addition to
+	// the lanes of messages in the operands.
+	Lanes []Role
+
+	Style   diagram.Style
+	Caption diagram.Style
+
+	// top and bottom are the resolved times.
+	top, bottom Time
+	// first and last are the indices of the first and last message.
+	first, last int
+	depth       int
+	left, right diagram.Length
+}
+
+// Operand is a section of a fragment.
+type Operand struct {
+	Guard string
+
+	// First and Last are the range of messages in the operand.
+	First *Message
+	Last  *Message
+
+	// top is the resolved time of the operand start.
+	top Time
+}
+
+// Styled sets the frame style.
+func (fragment *Fragment) Styled(style diagram.Style) *Fragment {
+	fragment.Style = style
+	return fragment
+}
+
+// Fragment starts a fragment with the first operand guarded by guard.
+// Messages added afterwards are included in the operand.
+func (dia *Diagram) Fragment(operator Operator, guard string) *Fragment {
+	fragment := &Fragment{
+		Operator: operator,
+		Operands: []*Operand{{Guard: guard}},
+	}
+	dia.Fragments = append(dia.Fragments, fragment)
+	dia.open = append(dia.open, fragment)
+	return fragment
+}
+
+// Else starts the next operand of the innermost open fragment.
+func (dia *Diagram) Else(guard string) *Operand {
+	if len(dia.open) == 0 {
+		return nil
+	}
+	fragment := dia.open[len(dia.open)-1]
+	operand := &Operand{Guard: guard}
+	fragment.Operands = append(fragment.Operands, operand)
+	return operand
+}
+
+// EndFragment closes the innermost open fragment.
+func (dia *Diagram) EndFragment() *Fragment {
+	if len(dia.open) == 0 {
+		return nil
+	}
+	fragment := dia.open[len(dia.open)-1]
+	dia.open = dia.open[:len(dia.open)-1]
+	return fragment
+}
+
+// include adds messages to the last operand.
+func (fragment *Fragment) include(messages ...*Message) {
+	if len(messages) == 0 {
+		return
+	}
+	operand := fragment.Operands[len(fragment.Operands)-1]
+	if operand.First == nil {
+		operand.First = messages[0]
+	}
+	operand.Last = messages[len(messages)-1]
+}
+
+// empty returns whether the fragment contains no messages.
+func (fragment *Fragment) empty() bool { return fragment.first < 0 }
+
+// operands returns the operands that contain messages.
+func (fragment *Fragment) operands() []*Operand {
+	var operands []*Operand
+	for _, operand := range fragment.Operands {
+		if operand.First != nil && operand.Last != nil {
+			operands = append(operands, operand)
+		}
+	}
+	return operands
+}
+
+// fragmentLabel returns the style of the operator label.
+func (dia *Diagram) fragmentLabel(fragment *Fragment) *diagram.Style {
+	style := fragment.Caption.Or(dia.Theme.FragmentCaption)
+	style.Origin = diagram.P(-1, 0)
+	return style
+}
+
+// fragmentHeader returns the height of the label row of fragment.
+func (dia *Diagram) fragmentHeader(fragment *Fragment) diagram.Length {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+	text := measurer.Measure(string(fragment.Operator), dia.fragmentLabel(fragment))
+	return text.Size().Y + 2*dia.Theme.FragmentPadding
+}
+
+// fragmentTab returns the width of the operator label box.
+func (dia *Diagram) fragmentTab(fragment *Fragment) diagram.Length {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+	text := measurer.Measure(string(fragment.Operator), dia.fragmentLabel(fragment))
+	return text.Size().X + 2*dia.Theme.FragmentPadding + dia.fragmentHeader(fragment)/3
+}
+
+// guardText returns the guard of operand in brackets.
+func guardText(operand *Operand) string {
+	if operand.Guard == "" || strings.HasPrefix(operand.Guard, "[") {
+		return operand.Guard
+	}
+	return "[" + operand.Guard + "]"
+}
+
+// messageIndex returns the position of each message in dia.Messages.
+func (dia *Diagram) messageIndex() map[*Message]int {
+	index := map[*Message]int{}
+	for i, message := range dia.Messages {
+		index[message] = i
+	}
+	return index
+}
+
+// prepareFragments finds message ranges and nesting of fragments,
+// index contains the position of each message.
+func (dia *Diagram) prepareFragments(index map[*Message]int) {
+	for _, fragment := range dia.Fragments {
+		fragment.first, fragment.last = -1, -1
+		for _, operand := range fragment.operands() {
+			first, ok1 := index[operand.First]
+			last, ok2 := index[operand.Last]
+			if !ok1 || !ok2 {
+				continue
+			}
+			if fragment.first < 0 || first < fragment.first {
+				fragment.first = first
+			}
+			if last > fragment.last {
+				fragment.last = last
+			}
+		}
+	}
+
+	for i, a := range dia.Fragments {
+		a.depth = 0
+		for k, b := range dia.Fragments {
+			if i == k || a.empty() || b.empty() || !b.contains(a) {
+				continue
+			}
+			// fragments with the same range are nested in order
+			if a.contains(b) && k > i {
+				continue
+			}
+			a.depth++
+		}
+	}
+}
+
+// contains returns whether the range of fragment contains other.
+func (fragment *Fragment) contains(other *Fragment) bool {
+	return fragment.first <= other.first && other.last <= fragment.last
+}
+
+// fragmentOperand is an operand together with its fragment.
+type fragmentOperand struct {
+	fragment *Fragment
+	operand  *Operand
+}
+
+// fragmentBoundaries returns operands starting at each message
+// ordered from outermost, and fragments ending at each message
+// ordered from innermost.
+func (dia *Diagram) fragmentBoundaries() (starts map[*Message][]fragmentOperand, ends map[*Message][]*Fragment) {
+	starts = map[*Message][]fragmentOperand{}
+	ends = map[*Message][]*Fragment{}
+	for _, fragment := range dia.Fragments {
+		if fragment.empty() {
+			continue
+		}
+		operands := fragment.operands()
+		for _, operand := range operands {
+			starts[operand.First] = append(starts[operand.First], fragmentOperand{fragment, operand})
+		}
+		last := dia.Messages[fragment.last]
+		ends[last] = append(ends[last], fragment)
+	}
+
+	for _, list := range starts {
+		sort.SliceStable(list, func(i, k int) bool { return list[i].fragment.depth < list[k].fragment.depth })
+	}
+	for _, list := range ends {
+		sort.SliceStable(list, func(i, k int) bool { return list[i].depth > list[k].depth })
+	}
+	return starts, ends
+}
+
+// layoutFragments computes the horizontal extent of fragments,
+// such that nested fragments are inside their parents.
+func (dia *Diagram) layoutFragments(index map[*Message]int) {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+
+	padding := dia.Theme.FragmentPadding
+	margin := dia.Theme.ActivationWidth + padding
+
+	fragments := append([]*Fragment{}, dia.Fragments...)
+	sort.SliceStable(fragments, func(i, k int) bool { return fragments[i].depth > fragments[k].depth })
+
+	for _, fragment := range fragments {
+		if fragment.empty() {
+			continue
+		}
+
+		first, last := -1, -1
+		include := func(name Role) {
+			order := dia.Lane(name).Order
+			if first < 0 || order < first {
+				first = order
+			}
+			if order > last {
+				last = order
+			}
+		}
+		for _, message := range dia.Messages[fragment.first : fragment.last+1] {
+			include(message.From)
+			include(message.To)
+		}
+		for _, name := range fragment.Lanes {
+			include(name)
+		}
+
+		fragment.left = dia.Lanes[first].Center - margin
+		fragment.right = dia.Lanes[last].Center + margin
+
+		// notes following messages in the fragment
+		for _, note := range dia.Notes {
+			i, ok := index[note.After]
+			if !ok || i < fragment.first || i > fragment.last || len(note.Lanes) == 0 {
+				continue
+			}
+			left, right := dia.noteExtent(note, dia.noteSize(note).X)
+			fragment.left = math.Min(fragment.left, left-padding)
+			fragment.right = math.Max(fragment.right, right+padding)
+		}
+		for _, child := range fragments {
+			if child == fragment || child.empty() || child.depth <= fragment.depth || !fragment.contains(child) {
+				continue
+			}
+			if child.left-padding < fragment.left {
+				fragment.left = child.left - padding
+			}
+			if child.right+padding > fragment.right {
+				fragment.right = child.right + padding
+			}
+		}
+
+		// the label and the first guard must fit in the frame
+		needed := dia.fragmentTab(fragment) + 2*padding
+		if operands := fragment.operands(); len(operands) > 0 {
+			needed += measurer.Measure(guardText(operands[0]), dia.fragmentLabel(fragment)).Size().X
+		}
+		if fragment.right-fragment.left < needed {
+			fragment.right = fragment.left + needed
+		}
+	}
+}
+
+// fitFragments widens the outer lanes when fragments extend
+// past the diagram, returns whether any lane was widened.
+func (dia *Diagram) fitFragments() bool {
+	if len(dia.Lanes) == 0 {
+		return false
+	}
+
+	width := diagram.Length(0)
+	for _, lane := range dia.Lanes {
+		width += lane.Width
+	}
+
+	left, right := diagram.Length(0), width
+	for _, fragment := range dia.Fragments {
+		if fragment.empty() {
+			continue
+		}
+		left = math.Min(left, fragment.left)
+		right = math.Max(right, fragment.right)
+	}
+	if left >= 0 && right <= width {
+		return false
+	}
+
+	// widening a lane moves its center by half of the extra width
+	dia.Lanes[0].Width -= 2 * left
+	dia.Lanes[len(dia.Lanes)-1].Width += 2 * (right - width)
+	return true
+}
+
+// drawFragment draws the frame, label, guards and separators of fragment.
+func (dia *Diagram) drawFragment(canvas diagram.Canvas, fragment *Fragment, times func(Time) diagram.Length) {
+	padding := dia.Theme.FragmentPadding
+	style := fragment.Style.Or(dia.Theme.Fragment)
+	top, bottom := times(fragment.top), times(fragment.bottom)
+	left, right := fragment.left, fragment.right
+	canvas.Rect(diagram.R(left, top, right, bottom), style)
+
+	// label in a box with a cut corner
+	label := dia.fragmentLabel(fragment)
+	header := dia.fragmentHeader(fragment)
+	cut := header / 3
+	tab := left + dia.fragmentTab(fragment)
+	canvas.Poly(diagram.Ps(
+		left, top,
+		tab, top,
+		tab, top+header-cut,
+		tab-cut, top+header,
+		left, top+header,
+	), &dia.Theme.FragmentLabel)
+	canvas.Text(string(fragment.Operator), diagram.P(left+padding, top+header/2), label)
+
+	separator := *style
+	separator.Fill = nil
+	separator.Dash = []diagram.Length{4}
+
+	for i, operand := range fragment.operands() {
+		y := top
+		x := tab + padding
+		if i > 0 {
+			y = times(operand.top)
+			x = left + padding
+			canvas.Poly(diagram.Ps(left, y, right, y), &separator)
+		}
+		if operand.Guard != "" {
+			canvas.Text(guardText(operand), diagram.P(x, y+header/2), label)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package sequence_test
+
+import (
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/sequence"
+)
+
+func TestFragment(t *testing.T) {
+	dia, err := sequence.Parse(strings.NewReader(`
+Client -> Server: request
+alt cache hit
+Server -> Client: cached
+else cache miss
+loop retry
+Server -> DB: query
+end
+Server -> Client: fresh
+end
+Client -> Server: done
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dia.Fragments) != 2 {
+		t.Fatalf("expected 2 fragments, got %d", len(dia.Fragments))
+	}
+	alt, loop := dia.Fragments[0], dia.Fragments[1]
+	if len(alt.Operands) != 2 || alt.Operands[1].Guard != "cache miss" || len(loop.Operands) != 1 {
+		t.Fatalf("invalid operands")
+	}
+
+	request, cached, query, fresh, done := dia.Messages[0], dia.Messages[1], dia.Messages[2], dia.Messages[3], dia.Messages[4]
+	if alt.Operands[0].First != cached || alt.Operands[1].First != query || alt.Operands[1].Last != fresh {
+		t.Errorf("invalid alt range")
+	}
+	if loop.Operands[0].First != query || loop.Operands[0].Last != query {
+		t.Errorf("invalid loop range")
+	}
+
+	rec := diagram.NewRecorder(dia.Size())
+	dia.Draw(rec)
+
+	// headers and separators take time
+	sleep := dia.AutoSleep
+	if !(cached.Start()-request.End() > sleep && query.Start()-cached.End() > sleep && done.Start()-fresh.End() > sleep) {
+		t.Errorf("fragments should push messages")
+	}
+
+	var frames []diagram.Rect
+	separators := 0
+	for _, op := range rec.Ops {
+		switch {
+		case op.Kind == diagram.OpRect && op.Style.Fill == nil:
+			frames = append(frames, *op.Rect)
+		case op.Kind == diagram.OpPoly && len(op.Style.Dash) > 0 && op.Points[0].Y == op.Points[1].Y:
+			separators++
+		}
+	}
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %v", frames)
+	}
+	outer, inner := frames[0], frames[1]
+	if !(outer.Min.X < inner.Min.X && inner.Max.X < outer.Max.X && outer.Min.Y < inner.Min.Y && inner.Max.Y < outer.Max.Y) {
+		t.Errorf("loop %v should be nested in alt %v", inner, outer)
+	}
+	if separators != 1 {
+		t.Errorf("expected 1 separator, got %d", separators)
+	}
+}
+
+func TestFragmentInside(t *testing.T) {
+	guard := strings.Repeat("a rather long guard ", 4)
+	dia, err := sequence.Parse(strings.NewReader(`
+A -> B: request
+opt ` + guard + `
+B -> B: self
+loop ` + guard + `
+B -> B: nested
+end
+end
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	width, height := dia.Size()
+	rec := diagram.NewRecorder(width, height)
+	dia.Draw(rec)
+
+	frames := 0
+	for _, op := range rec.Ops {
+		if op.Kind != diagram.OpRect || op.Style.Fill != nil {
+			continue
+		}
+		frames++
+		if r := *op.Rect; r.Min.X < 0 || r.Min.Y < 0 || r.Max.X > width || r.Max.Y > height {
+			t.Errorf("frame %v outside of %vx%v", r, width, height)
+		}
+	}
+	if frames != 2 {
+		t.Errorf("expected 2 frames, got %d", frames)
+	}
+}
+
+func TestFragmentParseError(t *testing.T) {
+	for _, input := range []string{"end", "else", "alt x\nA -> B: y"} {
+		if _, err := sequence.Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package sequence
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"loov.dev/diagram"
+)
+
+// Warning describes a construct that was skipped while importing a diagram.
+type Warning struct {
+	Line int
+	Text string
+}
+
+func (warning Warning) String() string { return fmt.Sprintf("line %d: %s", warning.Line, warning.Text) }
+
+// importer contains the shared state for importing other formats.
+type importer struct {
+	dia      *Diagram
+	warnings []Warning
+	line     int
+
+	// names maps participant aliases to lane names
+	names map[string]string
+	// sleep is the additional time before the next message
+	sleep Time
+	// blocks are the open blocks, true for fragments
+	blocks []bool
+}
+
+func newImporter() *importer {
+	return &importer{
+		dia:   New(),
+		names: map[string]string{},
+	}
+}
+
+// scan calls fn for each non-empty line with surrounding spaces removed.
+func (imp *importer) scan(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	for imp.line = 1; scanner.Scan(); imp.line++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return &ParseError{Line: imp.line, Err: err}
+		}
+	}
+	return scanner.Err()
+}
+
+func (imp *importer) warn(format string, args ...interface{}) {
+	imp.warnings = append(imp.warnings, Warning{
+		Line: imp.line,
+		Text: fmt.Sprintf(format, args...),
+	})
+}
+
+// declare adds a lane called name, that messages refer to by id.
+func (imp *importer) declare(id, name string) {
+	imp.names[id] = name
+	imp.dia.Lane(name)
+}
+
+// name returns the lane name for the participant id.
+func (imp *importer) name(id string) string {
+	if name, ok := imp.names[id]; ok {
+		return name
+	}
+	return id
+}
+
+// deactivate ends the innermost activation on lane when response is sent.
+func (imp *importer) deactivate(lane Role, response *Message) {
+	if imp.dia.deactivateAt(lane, response) == nil {
+		imp.warn("%q is not active", lane)
+	}
+}
+
+// note adds a note with placement such as "left of A" or "over A, B".
+func (imp *importer) note(placement, text string) {
+	where, lanes, err := parsePlacement(placement)
+	if err != nil {
+		imp.warn("invalid note placement %q: %v", placement, err)
+		return
+	}
+	for i, lane := range lanes {
+		lanes[i] = imp.name(lane)
+	}
+	imp.dia.AddNote(where, text, lanes...)
+}
+
+// begin starts a fragment.
+func (imp *importer) begin(operator Operator, guard string) {
+	imp.dia.Fragment(operator, guard)
+	imp.blocks = append(imp.blocks, true)
+}
+
+// ignore starts an unsupported block, its contents are still imported.
+func (imp *importer) ignore(keyword string) {
+	imp.warn("%q is not supported", keyword)
+	imp.blocks = append(imp.blocks, false)
+}
+
+// operand starts the next operand of the current fragment.
+func (imp *importer) operand(keyword, guard string) {
+	if len(imp.blocks) == 0 || !imp.blocks[len(imp.blocks)-1] {
+		imp.warn("%q outside of a fragment", keyword)
+		return
+	}
+	imp.dia.Else(guard)
+}
+
+// end closes the current block.
+func (imp *importer) end() {
+	if len(imp.blocks) == 0 {
+		imp.warn("end without a block")
+		return
+	}
+	if imp.blocks[len(imp.blocks)-1] {
+		imp.dia.EndFragment()
+	}
+	imp.blocks = imp.blocks[:len(imp.blocks)-1]
+}
+
+// finish reports blocks that were not closed.
+func (imp *importer) finish() {
+	if len(imp.blocks) > 0 {
+		imp.warn("missing end for %d blocks", len(imp.blocks))
+	}
+}
+
+// arrow describes how a message line is drawn.
+type arrow struct {
+	dotted bool
+	open   bool // without arrow head
+	failed bool
+	stroke color.Color
+}
+
+// send adds a message between participants.
+func (imp *importer) send(from, to, text string, arrow arrow) *Message {
+	message := Send(imp.name(from), imp.name(to), text)
+	if imp.sleep != 0 {
+		message.Sleeping(imp.dia.AutoSleep + imp.sleep)
+		imp.sleep = 0
+	}
+	if arrow.failed {
+		message.Failed()
+	}
+
+	if arrow.dotted || arrow.open || arrow.stroke != nil {
+		line := imp.dia.Theme.Send
+		if arrow.dotted {
+			line.Dash = []diagram.Length{4}
+		}
+		if arrow.open {
+			line.EndMarker = diagram.MarkerNone
+		}
+		if arrow.stroke != nil {
+			line.Stroke = arrow.stroke
+		}
+		message.Line = line
+	}
+
+	imp.dia.Add(message)
+	return message
+}
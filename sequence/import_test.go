@@ -0,0 +1,209 @@
+package sequence_test
+
+import (
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/sequence"
+)
+
+type expectMessage struct {
+	From, To, Text string
+	Dashed         bool
+	Marker         diagram.Marker
+}
+
+func checkMessages(t *testing.T, dia *sequence.Diagram, expected []expectMessage) {
+	t.Helper()
+	if len(dia.Messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %d", len(expected), len(dia.Messages))
+	}
+	for i, exp := range expected {
+		message := dia.Messages[i]
+		line := message.Line.Or(dia.Theme.Send)
+		got := expectMessage{
+			From:   message.From,
+			To:     message.To,
+			Text:   message.Text,
+			Dashed: len(line.Dash) > 0,
+			Marker: line.EndMarker,
+		}
+		if got != exp {
+			t.Errorf("%d: expected %+v, got %+v", i, exp, got)
+		}
+	}
+}
+
+func checkWarnings(t *testing.T, warnings []sequence.Warning, lines ...int) {
+	t.Helper()
+	var got []int
+	for _, warning := range warnings {
+		got = append(got, warning.Line)
+	}
+	if len(got) != len(lines) {
+		t.Fatalf("expected warnings on lines %v, got %v", lines, warnings)
+	}
+	for i := range got {
+		if got[i] != lines[i] {
+			t.Fatalf("expected warnings on lines %v, got %v", lines, warnings)
+		}
+	}
+}
+
+func TestParseMermaid(t *testing.T) {
+	dia, warnings, err := sequence.ParseMermaid(strings.NewReader(`sequenceDiagram
+    %% comment
+    participant A as Alice
+    actor B
+    A->>B: Hello<br/>Bob
+    B-->>A: Hi
+    A->B: plain
+    A-->B: dotted
+    A-xB: lost
+    Note right of B: note
+    loop Every minute
+        A-)+B: async
+    end
+    autonumber
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dia.Lanes) != 2 || dia.Lanes[0].Name != "Alice" || dia.Lanes[1].Name != "B" {
+		t.Errorf("invalid lanes %v %v", dia.Lanes[0].Name, dia.Lanes[1].Name)
+	}
+	checkMessages(t, dia, []expectMessage{
+		{"Alice", "B", "Hello\nBob", false, diagram.MarkerArrow},
+		{"B", "Alice", "Hi", true, diagram.MarkerArrow},
+		{"Alice", "B", "plain", false, diagram.MarkerNone},
+		{"Alice", "B", "dotted", true, diagram.MarkerNone},
+		{"Alice", "B", "lost", false, diagram.MarkerArrow},
+		{"Alice", "B", "async", false, diagram.MarkerArrow},
+	})
+	checkWarnings(t, warnings, 14)
+
+	if len(dia.Fragments) != 1 || dia.Fragments[0].Operator != sequence.Loop || dia.Fragments[0].Operands[0].Guard != "Every minute" ||
+		dia.Fragments[0].Operands[0].First != dia.Messages[5] {
+		t.Errorf("invalid fragments %+v", dia.Fragments)
+	}
+
+	if len(dia.Notes) != 1 || dia.Notes[0].Placement != sequence.RightOf || dia.Notes[0].Lanes[0] != "B" || dia.Notes[0].Text != "note" {
+		t.Errorf("invalid notes %+v", dia.Notes)
+	}
+
+	if len(dia.Activations) != 1 || dia.Activations[0].Lane != "B" || dia.Activations[0].Request != dia.Messages[5] {
+		t.Errorf("expected activation of B after async")
+	}
+
+	if _, _, err := sequence.ParseMermaid(strings.NewReader("graph TD\nA-->B")); err == nil {
+		t.Errorf("expected error for flowchart")
+	}
+}
+
+func TestParsePlantUML(t *testing.T) {
+	dia, warnings, err := sequence.ParsePlantUML(strings.NewReader(`@startuml
+' comment
+participant "Long Name" as L
+actor Bob #red
+L -> Bob : hello
+Bob --> L : reply
+Bob <- L : reversed
+L -[#ff0000]>x Bob : lost
+...
+L -> Bob ++ : activate
+note left of L
+  multi-line note
+  endpoints are listed
+endnote
+/' block
+comment '/
+alt success
+  Bob -> L
+end
+@enduml
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dia.Lanes) != 2 || dia.Lanes[0].Name != "Long Name" || dia.Lanes[1].Name != "Bob" {
+		t.Errorf("invalid lanes %v %v", dia.Lanes[0].Name, dia.Lanes[1].Name)
+	}
+	checkMessages(t, dia, []expectMessage{
+		{"Long Name", "Bob", "hello", false, diagram.MarkerArrow},
+		{"Bob", "Long Name", "reply", true, diagram.MarkerArrow},
+		{"Long Name", "Bob", "reversed", false, diagram.MarkerArrow},
+		{"Long Name", "Bob", "lost", false, diagram.MarkerArrow},
+		{"Long Name", "Bob", "activate", false, diagram.MarkerArrow},
+		{"Bob", "Long Name", "", false, diagram.MarkerArrow},
+	})
+	checkWarnings(t, warnings, 4)
+
+	if len(dia.Fragments) != 1 || dia.Fragments[0].Operator != sequence.Alt || dia.Fragments[0].Operands[0].Guard != "success" ||
+		dia.Fragments[0].Operands[0].Last != dia.Messages[5] {
+		t.Errorf("invalid fragments %+v", dia.Fragments)
+	}
+
+	if len(dia.Notes) != 1 || dia.Notes[0].Placement != sequence.LeftOf || dia.Notes[0].Lanes[0] != "Long Name" || dia.Notes[0].Text != "multi-line note\nendpoints are listed" {
+		t.Errorf("invalid notes %+v", dia.Notes)
+	}
+
+	if len(dia.Activations) != 1 || dia.Activations[0].Lane != "Bob" || dia.Activations[0].Request != dia.Messages[4] {
+		t.Errorf("expected activation of Bob after activate")
+	}
+
+	if delayed := dia.Messages[4]; delayed.Sleep != dia.AutoSleep+1 {
+		t.Errorf("expected delay, got sleep %v", delayed.Sleep)
+	}
+	if lost := dia.Messages[3]; lost.Line.Stroke == nil {
+		t.Errorf("expected colored line")
+	}
+}
+
+func TestParsePlantUMLParticipants(t *testing.T) {
+	dia, warnings, err := sequence.ParsePlantUML(strings.NewReader(`@startuml
+participant L as "Long Name"
+Participant "Other Name" as O
+ACTOR my-service
+my-service -> L : dashed name
+my-service->O
+BOX "Edge"
+L --> my-service : inside box
+END BOX
+NOTE LEFT OF L
+  shouting
+END NOTE
+ALT yes
+  O -> L
+End
+@enduml
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lanes []string
+	for _, lane := range dia.Lanes {
+		lanes = append(lanes, lane.Name)
+	}
+	if strings.Join(lanes, ",") != "Long Name,Other Name,my-service" {
+		t.Errorf("invalid lanes %q", lanes)
+	}
+	checkMessages(t, dia, []expectMessage{
+		{"my-service", "Long Name", "dashed name", false, diagram.MarkerArrow},
+		{"my-service", "Other Name", "", false, diagram.MarkerArrow},
+		{"Long Name", "my-service", "inside box", true, diagram.MarkerArrow},
+		{"Other Name", "Long Name", "", false, diagram.MarkerArrow},
+	})
+	// only the box is not supported
+	checkWarnings(t, warnings, 7)
+
+	if len(dia.Notes) != 1 || dia.Notes[0].Lanes[0] != "Long Name" || dia.Notes[0].Text != "shouting" {
+		t.Errorf("invalid notes %+v", dia.Notes)
+	}
+	if len(dia.Fragments) != 1 || dia.Fragments[0].Operator != sequence.Alt || dia.Fragments[0].Operands[0].Guard != "yes" {
+		t.Errorf("invalid fragments %+v", dia.Fragments)
+	}
+}
@@ -0,0 +1,119 @@
+package sequence
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	mermaidParticipant = regexp.MustCompile(`^(?:participant|actor)\s+(\S+)(?:\s+as\s+(.+))?$`)
+	mermaidMessage     = regexp.MustCompile(`^([^\s:+\->][^:]*?)\s*(-->>|->>|--x|-x|--\)|-\)|-->|->)\s*([+-]?)\s*([^\s:+\-][^:]*?)\s*(?::\s*(.*))?$`)
+	mermaidActivation  = regexp.MustCompile(`^(activate|deactivate)\s+(\S+)$`)
+	mermaidNote        = regexp.MustCompile(`^(?i)note\s+([^:]+?)\s*:\s*(.*)$`)
+	mermaidBlock       = regexp.MustCompile(`^(alt|else|opt|loop|par|and|critical|option|break|rect|box|end)(?:\s+(.*))?$`)
+	mermaidBreak       = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// mermaidKeywords are statements that are recognized, but not supported.
+var mermaidKeywords = []string{
+	"autonumber", "title", "create", "destroy", "link", "links", "properties", "details",
+}
+
+// ParseMermaid reads a Mermaid sequenceDiagram.
+//
+// Participants, actors, messages, activations, notes and
+// fragments are imported. Statements that
+// cannot be represented are skipped and reported as warnings.
+func ParseMermaid(r io.Reader) (*Diagram, []Warning, error) {
+	imp := newImporter()
+
+	header := false
+	err := imp.scan(r, func(line string) error {
+		if strings.HasPrefix(line, "%%") {
+			return nil
+		}
+		if !header {
+			if line != "sequenceDiagram" {
+				return errors.New("expected sequenceDiagram")
+			}
+			header = true
+			return nil
+		}
+
+		if match := mermaidParticipant.FindStringSubmatch(line); match != nil {
+			id, name := match[1], strings.TrimSpace(match[2])
+			if name == "" {
+				name = id
+			}
+			imp.declare(id, mermaidBreak.ReplaceAllString(name, "\n"))
+			return nil
+		}
+
+		if match := mermaidMessage.FindStringSubmatch(line); match != nil {
+			from, kind, activation, to, text := match[1], match[2], match[3], match[4], match[5]
+
+			message := imp.send(from, to, mermaidBreak.ReplaceAllString(text, "\n"), arrow{
+				dotted: strings.HasPrefix(kind, "--"),
+				open:   kind == "->" || kind == "-->",
+				failed: strings.HasSuffix(kind, "x"),
+			})
+			switch activation {
+			case "+":
+				imp.dia.activateAfter(message.To, message)
+			case "-":
+				imp.deactivate(message.From, message)
+			}
+			return nil
+		}
+
+		if match := mermaidActivation.FindStringSubmatch(line); match != nil {
+			if match[1] == "activate" {
+				imp.dia.activateAfter(imp.name(match[2]), imp.dia.lastMessage())
+			} else {
+				imp.deactivate(imp.name(match[2]), imp.dia.lastMessage())
+			}
+			return nil
+		}
+
+		if match := mermaidNote.FindStringSubmatch(line); match != nil {
+			imp.note(match[1], mermaidBreak.ReplaceAllString(match[2], "\n"))
+			return nil
+		}
+
+		if match := mermaidBlock.FindStringSubmatch(line); match != nil {
+			keyword, guard := match[1], match[2]
+			switch keyword {
+			case "alt", "opt", "loop", "par", "critical", "break":
+				imp.begin(Operator(keyword), guard)
+			case "else", "and", "option":
+				imp.operand(keyword, guard)
+			case "end":
+				imp.end()
+			default:
+				imp.ignore(keyword)
+			}
+			return nil
+		}
+
+		keyword := strings.Fields(line)[0]
+		for _, unsupported := range mermaidKeywords {
+			if strings.EqualFold(keyword, unsupported) {
+				imp.warn("%q is not supported", keyword)
+				return nil
+			}
+		}
+		imp.warn("unrecognized %q", line)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	imp.finish()
+	if !header {
+		return nil, nil, &ParseError{Line: imp.line, Err: errors.New("expected sequenceDiagram")}
+	}
+
+	return imp.dia, imp.warnings, nil
+}
@@ -0,0 +1,237 @@
+package sequence
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"loov.dev/diagram"
+)
+
+// Placement is the position of a note relative to its lanes.
+type Placement int
+
+const (
+	RightOf Placement = iota
+	LeftOf
+	// Over spans the note over one or more lanes.
+	Over
+)
+
+// Note is a text box on the timeline.
+//
+// Notes take up time, such that later messages are placed after them.
+type Note struct {
+	Placement Placement
+	Lanes     []Role
+	Text      string
+
+	// After is the message the note follows in automatic layout,
+	// nil places it at the start of the diagram.
+	After *Message
+
+	When  Time
+	Sleep Time
+
+	Style   diagram.Style
+	Caption diagram.Style
+
+	// duration is the time the note takes, based on its height.
+	duration Time
+}
+
+// AddNote adds a note after the last message.
+// RightOf and LeftOf use the first lane, Over spans all lanes.
+func (dia *Diagram) AddNote(placement Placement, text string, lanes ...Role) *Note {
+	note := &Note{
+		Placement: placement,
+		Lanes:     lanes,
+		Text:      text,
+		After:     dia.lastMessage(),
+		When:      Automatic,
+		Sleep:     Automatic,
+	}
+	dia.Notes = append(dia.Notes, note)
+	return note
+}
+
+func (note *Note) Start() Time { return note.When }
+func (note *Note) End() Time   { return note.When + note.duration }
+
+func (note *Note) At(at Time) *Note                 { note.When = at; return note }
+func (note *Note) Sleeping(sleep Time) *Note        { note.Sleep = sleep; return note }
+func (note *Note) Styled(style diagram.Style) *Note { note.Style = style; return note }
+
+// noteCaption returns the text style of note.
+func (dia *Diagram) noteCaption(note *Note) *diagram.Style {
+	caption := note.Caption.Or(dia.Theme.NoteCaption)
+	caption.Origin = diagram.P(-1, -1)
+	if caption.Wrap == 0 {
+		caption.Wrap = dia.Theme.NoteWidth - 2*dia.Theme.NotePadding
+	}
+	return caption
+}
+
+// noteText returns the bounds of the text of note relative to the anchor.
+func (dia *Diagram) noteText(note *Note) diagram.Rect {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+	return measurer.Measure(note.Text, dia.noteCaption(note))
+}
+
+// noteSize returns the size of the box fitted to the text of note.
+func (dia *Diagram) noteSize(note *Note) diagram.Point {
+	text := dia.noteText(note).Size()
+	padding := dia.Theme.NotePadding
+	// leave space for the folded corner
+	return diagram.P(text.X+3*padding, text.Y+2*padding)
+}
+
+// noteLanes returns the first and the last lane of note.
+func (dia *Diagram) noteLanes(note *Note) (first, last *Lane) {
+	for _, name := range note.Lanes {
+		lane := dia.Lane(name)
+		if first == nil || lane.Order < first.Order {
+			first = lane
+		}
+		if last == nil || lane.Order > last.Order {
+			last = lane
+		}
+	}
+	return first, last
+}
+
+// noteExtent returns the horizontal extent of note with the specified width.
+func (dia *Diagram) noteExtent(note *Note, width diagram.Length) (left, right diagram.Length) {
+	first, last := dia.noteLanes(note)
+	if first == nil {
+		return 0, 0
+	}
+
+	gap := dia.Theme.ActivationWidth
+	switch note.Placement {
+	case LeftOf:
+		return first.Center - gap - width, first.Center - gap
+	case RightOf:
+		return first.Center + gap, first.Center + gap + width
+	default:
+		left, right = first.Center-gap, last.Center+gap
+		if extra := width - (right - left); extra > 0 {
+			left, right = left-extra/2, right+extra/2
+		}
+		return left, right
+	}
+}
+
+// layoutNotes widens lanes to fit notes next to them.
+func (dia *Diagram) layoutNotes() {
+	padding := dia.Theme.LanePadding
+	gap := dia.Theme.ActivationWidth
+	for _, note := range dia.Notes {
+		first, last := dia.noteLanes(note)
+		if first == nil {
+			continue
+		}
+		width := dia.noteSize(note).X
+
+		switch note.Placement {
+		case RightOf, LeftOf:
+			// the note must fit between the lane and its neighbour
+			neighbour := first.Order + 1
+			if note.Placement == LeftOf {
+				neighbour = first.Order - 1
+			}
+			needed := width + gap + padding
+			if neighbour < 0 || neighbour >= len(dia.Lanes) {
+				first.Width = math.Max(first.Width, 2*needed)
+				continue
+			}
+			other := dia.Lanes[neighbour]
+			if available := (first.Width + other.Width) / 2; available < needed {
+				extra := needed - available
+				first.Width += extra
+				other.Width += extra
+			}
+		case Over:
+			available := first.Width
+			if first != last {
+				available = (first.Width + last.Width) / 2
+				for _, lane := range dia.Lanes[first.Order+1 : last.Order] {
+					available += lane.Width
+				}
+			}
+			if needed := width + 2*padding; available < needed {
+				extra := (needed - available) / float64(last.Order-first.Order+1)
+				for _, lane := range dia.Lanes[first.Order : last.Order+1] {
+					lane.Width += extra
+				}
+			}
+		}
+	}
+}
+
+// drawNote draws note as a box with a folded corner.
+func (dia *Diagram) drawNote(canvas diagram.Canvas, note *Note, top diagram.Length) {
+	size := dia.noteSize(note)
+	left, right := dia.noteExtent(note, size.X)
+	bottom := top + size.Y
+
+	fold := dia.Theme.NotePadding
+	style := note.Style.Or(dia.Theme.Note)
+	canvas.Poly(diagram.Ps(
+		left, top,
+		right-fold, top,
+		right, top+fold,
+		right, bottom,
+		left, bottom,
+		left, top,
+	), style)
+	canvas.Poly(diagram.Ps(
+		right-fold, top,
+		right-fold, top+fold,
+		right, top+fold,
+	), &diagram.Style{Stroke: style.Stroke, Size: style.Size})
+
+	padding := dia.Theme.NotePadding
+	textLeft := left + padding
+	if width := right - left; width > size.X {
+		// center text in wide notes spanning lanes
+		textLeft += (width - size.X) / 2
+	}
+	text := dia.noteText(note)
+	canvas.Text(note.Text, diagram.P(textLeft-text.Min.X, top+padding-text.Min.Y), dia.noteCaption(note))
+}
+
+// parsePlacement parses "left of A", "right of A" or "over A, B".
+func parsePlacement(s string) (Placement, []Role, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	lower := strings.ToLower(s)
+
+	var placement Placement
+	var rest string
+	switch {
+	case strings.HasPrefix(lower, "left of "):
+		placement, rest = LeftOf, s[len("left of "):]
+	case strings.HasPrefix(lower, "right of "):
+		placement, rest = RightOf, s[len("right of "):]
+	case strings.HasPrefix(lower, "over "):
+		placement, rest = Over, s[len("over "):]
+	default:
+		return 0, nil, errors.New("expected left of, right of or over")
+	}
+
+	var lanes []Role
+	for _, name := range strings.Split(rest, ",") {
+		name = strings.Trim(strings.TrimSpace(name), `"`)
+		if name == "" {
+			return 0, nil, errors.New("expected lane name")
+		}
+		lanes = append(lanes, name)
+	}
+	if placement != Over && len(lanes) != 1 {
+		return 0, nil, errors.New("expected a single lane")
+	}
+	return placement, lanes, nil
+}
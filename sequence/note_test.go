@@ -0,0 +1,52 @@
+package sequence_test
+
+import (
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/sequence"
+)
+
+func TestNote(t *testing.T) {
+	dia, err := sequence.Parse(strings.NewReader(`
+Client -> Server: request
+note right of Server: a long note that needs to be wrapped over multiple lines to fit
+Server -> Client: response
+note over Client, Server: spanning
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := diagram.NewRecorder(dia.Size())
+	dia.Draw(rec)
+
+	request, response := dia.Messages[0], dia.Messages[1]
+	wrapped, spanning := dia.Notes[0], dia.Notes[1]
+
+	if wrapped.After != request || spanning.After != response {
+		t.Fatalf("notes should follow messages")
+	}
+	if !(request.End() < wrapped.Start() && wrapped.End() < response.Start() && response.End() < spanning.Start()) {
+		t.Errorf("notes should push later messages: request %v-%v, note %v-%v, response %v-%v, note %v",
+			request.Start(), request.End(), wrapped.Start(), wrapped.End(), response.Start(), response.End(), spanning.Start())
+	}
+	if wrapped.End()-wrapped.Start() <= spanning.End()-spanning.Start() {
+		t.Errorf("wrapped note should be taller")
+	}
+
+	width, _ := dia.Size()
+	for _, layer := range rec.Layers {
+		for _, op := range layer.Ops {
+			if op.Kind != diagram.OpPoly {
+				continue
+			}
+			for _, p := range op.Points {
+				if p.X < 0 || p.X > width {
+					t.Errorf("note outside of diagram: %v", op.Points)
+				}
+			}
+		}
+	}
+}
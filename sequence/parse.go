@@ -0,0 +1,408 @@
+package sequence
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"loov.dev/diagram"
+)
+
+// ParseError is an error at a specific line of the input.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (err *ParseError) Error() string { return fmt.Sprintf("line %d: %v", err.Line, err.Err) }
+func (err *ParseError) Unwrap() error { return err.Err }
+
+// Parse reads a diagram from a line oriented description:
+//
+//	# lines starting with a hash are comments
+//	# participant declares a lane, lanes are otherwise ordered by first use
+//	participant Client
+//	# default time between messages and the time a message takes
+//	autosleep 0.5
+//	autodelay 0.5
+//
+//	Client -> Server: Init
+//	Server --> Client: dashed line
+//	Client x-> Server: failed message
+//	"Web Server" -> DB @+1.5 delay 3: sleep 1.5 and take 3 time units
+//	DB -> "Web Server" @4 align end: starts exactly at 4
+//
+//	# activations start when the previous message arrives
+//	# and end when the previous message is sent, or at an explicit time
+//	activate Server
+//	deactivate Server
+//	activate Client @2
+//
+//	# fragments alt, opt, loop, par, critical and break frame messages
+//	# until end, else and "and" start new operands
+//	alt cache hit
+//	Server -> Client: cached
+//	else
+//	Server -> DB: query
+//	end
+//
+//	# notes are placed after the previous message
+//	note left of Client: text
+//	note over Client, "Web Server": spanning multiple lanes
+//
+// Options are specified between the target and the colon:
+//
+//	@T, at T        start at time T
+//	@+T, @-T        sleep T after the end of the previous message
+//	sleep T         same as @+T
+//	delay T         the message takes T time units
+//	align start     place text near start, also "end" and "center"
+//	stroke #rrggbb  line color, width N sets line width, dashed makes it dashed
+//	color #rrggbb   text color, size N sets text size
+//
+// Names containing spaces or arrows must be quoted and failed arrows
+// must be separated from the sender by whitespace. Text may contain \n
+// for line breaks.
+func Parse(r io.Reader) (*Diagram, error) {
+	dia := New()
+
+	scanner := bufio.NewScanner(r)
+	number := 1
+	for ; scanner.Scan(); number++ {
+		if err := dia.parseLine(scanner.Text()); err != nil {
+			return nil, &ParseError{Line: number, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(dia.open) > 0 {
+		return nil, &ParseError{Line: number - 1, Err: fmt.Errorf("missing end for %v", dia.open[len(dia.open)-1].Operator)}
+	}
+
+	return dia, nil
+}
+
+// arrows lists the valid arrows, longest first.
+var arrows = []string{"x-->", "x->", "-->", "->"}
+
+func (dia *Diagram) parseLine(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+
+	if fields := strings.Fields(line); len(fields) == 1 || !isArrow(fields[1]) {
+		guard := strings.TrimSpace(line[len(fields[0]):])
+		switch fields[0] {
+		case string(Alt), string(Opt), string(Loop), string(Par), string(Critical), string(Break):
+			dia.Fragment(Operator(fields[0]), guard)
+			return nil
+		case "else", "and":
+			if dia.Else(guard) == nil {
+				return fmt.Errorf("%v outside of a fragment", fields[0])
+			}
+			return nil
+		case "end":
+			if dia.EndFragment() == nil {
+				return errors.New("end outside of a fragment")
+			}
+			return nil
+		}
+	}
+
+	header, text, hasText := splitText(line)
+	tokens, err := tokenize(header)
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) > 0 && strings.EqualFold(tokens[0], "note") && hasText {
+		placement, lanes, err := parsePlacement(strings.TrimSpace(header)[len("note"):])
+		if err != nil {
+			return err
+		}
+		dia.AddNote(placement, strings.Replace(strings.TrimSpace(text), `\n`, "\n", -1), lanes...)
+		return nil
+	}
+
+	if len(tokens) < 2 || !isArrow(tokens[1]) {
+		if hasText {
+			return fmt.Errorf("expected message, got %q", line)
+		}
+		return dia.parseDirective(tokens)
+	}
+
+	if len(tokens) < 3 || isArrow(tokens[2]) {
+		return fmt.Errorf("expected target after %q", tokens[1])
+	}
+
+	text = strings.Replace(strings.TrimSpace(text), `\n`, "\n", -1)
+	message := Send(tokens[0], tokens[2], text)
+
+	arrow := tokens[1]
+	if strings.HasPrefix(arrow, "x") {
+		message.Failed()
+	}
+
+	var send, caption *diagram.Style
+	lineStyle := func() *diagram.Style {
+		if send == nil {
+			send = message.Line.Or(dia.Theme.Send)
+		}
+		return send
+	}
+	captionStyle := func() *diagram.Style {
+		if caption == nil {
+			caption = message.Caption.Or(dia.Theme.Message)
+		}
+		return caption
+	}
+
+	if strings.Contains(arrow, "--") {
+		lineStyle().Dash = []diagram.Length{4}
+	}
+
+	options := tokens[3:]
+	for len(options) > 0 {
+		option := options[0]
+		options = options[1:]
+
+		value := func() (string, error) {
+			if len(options) == 0 {
+				return "", fmt.Errorf("option %q requires a value", option)
+			}
+			v := options[0]
+			options = options[1:]
+			return v, nil
+		}
+		number := func() (float64, error) {
+			v, err := value()
+			if err != nil {
+				return 0, err
+			}
+			return parseNumber(v)
+		}
+
+		switch {
+		case strings.HasPrefix(option, "@+") || strings.HasPrefix(option, "@-"):
+			sleep, err := parseNumber(option[1:])
+			if err != nil {
+				return err
+			}
+			message.Sleeping(sleep)
+		case strings.HasPrefix(option, "@"):
+			at, err := parseNumber(option[1:])
+			if err != nil {
+				return err
+			}
+			message.At(at)
+		case option == "at":
+			at, err := number()
+			if err != nil {
+				return err
+			}
+			message.At(at)
+		case option == "sleep":
+			sleep, err := number()
+			if err != nil {
+				return err
+			}
+			message.Sleeping(sleep)
+		case option == "delay":
+			delay, err := number()
+			if err != nil {
+				return err
+			}
+			message.Delayed(delay)
+		case option == "align":
+			v, err := value()
+			if err != nil {
+				return err
+			}
+			switch v {
+			case "start":
+				message.StartAlign()
+			case "end":
+				message.EndAlign()
+			case "center":
+				message.align = 0
+			default:
+				return fmt.Errorf("expected start, end or center for align, got %q", v)
+			}
+		case option == "stroke":
+			v, err := value()
+			if err != nil {
+				return err
+			}
+			c, err := diagram.ParseHexColor(v)
+			if err != nil {
+				return err
+			}
+			lineStyle().Stroke = c
+		case option == "width":
+			width, err := number()
+			if err != nil {
+				return err
+			}
+			lineStyle().Size = width
+		case option == "dashed":
+			lineStyle().Dash = []diagram.Length{4}
+		case option == "color":
+			v, err := value()
+			if err != nil {
+				return err
+			}
+			c, err := diagram.ParseHexColor(v)
+			if err != nil {
+				return err
+			}
+			captionStyle().Fill = c
+		case option == "size":
+			size, err := number()
+			if err != nil {
+				return err
+			}
+			captionStyle().Size = size
+		default:
+			return fmt.Errorf("unknown option %q", option)
+		}
+	}
+
+	if send != nil {
+		message.Line = *send
+	}
+	if caption != nil {
+		message.Caption = *caption
+	}
+
+	dia.Add(message)
+	return nil
+}
+
+func (dia *Diagram) parseDirective(tokens []string) error {
+	switch tokens[0] {
+	case "participant":
+		if len(tokens) != 2 {
+			return errors.New("expected participant name")
+		}
+		dia.Lane(tokens[1])
+	case "activate", "deactivate":
+		activate := tokens[0] == "activate"
+		switch {
+		case len(tokens) == 2 && activate:
+			dia.activateAfter(tokens[1], dia.lastMessage())
+		case len(tokens) == 2:
+			if dia.deactivateAt(tokens[1], dia.lastMessage()) == nil {
+				return fmt.Errorf("%q is not active", tokens[1])
+			}
+		case len(tokens) == 3 && strings.HasPrefix(tokens[2], "@"):
+			at, err := parseNumber(tokens[2][1:])
+			if err != nil {
+				return err
+			}
+			if activate {
+				dia.Activate(tokens[1], at)
+			} else if dia.Deactivate(tokens[1], at) == nil {
+				return fmt.Errorf("%q is not active", tokens[1])
+			}
+		default:
+			return fmt.Errorf("expected %v name and optional @time", tokens[0])
+		}
+	case "autosleep", "autodelay":
+		if len(tokens) != 2 {
+			return fmt.Errorf("expected %v value", tokens[0])
+		}
+		v, err := parseNumber(tokens[1])
+		if err != nil {
+			return err
+		}
+		if tokens[0] == "autosleep" {
+			dia.AutoSleep = v
+		} else {
+			dia.AutoDelay = v
+		}
+	default:
+		return fmt.Errorf("unknown directive %q", tokens[0])
+	}
+	return nil
+}
+
+// parseNumber parses a finite time or length.
+func parseNumber(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("expected number, got %q", s)
+	}
+	return v, nil
+}
+
+// splitText splits line at the first colon outside of quotes.
+func splitText(line string) (header, text string, ok bool) {
+	quoted := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				return line[:i], line[i+1:], true
+			}
+		}
+	}
+	return line, "", false
+}
+
+// tokenize splits s into quoted or plain names, arrows and options.
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return tokens, nil
+		}
+
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return nil, errors.New("missing closing quote")
+			}
+			tokens = append(tokens, s[1:end+1])
+			s = s[end+2:]
+			continue
+		}
+
+		if arrow := arrowPrefix(s); arrow != "" {
+			tokens = append(tokens, arrow)
+			s = s[len(arrow):]
+			continue
+		}
+
+		// plain tokens end at whitespace or an arrow
+		end := 1
+		for end < len(s) && s[end] != ' ' && s[end] != '\t' && s[end] != '"' {
+			if s[end] == '-' && arrowPrefix(s[end:]) != "" {
+				break
+			}
+			end++
+		}
+		tokens = append(tokens, s[:end])
+		s = s[end:]
+	}
+}
+
+// arrowPrefix returns the arrow at the start of s.
+func arrowPrefix(s string) string {
+	for _, arrow := range arrows {
+		if strings.HasPrefix(s, arrow) {
+			return arrow
+		}
+	}
+	return ""
+}
+
+func isArrow(token string) bool { return arrowPrefix(token) == token }
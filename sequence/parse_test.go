@@ -0,0 +1,98 @@
+package sequence_test
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/sequence"
+)
+
+func TestParse(t *testing.T) {
+	dia, err := sequence.Parse(strings.NewReader(`
+# example
+participant Server
+autosleep 1
+autodelay 0.25
+
+Client -> Server: Init
+Server --> "DNS Server": Update\nrecords
+"DNS Server"->Server @+1.5 delay 3: ACK
+Server x-> Client @10 align end stroke #ff0000 width 2 color #00ff00 size 10: Data
+Client -> Server sleep -0.5 align start: Retry
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lanes []string
+	for _, lane := range dia.Lanes {
+		lanes = append(lanes, lane.Name)
+	}
+	if got := strings.Join(lanes, ","); got != "Server" {
+		t.Errorf("got lanes %q", got)
+	}
+	if dia.AutoSleep != 1 || dia.AutoDelay != 0.25 {
+		t.Errorf("got autosleep %v autodelay %v", dia.AutoSleep, dia.AutoDelay)
+	}
+
+	if len(dia.Messages) != 5 {
+		t.Fatalf("got %d messages", len(dia.Messages))
+	}
+	init, update, ack, data, retry := dia.Messages[0], dia.Messages[1], dia.Messages[2], dia.Messages[3], dia.Messages[4]
+
+	if init.From != "Client" || init.To != "Server" || init.Text != "Init" || !init.Line.IsZero() {
+		t.Errorf("invalid init: %+v", init)
+	}
+	if update.To != "DNS Server" || update.Text != "Update\nrecords" || len(update.Line.Dash) == 0 {
+		t.Errorf("invalid update: %+v", update)
+	}
+	if ack.From != "DNS Server" || ack.Sleep != 1.5 || ack.Delay != 3 || !sequence.IsAutomatic(ack.When) {
+		t.Errorf("invalid ack: %+v", ack)
+	}
+	if data.When != 10 || data.Line.Size != 2 || data.Caption.Size != 10 ||
+		data.Line.Stroke != (color.NRGBA{0xFF, 0, 0, 0xFF}) ||
+		data.Caption.Fill != (color.NRGBA{0, 0xFF, 0, 0xFF}) ||
+		data.Line.EndMarker != diagram.MarkerArrow {
+		t.Errorf("invalid data: %+v", data)
+	}
+	if retry.Sleep != -0.5 {
+		t.Errorf("invalid retry: %+v", retry)
+	}
+
+	svg := diagram.NewSVG(dia.Size())
+	dia.Draw(svg)
+	if output := string(svg.Bytes()); strings.Contains(output, "NaN") {
+		t.Errorf("invalid output:\n%s", output)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"A -> B: ok\nA -> : missing", 2},
+		{"A -> B @x: bad time", 1},
+		{"\n\nA -> B delay: missing value", 3},
+		{"A -> B align middle: bad align", 1},
+		{"A -> B stroke red: bad color", 1},
+		{"A -> B: ok\n\"A -> B: unterminated", 2},
+		{"A -> B: ok\nunknown 1", 2},
+		{"A B: not a message", 1},
+	}
+
+	for _, test := range tests {
+		_, err := sequence.Parse(strings.NewReader(test.input))
+		var perr *sequence.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: expected parse error, got %v", test.input, err)
+			continue
+		}
+		if perr.Line != test.line {
+			t.Errorf("%q: expected line %d, got %v", test.input, test.line, err)
+		}
+	}
+}
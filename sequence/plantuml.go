@@ -0,0 +1,215 @@
+package sequence
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"loov.dev/diagram"
+)
+
+// plantName matches unquoted participant names, dashes
+// are allowed when they do not start an arrow.
+const plantName = `[^\s"<>:\-\[\]]+(?:-[^\s"<>:\-\[\]]+)*`
+
+var (
+	plantParticipant = regexp.MustCompile(`^(?i:participant|actor|boundary|control|entity|database|collections|queue)\s+("[^"]+"|[^\s"]+)(?:\s+(?i:as)\s+("[^"]+"|[^\s"]+))?(?:\s+(.*))?$`)
+	plantMessage     = regexp.MustCompile(`^("[^"]+"|` + plantName + `)\s*(x?<<?-(?:\[[^\]]*\])?-?|-(?:\[[^\]]*\])?-?>>?x?)\s*("[^"]+"|` + plantName + `)(\s*(?:\+\+|--|\*\*|!!))?\s*(?::\s*(.*))?$`)
+	plantActivation  = regexp.MustCompile(`^(?i)(activate|deactivate)\s+("[^"]+"|\S+)(?:\s+#\S+)?$`)
+	plantNote        = regexp.MustCompile(`^(?i)[hr]?note\s+((?:left|right)\s+of\s+.+?|over\s+.+?)(?:\s+#\S+)?\s*(?::\s*(.*))?$`)
+	plantBlock       = regexp.MustCompile(`^(?i)(alt|else|opt|loop|par|break|critical|group|box|end)(?:\s+(.*))?$`)
+	plantArrowStyle  = regexp.MustCompile(`\[([^\]]*)\]`)
+
+	plantNoteEnd      = regexp.MustCompile(`^(?i)end\s?[hr]?note$`)
+	plantRefEnd       = regexp.MustCompile(`^(?i)end(?:\s?ref)?$`)
+	plantSkinparamEnd = regexp.MustCompile(`^}`)
+)
+
+// plantKeywords are statements that are recognized, but not supported.
+var plantKeywords = []string{
+	"destroy", "return", "autoactivate",
+	"title", "header", "footer", "legend", "autonumber", "newpage", "hide", "show",
+	"skinparam", "==",
+}
+
+// ParsePlantUML reads a PlantUML sequence diagram.
+//
+// Participants, messages, activations, notes, fragments and
+// delays are imported. Statements that
+// cannot be represented are skipped and reported as warnings.
+func ParsePlantUML(r io.Reader) (*Diagram, []Warning, error) {
+	imp := newImporter()
+
+	// skipUntil matches the line ending a skipped multi-line statement
+	var skipUntil *regexp.Regexp
+	comment := false
+
+	// note is the placement of a multi-line note and lines its text
+	note, lines := "", []string{}
+
+	err := imp.scan(r, func(line string) error {
+		switch {
+		case note != "":
+			if plantNoteEnd.MatchString(line) {
+				imp.note(note, strings.Join(lines, "\n"))
+				note, lines = "", nil
+			} else {
+				lines = append(lines, line)
+			}
+			return nil
+		case comment:
+			comment = !strings.HasSuffix(line, "'/")
+			return nil
+		case strings.HasPrefix(line, "/'"):
+			comment = !strings.HasSuffix(line, "'/")
+			return nil
+		case skipUntil != nil:
+			if skipUntil.MatchString(line) {
+				skipUntil = nil
+			}
+			return nil
+		case line[0] == '\'', strings.HasPrefix(line, "@startuml"), strings.HasPrefix(line, "@enduml"):
+			return nil
+		}
+
+		if match := plantParticipant.FindStringSubmatch(line); match != nil {
+			// both "Long Name" as L and L as "Long Name" declare an alias
+			name, id := match[1], match[2]
+			if id == "" {
+				id = name
+			} else if !strings.HasPrefix(name, `"`) && strings.HasPrefix(id, `"`) {
+				name, id = id, name
+			}
+			name, id = strings.Trim(name, `"`), strings.Trim(id, `"`)
+			if match[3] != "" {
+				imp.warn("participant options %q are not supported", match[3])
+			}
+			imp.declare(id, strings.Replace(name, `\n`, "\n", -1))
+			return nil
+		}
+
+		if match := plantMessage.FindStringSubmatch(line); match != nil {
+			from, kind, to, activation, text := match[1], match[2], match[3], match[4], match[5]
+			from, to = strings.Trim(from, `"`), strings.Trim(to, `"`)
+			if strings.Contains(kind, "<") {
+				from, to = to, from
+			}
+
+			var style arrow
+			if s := plantArrowStyle.FindStringSubmatch(kind); s != nil {
+				c, err := diagram.ParseHexColor(s[1])
+				if err != nil {
+					imp.warn("arrow style %q is not supported", s[1])
+				}
+				style.stroke = c
+				kind = plantArrowStyle.ReplaceAllString(kind, "")
+			}
+			style.dotted = strings.Contains(kind, "--")
+			style.failed = strings.Contains(kind, "x")
+
+			message := imp.send(from, to, strings.Replace(text, `\n`, "\n", -1), style)
+			switch strings.TrimSpace(activation) {
+			case "++":
+				imp.dia.activateAfter(message.To, message)
+			case "--":
+				imp.deactivate(message.From, message)
+			case "**", "!!":
+				imp.warn("creating and destroying participants is not supported")
+			}
+			return nil
+		}
+
+		if match := plantActivation.FindStringSubmatch(line); match != nil {
+			name := imp.name(strings.Trim(match[2], `"`))
+			if strings.EqualFold(match[1], "activate") {
+				imp.dia.activateAfter(name, imp.dia.lastMessage())
+			} else {
+				imp.deactivate(name, imp.dia.lastMessage())
+			}
+			return nil
+		}
+
+		if match := plantNote.FindStringSubmatch(line); match != nil {
+			if strings.Contains(line, ":") {
+				imp.note(match[1], strings.Replace(match[2], `\n`, "\n", -1))
+			} else {
+				note = match[1]
+			}
+			return nil
+		}
+
+		if strings.HasPrefix(line, "...") || strings.HasPrefix(line, "|||") {
+			// delays and spacing leave a gap before the next message
+			if line[0] == '.' {
+				imp.sleep += 1
+			} else {
+				imp.sleep += imp.dia.AutoSleep
+			}
+			return nil
+		}
+
+		if match := plantBlock.FindStringSubmatch(line); match != nil {
+			keyword, guard := strings.ToLower(match[1]), match[2]
+			switch keyword {
+			case "alt", "opt", "loop", "par", "break", "critical":
+				imp.begin(Operator(keyword), guard)
+			case "group":
+				// group label [secondary label]
+				label := guard
+				guard = ""
+				if i := strings.IndexByte(label, '['); i >= 0 {
+					label, guard = label[:i], strings.Trim(label[i:], "[] ")
+				}
+				imp.begin(Operator(strings.TrimSpace(label)), guard)
+			case "else":
+				imp.operand(keyword, guard)
+			case "end":
+				imp.end()
+			default:
+				imp.ignore(keyword)
+			}
+			return nil
+		}
+
+		keyword := strings.Fields(line)[0]
+		isNote := equalFold(keyword, "note", "hnote", "rnote")
+		switch {
+		case strings.HasPrefix(keyword, "=="):
+			keyword = "=="
+		case isNote && !strings.Contains(line, ":"):
+			skipUntil = plantNoteEnd
+		case equalFold(keyword, "ref") && !strings.Contains(line, ":"):
+			skipUntil = plantRefEnd
+		case equalFold(keyword, "skinparam") && strings.HasSuffix(line, "{"):
+			skipUntil = plantSkinparamEnd
+		}
+
+		if isNote || equalFold(keyword, "ref") {
+			// notes attached to messages or named notes
+			imp.warn("%q is not supported", keyword)
+			return nil
+		}
+		if equalFold(keyword, plantKeywords...) {
+			imp.warn("%q is not supported", keyword)
+			return nil
+		}
+		imp.warn("unrecognized %q", line)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	imp.finish()
+
+	return imp.dia, imp.warnings, nil
+}
+
+// equalFold returns whether s is equal to any of names, ignoring case.
+func equalFold(s string, names ...string) bool {
+	for _, name := range names {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,11 @@
 package diagram
 
-import "image/color"
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strconv"
+)
 
 type Style struct {
 	Stroke color.Color
@@ -8,13 +13,17 @@ type Style struct {
 	Size   Length
 
 	// line only
-	Dash       []Length
-	DashOffset []Length
+	Dash        []Length
+	DashOffset  []Length
+	StartMarker Marker
+	EndMarker   Marker
 
 	// text only
-	Font     string
-	Rotation float64
-	Origin   Point // {-1..1, -1..1}
+	Font       string
+	Rotation   float64
+	Origin     Point   // {-1..1, -1..1}
+	Wrap       Length  // maximum line width, 0 disables wrapping
+	LineHeight float64 // relative to Size, defaults to 1.2
 
 	// SVG
 	Hint  string
@@ -42,3 +51,71 @@ func (style *Style) Or(other Style) *Style {
 	copy := *style
 	return &copy
 }
+
+func (style Style) MarshalJSON() ([]byte, error) {
+	type plainStyle Style
+	encoded := struct {
+		Stroke string `json:",omitempty"`
+		Fill   string `json:",omitempty"`
+		*plainStyle
+	}{plainStyle: (*plainStyle)(&style)}
+
+	if style.Stroke != nil {
+		encoded.Stroke = convertColorToHex(style.Stroke)
+	}
+	if style.Fill != nil {
+		encoded.Fill = convertColorToHex(style.Fill)
+	}
+
+	return json.Marshal(encoded)
+}
+
+func (style *Style) UnmarshalJSON(data []byte) error {
+	type plainStyle Style
+	decoded := struct {
+		Stroke string
+		Fill   string
+		*plainStyle
+	}{plainStyle: (*plainStyle)(style)}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	var err error
+	style.Stroke, err = ParseHexColor(decoded.Stroke)
+	if err != nil {
+		return fmt.Errorf("invalid stroke: %v", err)
+	}
+	style.Fill, err = ParseHexColor(decoded.Fill)
+	if err != nil {
+		return fmt.Errorf("invalid fill: %v", err)
+	}
+	return nil
+}
+
+// ParseHexColor parses colors in the format #rrggbb or #rrggbbaa,
+// an empty string results in a nil color.
+func ParseHexColor(s string) (color.Color, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '#' || (len(s) != 7 && len(s) != 9) {
+		return nil, fmt.Errorf("expected #rrggbb or #rrggbbaa, got %q", s)
+	}
+
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("expected #rrggbb or #rrggbbaa, got %q", s)
+	}
+	if len(s) == 7 {
+		v = v<<8 | 0xFF
+	}
+
+	return color.NRGBA{
+		R: uint8(v >> 24),
+		G: uint8(v >> 16),
+		B: uint8(v >> 8),
+		A: uint8(v),
+	}, nil
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"math"
 	"sort"
+	"strings"
 )
 
 type SVG struct {
@@ -18,7 +19,9 @@ type SVG struct {
 type svgContext struct {
 	index int
 	clip  bool
-	// bounds relative to parent
+	// transform relative to parent
+	transform Affine
+	// bounds in local coordinates
 	bounds   Rect
 	elements []svgElement
 	layers   []*svgContext
@@ -29,6 +32,8 @@ type svgElement struct {
 	style Style
 	// line
 	points []Point
+	// path
+	path *Path
 	// text
 	text   string
 	origin Point
@@ -38,6 +43,7 @@ type svgElement struct {
 
 func NewSVG(width, height Length) *SVG {
 	svg := &SVG{}
+	svg.transform = Identity()
 	svg.Style = `text { text-shadow: -1px -1px 0 rgba(255,255,255,0.5),	1px -1px 0 rgba(255,255,255,0.5), 1px  1px 0 rgba(255,255,255,0.5), -1px  1px 0 rgba(255,255,255,0.5); }`
 	svg.bounds.Max.X = width
 	svg.bounds.Max.Y = height
@@ -55,20 +61,27 @@ func (svg *SVG) Bytes() []byte {
 	return buffer.Bytes()
 }
 
-func (svg *svgContext) Bounds() Rect { return svg.bounds.Zero() }
+func (svg *svgContext) Bounds() Rect { return svg.bounds }
 func (svg *svgContext) Size() Point  { return svg.bounds.Size() }
 
-func (svg *svgContext) context(r Rect, clip bool) Canvas {
+func (svg *svgContext) context(transform Affine, bounds Rect, clip bool) Canvas {
 	element := svgElement{}
 	element.context = &svgContext{}
 	element.context.clip = clip
-	element.context.bounds = r
+	element.context.transform = transform
+	element.context.bounds = bounds
 	svg.elements = append(svg.elements, element)
 	return element.context
 }
 
-func (svg *svgContext) Context(r Rect) Canvas { return svg.context(r, false) }
-func (svg *svgContext) Clip(r Rect) Canvas    { return svg.context(r, true) }
+func (svg *svgContext) Context(r Rect) Canvas { return svg.context(Translate(r.Min), r.Zero(), false) }
+func (svg *svgContext) Clip(r Rect) Canvas    { return svg.context(Translate(r.Min), r.Zero(), true) }
+
+func (svg *svgContext) Transform(m Affine) Canvas {
+	m = m.orIdentity()
+	inv, _ := m.Invert()
+	return svg.context(m, inv.ApplyRect(svg.bounds), false)
+}
 
 func (svg *svgContext) Layer(index int) Canvas {
 	if index == 0 {
@@ -78,13 +91,14 @@ func (svg *svgContext) Layer(index int) Canvas {
 	i := sort.Search(len(svg.layers), func(i int) bool {
 		return svg.layers[i].index > index
 	})
-	if i < len(svg.layers) && svg.layers[i].index == index {
-		return svg.layers[i]
+	if i > 0 && svg.layers[i-1].index == index {
+		return svg.layers[i-1]
 	}
 
 	layer := &svgContext{}
 	layer.index = index
-	layer.bounds = svg.bounds.Zero()
+	layer.transform = Identity()
+	layer.bounds = svg.bounds
 
 	svg.layers = append(svg.layers, layer)
 	copy(svg.layers[i+1:], svg.layers[i:])
@@ -114,6 +128,17 @@ func (svg *svgContext) Rect(r Rect, style *Style) {
 	svg.Poly(r.Points(), style)
 }
 
+func (svg *svgContext) Path(path *Path, style *Style) {
+	style.mustExist()
+	if path == nil {
+		return
+	}
+	svg.elements = append(svg.elements, svgElement{
+		path:  path.clone(),
+		style: *style,
+	})
+}
+
 func (svg *SVG) WriteTo(dst io.Writer) (n int64, err error) {
 	w := &writer{}
 	w.Writer = dst
@@ -142,13 +167,15 @@ func (svg *SVG) WriteTo(dst io.Writer) (n int64, err error) {
 		if svg.clip {
 			id++
 			size := svg.bounds.Size()
-			w.Print(`<clipPath id='clip%v'><rect x='0' y='0' width='%v' height='%v' /></clipPath>`, id, size.X, size.Y)
+			w.Print(`<clipPath id='clip%v'><rect x='%v' y='%v' width='%v' height='%v' /></clipPath>`, id, svg.bounds.Min.X, svg.bounds.Min.Y, size.X, size.Y)
 		}
 
 		w.Printf(`<g`)
 		w.Printf(` loov:index='%v'`, svg.index)
-		if svg.bounds.Min.X != 0 || svg.bounds.Min.Y != 0 {
-			w.Printf(` transform='translate(%.2f %.2f)'`, svg.bounds.Min.X, svg.bounds.Min.Y)
+		if m := svg.transform.orIdentity(); !m.IsTranslation() {
+			w.Printf(` transform='matrix(%v %v %v %v %v %v)'`, m.A, m.B, m.C, m.D, m.E, m.F)
+		} else if m.E != 0 || m.F != 0 {
+			w.Printf(` transform='translate(%.2f %.2f)'`, m.E, m.F)
 		}
 		if svg.clip {
 			w.Printf(` clip-path='url(#clip%v)'`, id)
@@ -157,7 +184,7 @@ func (svg *SVG) WriteTo(dst io.Writer) (n int64, err error) {
 		w.Print(">")
 		defer w.Print(`</g>`)
 
-		after := 0
+		after := len(svg.layers)
 		for i, layer := range svg.layers {
 			if layer.index >= 0 {
 				after = i
@@ -196,22 +223,49 @@ func (svg *SVG) WriteTo(dst io.Writer) (n int64, err error) {
 				w.Printf(`<title>`)
 				xml.EscapeText(w, []byte(el.style.Hint))
 				w.Printf(`</title>`)
-				w.Print(`'</polyline>`)
+				w.Print(`</polyline>`)
+			} else {
+				w.Print(`' />`)
+			}
+		}
+		if !el.path.Empty() {
+			w.Printf(`<path `)
+			w.writePolyStyle(&el.style)
+			w.Printf(` d='`)
+			w.writePathData(el.path)
+			if el.style.Hint != "" {
+				w.Print(`' >`)
+				w.Printf(`<title>`)
+				xml.EscapeText(w, []byte(el.style.Hint))
+				w.Printf(`</title>`)
+				w.Print(`</path>`)
 			} else {
 				w.Print(`' />`)
 			}
 		}
 		if el.text != "" {
-			w.Printf(`<g transform='translate(%.2f,%2.f)'>`, el.origin.X, el.origin.Y)
+			w.Printf(`<g transform='translate(%.2f,%.2f)'>`, el.origin.X, el.origin.Y)
+			lines := TextLines(el.text, &el.style)
 			w.Printf(`<text `)
-			w.writeTextStyle(&el.style)
+			w.writeTextStyle(&el.style, len(lines) == 1)
 			w.Printf(`>`)
 			if el.style.Hint != "" {
 				w.Printf(`<title>`)
 				xml.EscapeText(w, []byte(el.style.Hint))
 				w.Printf(`</title>`)
 			}
-			xml.EscapeText(w, []byte(el.text))
+			if len(lines) == 1 {
+				xml.EscapeText(w, []byte(el.text))
+			} else {
+				// baselines are positioned explicitly, since
+				// alignment-baseline does not apply to the whole block
+				layout := layoutText(el.text, &el.style)
+				for _, line := range layout.lines {
+					w.Printf(`<tspan x='0' y='%.2f'>`, line.offset.Y)
+					xml.EscapeText(w, []byte(line.text))
+					w.Printf(`</tspan>`)
+				}
+			}
 			w.Print(`</text></g>`)
 		}
 		if el.context != nil {
@@ -220,11 +274,104 @@ func (svg *SVG) WriteTo(dst io.Writer) (n int64, err error) {
 	}
 
 	writeLayer(&svg.svgContext)
+	w.writeMarkerDefs()
 
 	return w.total, w.err
 }
 
-func (w *writer) writeTextStyle(style *Style) {
+type svgMarker struct {
+	marker Marker
+	start  bool
+	color  string
+}
+
+func (m svgMarker) id() string {
+	position := "end"
+	if m.start {
+		position = "start"
+	}
+	return fmt.Sprintf("marker-%v-%v-%v", m.marker, position, strings.TrimPrefix(m.color, "#"))
+}
+
+func (w *writer) useMarker(marker Marker, start bool, stroke color.Color) string {
+	m := svgMarker{marker: marker, start: start, color: convertColorToHex(stroke)}
+	if w.markers == nil {
+		w.markers = map[string]svgMarker{}
+	}
+	id := m.id()
+	w.markers[id] = m
+	return id
+}
+
+func (w *writer) writeMarkerDefs() {
+	if len(w.markers) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(w.markers))
+	for id := range w.markers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	w.Print(`<defs>`)
+	defer w.Print(`</defs>`)
+
+	for _, id := range ids {
+		m := w.markers[id]
+		w.Print(`<marker id='%v' markerUnits='strokeWidth' orient='auto' overflow='visible' markerWidth='1' markerHeight='1'>`, id)
+
+		// start markers point backwards along the line
+		angle := 0.0
+		if m.start {
+			angle = math.Pi
+		}
+		lines, fills := m.marker.place(Point{}, angle, 1)
+		for _, line := range lines {
+			w.Printf(`<polyline style='fill: none;stroke: %v;stroke-width: 1;' points='`, m.color)
+			for _, p := range line {
+				w.Printf(`%.2f,%.2f `, p.X, p.Y)
+			}
+			w.Print(`' />`)
+		}
+		for _, fill := range fills {
+			w.Printf(`<polygon style='fill: %v;stroke: none;' points='`, m.color)
+			for _, p := range fill {
+				w.Printf(`%.2f,%.2f `, p.X, p.Y)
+			}
+			w.Print(`' />`)
+		}
+
+		w.Print(`</marker>`)
+	}
+}
+
+func (w *writer) writePathData(path *Path) {
+	for i, cmd := range path.Commands {
+		if i > 0 {
+			w.Printf(` `)
+		}
+		w.Printf(`%v`, cmd.Verb)
+		if cmd.Arc != nil {
+			w.Printf(` %.2f %.2f %.2f %v %v`,
+				cmd.Arc.Radius.X, cmd.Arc.Radius.Y,
+				cmd.Arc.Rotation*180/math.Pi,
+				svgFlag(cmd.Arc.Large), svgFlag(cmd.Arc.Sweep))
+		}
+		for _, p := range cmd.Points {
+			w.Printf(` %.2f,%.2f`, p.X, p.Y)
+		}
+	}
+}
+
+func svgFlag(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func (w *writer) writeTextStyle(style *Style, align bool) {
 	// TODO: merge with writePolyStyle
 	if style.Class != "" {
 		w.Printf(` class='`)
@@ -244,7 +391,9 @@ func (w *writer) writeTextStyle(style *Style) {
 		w.Printf(`text-anchor="start" `)
 	}
 
-	if style.Origin.Y == 0 {
+	if !align {
+		// baseline is positioned by the caller
+	} else if style.Origin.Y == 0 {
 		w.Printf(`alignment-baseline="middle" `)
 	} else if style.Origin.Y == 1 {
 		w.Printf(`alignment-baseline="baseline" `)
@@ -316,41 +465,33 @@ func (w *writer) writePolyStyle(style *Style) {
 		w.Printf(`stroke-width: %vpx;`, style.Size)
 	}
 
+	if style.Stroke != nil && style.StartMarker != MarkerNone {
+		w.Printf(`marker-start: url(#%v);`, w.useMarker(style.StartMarker, true, style.Stroke))
+	}
+	if style.Stroke != nil && style.EndMarker != MarkerNone {
+		w.Printf(`marker-end: url(#%v);`, w.useMarker(style.EndMarker, false, style.Stroke))
+	}
+
 	// TODO: dash
 }
 
-func convertColorToHex(color color.Color) string {
-	r, g, b, a := color.RGBA()
-	if a > 0 {
-		r, g, b, a = r*0xff/a, g*0xff/a, b*0xff/a, a/0xff
-		if r > 0xFF {
-			r = 0xFF
-		}
-		if g > 0xFF {
-			g = 0xFF
-		}
-		if b > 0xFF {
-			b = 0xFF
-		}
-		if a > 0xFF {
-			a = 0xFF
-		}
-	} else {
-		r, g, b, a = 0, 0, 0, 0
+func convertColorToHex(c color.Color) string {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0 {
+		return "#00000000"
 	}
-	if a == 0xFF {
-		hex := r<<16 | g<<8 | b<<0
-		return fmt.Sprintf("#%06x", hex)
+	if nrgba.A == 0xFF {
+		return fmt.Sprintf("#%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B)
 	}
-
-	hex := r<<24 | g<<16 | b<<8 | a
-	return fmt.Sprintf("#%08x", hex)
+	return fmt.Sprintf("#%02x%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B, nrgba.A)
 }
 
 type writer struct {
 	io.Writer
 	total int64
 	err   error
+
+	markers map[string]svgMarker
 }
 
 func (w *writer) Errored() bool { return w.err != nil }
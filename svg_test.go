@@ -0,0 +1,128 @@
+package diagram_test
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+)
+
+func TestSVGLayers(t *testing.T) {
+	svg := diagram.NewSVG(20, 20)
+	svg.Layer(-1).Text("below", diagram.P(0, 0), &diagram.Style{})
+	svg.Text("middle", diagram.P(0, 0), &diagram.Style{})
+	svg.Layer(1).Text("above", diagram.P(0, 0), &diagram.Style{})
+	svg.Layer(-1).Text("below", diagram.P(0, 0), &diagram.Style{})
+
+	if svg.Layer(1) != svg.Layer(1) || svg.Layer(-1) != svg.Layer(-1) {
+		t.Errorf("layers should be reused")
+	}
+
+	out := string(svg.Bytes())
+	if n := strings.Count(out, ">below<"); n != 2 {
+		t.Errorf("expected layer -1 to be written once with 2 texts, got %d texts", n)
+	}
+	below, middle, above := strings.Index(out, ">below<"), strings.Index(out, ">middle<"), strings.Index(out, ">above<")
+	if !(below < middle && middle < above) {
+		t.Errorf("invalid layer order:\n%s", out)
+	}
+
+	// canvas with only negative layers
+	only := diagram.NewSVG(20, 20)
+	only.Layer(-1).Text("below", diagram.P(0, 0), &diagram.Style{})
+	if n := strings.Count(string(only.Bytes()), ">below<"); n != 1 {
+		t.Errorf("expected negative layer to be written once, got %d", n)
+	}
+}
+
+// svgLines returns the text and baseline of each tspan in out.
+func svgLines(t *testing.T, out string) (texts []string, baselines []float64) {
+	t.Helper()
+	for _, match := range regexp.MustCompile(`<tspan x='0' y='([-\d.]+)'>([^<]*)</tspan>`).FindAllStringSubmatch(out, -1) {
+		y, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, match[2])
+		baselines = append(baselines, y)
+	}
+	return texts, baselines
+}
+
+func TestSVGMultilineText(t *testing.T) {
+	const precision = 0.01
+	near := func(a, b float64) bool { return math.Abs(a-b) <= precision }
+
+	for _, test := range []struct {
+		name  string
+		text  string
+		style diagram.Style
+		lines []string
+	}{
+		{"newlines", "first\nsecond\nthird", diagram.Style{Size: 10}, []string{"first", "second", "third"}},
+		{"wrapped", "first second third", diagram.Style{Size: 10, Wrap: 40}, []string{"first", "second", "third"}},
+		{"line height", "first\nsecond\nthird", diagram.Style{Size: 10, LineHeight: 2}, []string{"first", "second", "third"}},
+	} {
+		lineHeight := test.style.LineHeight
+		if lineHeight == 0 {
+			lineHeight = 1.2
+		}
+		lineHeight *= test.style.Size
+
+		first := map[float64]float64{}
+		ascent, descent := map[float64]float64{}, map[float64]float64{}
+		for _, origin := range []float64{-1, 0, 1} {
+			style := test.style
+			style.Origin = diagram.P(0, origin)
+
+			svg := diagram.NewSVG(100, 100)
+			svg.Text(test.text, diagram.P(50, 50), &style)
+			out := string(svg.Bytes())
+
+			if !strings.Contains(out, "<g transform='translate(50.00,50.00)'>") {
+				t.Errorf("%v/%v: text should be anchored at 50,50:\n%s", test.name, origin, out)
+			}
+			if strings.Contains(out, "alignment-baseline") {
+				t.Errorf("%v/%v: baselines should be explicit:\n%s", test.name, origin, out)
+			}
+
+			texts, baselines := svgLines(t, out)
+			if strings.Join(texts, "|") != strings.Join(test.lines, "|") {
+				t.Fatalf("%v/%v: expected lines %q, got %q", test.name, origin, test.lines, texts)
+			}
+			for i := 1; i < len(baselines); i++ {
+				if !near(baselines[i]-baselines[i-1], lineHeight) {
+					t.Errorf("%v/%v: expected line height %v, got %v", test.name, origin, lineHeight, baselines)
+				}
+			}
+
+			// the lines move with the box reported by the measurer
+			box := diagram.DefaultMeasurer.Measure(test.text, &style)
+			if h := box.Size().Y; h < float64(len(texts)-1)*lineHeight {
+				t.Errorf("%v/%v: measured height %v is smaller than the lines", test.name, origin, h)
+			}
+			last := baselines[len(baselines)-1]
+			if origin == 1 && !near(last, 0) {
+				t.Errorf("%v/%v: last baseline should be at the anchor, got %v", test.name, origin, last)
+			}
+			ascent[origin], descent[origin] = baselines[0]-box.Min.Y, box.Max.Y-last
+			first[origin] = baselines[0]
+			for i, y := range baselines {
+				if y < box.Min.Y || y > box.Max.Y {
+					t.Errorf("%v/%v: baseline %d at %v outside of measured %v", test.name, origin, i, y, box)
+				}
+			}
+		}
+
+		// the middle origin is halfway between the top and the bottom
+		if !near(first[0], (first[-1]+first[1])/2) {
+			t.Errorf("%v: baselines %v should be centered", test.name, first)
+		}
+		if !near(ascent[-1], ascent[0]) || !near(ascent[0], ascent[1]) || !near(descent[-1], descent[0]) || !near(descent[0], descent[1]) {
+			t.Errorf("%v: baselines should move with the measured box, ascent %v descent %v", test.name, ascent, descent)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package tree
+
+import (
+	"math"
+
+	"loov.dev/diagram"
+)
+
+// layout positions all nodes and returns the size of the diagram.
+func (dia *Diagram) layout() diagram.Point {
+	padding := dia.Theme.Padding
+	if dia.Root == nil {
+		return diagram.P(2*padding, 2*padding)
+	}
+
+	var prepare func(node, parent *Node, depth, number int)
+	prepare = func(node, parent *Node, depth, number int) {
+		node.parent = parent
+		node.depth = depth
+		node.number = number
+		node.size = dia.measure(node)
+		if dia.Layout == Tidy && dia.Direction == LeftRight {
+			node.size.X, node.size.Y = node.size.Y, node.size.X
+		}
+		node.prelim, node.mod, node.shift, node.change = 0, 0, 0, 0
+		node.thread, node.ancestor = nil, node
+		for i, child := range node.Children {
+			prepare(child, node, depth+1, i)
+		}
+	}
+	prepare(dia.Root, nil, 0, 0)
+
+	var size diagram.Point
+	switch dia.Layout {
+	case Indented:
+		size = dia.indented()
+	case Radial:
+		size = dia.radial()
+	default:
+		size = dia.tidy()
+	}
+
+	dia.Root.Walk(func(node *Node) {
+		node.Bounds = node.Bounds.Offset(diagram.P(padding, padding))
+	})
+	return size.Add(diagram.P(2*padding, 2*padding))
+}
+
+// indented places each node on its own row.
+func (dia *Diagram) indented() diagram.Point {
+	var size diagram.Point
+	y := diagram.Length(0)
+	dia.Root.Walk(func(node *Node) {
+		x := float64(node.depth) * dia.Theme.Indent
+		node.Bounds = diagram.R(x, y, x+node.size.X, y+node.size.Y)
+		size = size.Max(node.Bounds.Max)
+		y += node.size.Y + dia.Theme.SiblingSpacing/2
+	})
+	return size
+}
+
+// tidy places nodes using the Reingold-Tilford algorithm.
+func (dia *Diagram) tidy() diagram.Point {
+	dia.walk()
+
+	// levels are as deep as their largest node
+	var depth []diagram.Length
+	dia.Root.Walk(func(node *Node) {
+		for len(depth) <= node.depth {
+			depth = append(depth, 0)
+		}
+		depth[node.depth] = math.Max(depth[node.depth], node.size.Y)
+	})
+	along := make([]diagram.Length, len(depth))
+	offset := diagram.Length(0)
+	for i := range depth {
+		if i > 0 {
+			offset += dia.Theme.LevelSpacing
+		}
+		along[i] = offset + depth[i]/2
+		offset += depth[i]
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+	dia.Root.Walk(func(node *Node) {
+		min = math.Min(min, node.across-node.size.X/2)
+		max = math.Max(max, node.across+node.size.X/2)
+	})
+
+	point := func(across, along diagram.Length) diagram.Point {
+		if dia.Direction == LeftRight {
+			return diagram.Point{X: along, Y: across}
+		}
+		return diagram.Point{X: across, Y: along}
+	}
+
+	dia.Root.Walk(func(node *Node) {
+		c := point(node.across-min, along[node.depth])
+		half := point(node.size.X/2, node.size.Y/2)
+		node.Bounds = diagram.Rect{Min: c.Sub(half), Max: c.Add(half)}
+	})
+	return point(max-min, offset)
+}
+
+// radial places the levels of a tidy layout on concentric circles.
+func (dia *Diagram) radial() diagram.Point {
+	dia.walk()
+
+	// leave space between the first and the last node of the outer levels
+	min, max := math.Inf(1), math.Inf(-1)
+	levels := 0
+	largest := diagram.Length(0)
+	dia.Root.Walk(func(node *Node) {
+		min = math.Min(min, node.across-node.size.X/2)
+		max = math.Max(max, node.across+node.size.X/2)
+		if node.depth > levels {
+			levels = node.depth
+		}
+		largest = math.Max(largest, math.Hypot(node.size.X, node.size.Y))
+	})
+	span := max - min + dia.Theme.SiblingSpacing
+
+	ring := largest + dia.Theme.LevelSpacing
+	if levels > 0 {
+		// the first circle must fit all the nodes, since
+		// the angles of the outer levels are the same
+		ring = math.Max(ring, span/(2*math.Pi))
+	}
+
+	bounds := diagram.Rect{Min: diagram.P(math.Inf(1), math.Inf(1)), Max: diagram.P(math.Inf(-1), math.Inf(-1))}
+	dia.Root.Walk(func(node *Node) {
+		angle := 2 * math.Pi * (node.across - min) / span
+		c := diagram.Polar(diagram.Point{}, float64(node.depth)*ring, angle-math.Pi/2)
+		half := node.size.Scale(0.5)
+		node.Bounds = diagram.Rect{Min: c.Sub(half), Max: c.Add(half)}
+
+		bounds.Min = bounds.Min.Min(node.Bounds.Min)
+		bounds.Max = bounds.Max.Max(node.Bounds.Max)
+	})
+
+	dia.Root.Walk(func(node *Node) {
+		node.Bounds = node.Bounds.Offset(bounds.Min.Neg())
+	})
+	return bounds.Size()
+}
+
+// walk assigns the across position of all nodes, using the linear time
+// version of Reingold-Tilford by Buchheim, Jünger and Leipert.
+func (dia *Diagram) walk() {
+	dia.firstWalk(dia.Root)
+	secondWalk(dia.Root, -dia.Root.prelim)
+}
+
+// separation returns the minimum distance between the centers of a and b.
+func (dia *Diagram) separation(a, b *Node) diagram.Length {
+	return (a.size.X+b.size.X)/2 + dia.Theme.SiblingSpacing
+}
+
+// leftSibling returns the previous sibling of node.
+func (node *Node) leftSibling() *Node {
+	if node.parent == nil || node.number == 0 {
+		return nil
+	}
+	return node.parent.Children[node.number-1]
+}
+
+// leftmostSibling returns the first child of the parent of node.
+func (node *Node) leftmostSibling() *Node {
+	if node.parent == nil || node.number == 0 {
+		return nil
+	}
+	return node.parent.Children[0]
+}
+
+// nextLeft returns the next node on the left contour.
+func (node *Node) nextLeft() *Node {
+	if len(node.Children) > 0 {
+		return node.Children[0]
+	}
+	return node.thread
+}
+
+// nextRight returns the next node on the right contour.
+func (node *Node) nextRight() *Node {
+	if len(node.Children) > 0 {
+		return node.Children[len(node.Children)-1]
+	}
+	return node.thread
+}
+
+// firstWalk computes preliminary positions bottom up.
+func (dia *Diagram) firstWalk(node *Node) {
+	left := node.leftSibling()
+	if len(node.Children) == 0 {
+		if left != nil {
+			node.prelim = left.prelim + dia.separation(left, node)
+		}
+		return
+	}
+
+	defaultAncestor := node.Children[0]
+	for _, child := range node.Children {
+		dia.firstWalk(child)
+		defaultAncestor = dia.apportion(child, defaultAncestor)
+	}
+	executeShifts(node)
+
+	first, last := node.Children[0], node.Children[len(node.Children)-1]
+	mid := (first.prelim + last.prelim) / 2
+	if left != nil {
+		node.prelim = left.prelim + dia.separation(left, node)
+		node.mod = node.prelim - mid
+	} else {
+		node.prelim = mid
+	}
+}
+
+// apportion moves the subtree of node away from its left siblings,
+// such that the contours don't overlap.
+func (dia *Diagram) apportion(node, defaultAncestor *Node) *Node {
+	left := node.leftSibling()
+	if left == nil {
+		return defaultAncestor
+	}
+
+	// inner and outer contours on the right (p) and left (m) side
+	insideRight, outsideRight := node, node
+	insideLeft, outsideLeft := left, node.leftmostSibling()
+	sumInsideRight, sumOutsideRight := insideRight.mod, outsideRight.mod
+	sumInsideLeft, sumOutsideLeft := insideLeft.mod, outsideLeft.mod
+
+	for insideLeft.nextRight() != nil && insideRight.nextLeft() != nil {
+		insideLeft = insideLeft.nextRight()
+		insideRight = insideRight.nextLeft()
+		outsideLeft = outsideLeft.nextLeft()
+		outsideRight = outsideRight.nextRight()
+		outsideRight.ancestor = node
+
+		shift := (insideLeft.prelim + sumInsideLeft) - (insideRight.prelim + sumInsideRight) + dia.separation(insideLeft, insideRight)
+		if shift > 0 {
+			moveSubtree(ancestor(insideLeft, node, defaultAncestor), node, shift)
+			sumInsideRight += shift
+			sumOutsideRight += shift
+		}
+
+		sumInsideLeft += insideLeft.mod
+		sumInsideRight += insideRight.mod
+		sumOutsideLeft += outsideLeft.mod
+		sumOutsideRight += outsideRight.mod
+	}
+
+	if insideLeft.nextRight() != nil && outsideRight.nextRight() == nil {
+		outsideRight.thread = insideLeft.nextRight()
+		outsideRight.mod += sumInsideLeft - sumOutsideRight
+	}
+	if insideRight.nextLeft() != nil && outsideLeft.nextLeft() == nil {
+		outsideLeft.thread = insideRight.nextLeft()
+		outsideLeft.mod += sumInsideRight - sumOutsideLeft
+		defaultAncestor = node
+	}
+	return defaultAncestor
+}
+
+// ancestor returns the ancestor of contour that is a sibling of node.
+func ancestor(contour, node, defaultAncestor *Node) *Node {
+	if contour.ancestor.parent == node.parent {
+		return contour.ancestor
+	}
+	return defaultAncestor
+}
+
+// moveSubtree shifts the subtree of right and spreads the shift
+// over the subtrees between left and right.
+func moveSubtree(left, right *Node, shift diagram.Length) {
+	subtrees := float64(right.number - left.number)
+	right.change -= shift / subtrees
+	right.shift += shift
+	left.change += shift / subtrees
+	right.prelim += shift
+	right.mod += shift
+}
+
+// executeShifts applies the shifts accumulated by moveSubtree to children.
+func executeShifts(node *Node) {
+	shift, change := diagram.Length(0), diagram.Length(0)
+	for i := len(node.Children) - 1; i >= 0; i-- {
+		child := node.Children[i]
+		child.prelim += shift
+		child.mod += shift
+		change += child.change
+		shift += child.shift + change
+	}
+}
+
+// secondWalk computes final positions by summing modifiers top down.
+func secondWalk(node *Node, mod diagram.Length) {
+	node.across = node.prelim + mod
+	for _, child := range node.Children {
+		secondWalk(child, mod+node.mod)
+	}
+}
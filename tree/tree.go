@@ -0,0 +1,229 @@
+// Package tree implements drawing of node hierarchies.
+//
+// Three layouts are supported: Tidy places children below their parent
+// using the Reingold-Tilford algorithm, Indented places each node on its own
+// row similar to a file browser, and Radial places levels on concentric circles.
+package tree
+
+import (
+	"image/color"
+	"math"
+
+	"loov.dev/diagram"
+)
+
+// Layout is the method used to position nodes.
+type Layout int
+
+const (
+	// Tidy places children centered below their parent,
+	// subtrees are packed as closely as possible.
+	Tidy Layout = iota
+	// Indented places each node on its own row,
+	// indented by the depth of the node.
+	Indented
+	// Radial places each level on a circle around the root.
+	Radial
+)
+
+// Direction is the direction in which levels of a Tidy layout are stacked.
+type Direction int
+
+const (
+	TopDown Direction = iota
+	LeftRight
+)
+
+// Node is a node in the hierarchy.
+type Node struct {
+	Text     string
+	Children []*Node
+
+	Style   diagram.Style
+	Caption diagram.Style
+
+	// Bounds is the position of the node after layout.
+	Bounds diagram.Rect
+
+	parent *Node
+	depth  int
+	// number is the index among siblings.
+	number int
+	// size is the node size, X across and Y along the levels.
+	size diagram.Point
+
+	// state for the Reingold-Tilford algorithm
+	prelim, mod, shift, change diagram.Length
+	thread, ancestor           *Node
+	across                     diagram.Length
+}
+
+// NewNode creates a node with the specified children.
+func NewNode(text string, children ...*Node) *Node {
+	return &Node{Text: text, Children: children}
+}
+
+// Add adds children to the node.
+func (node *Node) Add(children ...*Node) *Node {
+	node.Children = append(node.Children, children...)
+	return node
+}
+
+// Child returns the child with the specified text,
+// creating it when it doesn't exist.
+func (node *Node) Child(text string) *Node {
+	for _, child := range node.Children {
+		if child.Text == text {
+			return child
+		}
+	}
+	child := NewNode(text)
+	node.Children = append(node.Children, child)
+	return child
+}
+
+// Walk calls fn for node and all of its descendants in depth first order.
+func (node *Node) Walk(fn func(node *Node)) {
+	fn(node)
+	for _, child := range node.Children {
+		child.Walk(fn)
+	}
+}
+
+// Diagram draws a hierarchy starting from Root.
+type Diagram struct {
+	Root *Node
+
+	Layout    Layout
+	Direction Direction
+
+	// Measurer is used to size nodes to fit their text.
+	Measurer diagram.Measurer
+
+	Theme struct {
+		NodeWidth      diagram.Length // minimum node width
+		NodeHeight     diagram.Length // minimum node height
+		NodePadding    diagram.Length // space between text and outline
+		SiblingSpacing diagram.Length // space between neighbouring nodes
+		LevelSpacing   diagram.Length // space between levels
+		Indent         diagram.Length // indentation per level in Indented layout
+		Padding        diagram.Length // space around the diagram
+
+		Node    diagram.Style
+		Caption diagram.Style
+		Link    diagram.Style
+	}
+}
+
+// New creates a tree diagram with the default theme.
+func New(root *Node) *Diagram {
+	dia := &Diagram{}
+	dia.Root = root
+	dia.Measurer = diagram.DefaultMeasurer
+
+	dia.Theme.NodeWidth = 40
+	dia.Theme.NodeHeight = 24
+	dia.Theme.NodePadding = 6
+	dia.Theme.SiblingSpacing = 12
+	dia.Theme.LevelSpacing = 36
+	dia.Theme.Indent = 24
+	dia.Theme.Padding = 10
+
+	dia.Theme.Node = diagram.Style{
+		Stroke: color.NRGBA{0x30, 0x30, 0x30, 0xFF},
+		Fill:   color.NRGBA{0xEE, 0xF3, 0xFA, 0xFF},
+		Size:   1,
+	}
+	dia.Theme.Caption = diagram.Style{
+		Fill: color.NRGBA{0x10, 0x10, 0x10, 0xFF},
+		Size: 12,
+	}
+	dia.Theme.Link = diagram.Style{
+		Stroke: color.NRGBA{0x60, 0x60, 0x60, 0xFF},
+		Size:   1,
+	}
+
+	return dia
+}
+
+// Size returns the size needed to draw the diagram.
+func (dia *Diagram) Size() (width, height float64) {
+	size := dia.layout()
+	return size.X, size.Y
+}
+
+// Draw lays out and draws the diagram.
+func (dia *Diagram) Draw(canvas diagram.Canvas) {
+	if dia.Root == nil {
+		return
+	}
+	dia.layout()
+
+	links := canvas.Layer(0)
+	nodes := canvas.Layer(1)
+	texts := canvas.Layer(2)
+
+	dia.Root.Walk(func(node *Node) {
+		for _, child := range node.Children {
+			links.Poly(dia.link(node, child), &dia.Theme.Link)
+		}
+
+		style := node.Style.Or(dia.Theme.Node)
+		nodes.Path(diagram.RoundedRect(node.Bounds, 3), style)
+
+		caption := *node.Caption.Or(dia.Theme.Caption)
+		caption.Origin = diagram.P(0, 0)
+		texts.Text(node.Text, center(node.Bounds), &caption)
+	})
+}
+
+// center returns the center of r.
+func center(r diagram.Rect) diagram.Point {
+	return r.UnitLocation(diagram.P(0, 0))
+}
+
+// link returns the line connecting parent to child.
+func (dia *Diagram) link(parent, child *Node) []diagram.Point {
+	p, c := parent.Bounds, child.Bounds
+	switch dia.Layout {
+	case Indented:
+		x := p.Min.X + dia.Theme.Indent/2
+		y := center(c).Y
+		return diagram.Ps(x, p.Max.Y, x, y, c.Min.X, y)
+	case Radial:
+		return []diagram.Point{center(p), center(c)}
+	}
+
+	// elbow in the middle of the space between levels
+	if dia.Direction == LeftRight {
+		mid := (p.Max.X + c.Min.X) / 2
+		return diagram.Ps(
+			p.Max.X, center(p).Y,
+			mid, center(p).Y,
+			mid, center(c).Y,
+			c.Min.X, center(c).Y,
+		)
+	}
+	mid := (p.Max.Y + c.Min.Y) / 2
+	return diagram.Ps(
+		center(p).X, p.Max.Y,
+		center(p).X, mid,
+		center(c).X, mid,
+		center(c).X, c.Min.Y,
+	)
+}
+
+// measure returns the size of node fitted to its text.
+func (dia *Diagram) measure(node *Node) diagram.Point {
+	measurer := dia.Measurer
+	if measurer == nil {
+		measurer = diagram.DefaultMeasurer
+	}
+
+	text := measurer.Measure(node.Text, node.Caption.Or(dia.Theme.Caption)).Size()
+	padding := dia.Theme.NodePadding
+	return diagram.Point{
+		X: math.Max(text.X+2*padding, dia.Theme.NodeWidth),
+		Y: math.Max(text.Y+2*padding, dia.Theme.NodeHeight),
+	}
+}
@@ -0,0 +1,125 @@
+package tree_test
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"loov.dev/diagram"
+	"loov.dev/diagram/tree"
+)
+
+func center(node *tree.Node) diagram.Point {
+	return node.Bounds.UnitLocation(diagram.P(0, 0))
+}
+
+func overlaps(a, b diagram.Rect) bool {
+	return a.Min.X < b.Max.X && b.Min.X < a.Max.X &&
+		a.Min.Y < b.Max.Y && b.Min.Y < a.Max.Y
+}
+
+func example() *tree.Node {
+	root := tree.NewNode("loov.dev/diagram")
+	root.Child("plot").Add(
+		tree.NewNode("axis"),
+		tree.NewNode("scale"),
+	)
+	root.Child("sequence")
+	root.Child("tree").Child("TestLayout").Add(
+		tree.NewNode("Tidy"),
+		tree.NewNode("Indented"),
+		tree.NewNode("Radial"),
+	)
+	root.Child("flowchart").Child("TestLayout")
+	return root
+}
+
+// wide returns a root with many leaves and a single deep chain.
+func wide() *tree.Node {
+	root := tree.NewNode("root")
+	for i := 1; i <= 12; i++ {
+		root.Child("leaf " + strconv.Itoa(i))
+	}
+	root.Child("a").Child("b").Child("c").Child("d")
+	return root
+}
+
+func nodes(root *tree.Node) []*tree.Node {
+	var all []*tree.Node
+	root.Walk(func(node *tree.Node) { all = append(all, node) })
+	return all
+}
+
+func TestLayout(t *testing.T) {
+	for _, shape := range []func() *tree.Node{example, wide} {
+		for _, layout := range []tree.Layout{tree.Tidy, tree.Indented, tree.Radial} {
+			for _, direction := range []tree.Direction{tree.TopDown, tree.LeftRight} {
+				dia := tree.New(shape())
+				dia.Layout = layout
+				dia.Direction = direction
+
+				width, height := dia.Size()
+				svg := diagram.NewSVG(width, height)
+				dia.Draw(svg)
+
+				output := string(svg.Bytes())
+				if strings.Contains(output, "NaN") || strings.Contains(output, "Inf") {
+					t.Errorf("%v/%v: invalid output:\n%s", layout, direction, output)
+				}
+
+				all := nodes(dia.Root)
+				for i, a := range all {
+					if a.Bounds.Min.X < 0 || a.Bounds.Min.Y < 0 || a.Bounds.Max.X > width || a.Bounds.Max.Y > height {
+						t.Errorf("%v/%v: %v outside of diagram: %v", layout, direction, a.Text, a.Bounds)
+					}
+					for _, b := range all[i+1:] {
+						if overlaps(a.Bounds, b.Bounds) {
+							t.Errorf("%v/%v: %v and %v overlap: %v %v", layout, direction, a.Text, b.Text, a.Bounds, b.Bounds)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTidy(t *testing.T) {
+	dia := tree.New(example())
+	dia.Size()
+
+	dia.Root.Walk(func(node *tree.Node) {
+		if len(node.Children) == 0 {
+			return
+		}
+		first, last := node.Children[0], node.Children[len(node.Children)-1]
+		mid := (center(first).X + center(last).X) / 2
+		if math.Abs(center(node).X-mid) > 1e-6 {
+			t.Errorf("%v not centered above children: %v, expected %v", node.Text, center(node).X, mid)
+		}
+		for _, child := range node.Children {
+			if node.Bounds.Max.Y >= child.Bounds.Min.Y {
+				t.Errorf("%v not below %v", child.Text, node.Text)
+			}
+		}
+	})
+}
+
+func TestIndented(t *testing.T) {
+	dia := tree.New(example())
+	dia.Layout = tree.Indented
+	dia.Size()
+
+	var previous *tree.Node
+	dia.Root.Walk(func(node *tree.Node) {
+		if previous != nil && previous.Bounds.Max.Y > node.Bounds.Min.Y {
+			t.Errorf("%v should be below %v", node.Text, previous.Text)
+		}
+		for _, child := range node.Children {
+			if child.Bounds.Min.X <= node.Bounds.Min.X {
+				t.Errorf("%v should be indented from %v", child.Text, node.Text)
+			}
+		}
+		previous = node
+	})
+}